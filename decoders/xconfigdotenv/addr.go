@@ -0,0 +1,70 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	tcpAddrType = reflect.TypeFor[net.TCPAddr]()
+	udpAddrType = reflect.TypeFor[net.UDPAddr]()
+)
+
+// parseTCPAddr parses a net.TCPAddr from an IP address and a port, as in
+// 1.2.3.4:80, [::1]:80 or :80, without resolving host names.
+func parseTCPAddr(rawVal string) (net.TCPAddr, error) {
+	ip, port, zone, err := parseHostPort(rawVal)
+	return net.TCPAddr{IP: ip, Port: port, Zone: zone}, err
+}
+
+// parseUDPAddr parses a net.UDPAddr as parseTCPAddr does a net.TCPAddr.
+func parseUDPAddr(rawVal string) (net.UDPAddr, error) {
+	ip, port, zone, err := parseHostPort(rawVal)
+	return net.UDPAddr{IP: ip, Port: port, Zone: zone}, err
+}
+
+// parseHostPort splits rawVal into an IP address, with its IPv6 zone, and
+// a port number. The host may be left out, giving a nil IP, but not be a
+// name, which only WithResolveAddrs looks up.
+func parseHostPort(rawVal string) (net.IP, int, string, error) {
+	host, portText, err := net.SplitHostPort(strings.TrimSpace(rawVal))
+	if err != nil {
+		return nil, 0, "", err
+	}
+	port, err := strconv.ParseUint(portText, 10, 16)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("expecting a port number, got %q", portText)
+	}
+	if host == "" {
+		return nil, int(port), "", nil
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("expecting an IP address, got %q, which is only resolved with WithResolveAddrs", host)
+	}
+	return addr.Unmap().AsSlice(), int(port), addr.Zone(), nil
+}
+
+// setResolvedAddr sets fieldVal, if it is a net.TCPAddr or a net.UDPAddr,
+// to the address net.ResolveTCPAddr or net.ResolveUDPAddr gives for rawVal.
+// It reports whether fieldVal is of either type.
+func setResolvedAddr(fieldVal reflect.Value, rawVal string) (bool, error) {
+	var addr any
+	var err error
+	switch fieldVal.Type() {
+	case tcpAddrType:
+		addr, err = net.ResolveTCPAddr("tcp", strings.TrimSpace(rawVal))
+	case udpAddrType:
+		addr, err = net.ResolveUDPAddr("udp", strings.TrimSpace(rawVal))
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return true, fmt.Errorf("cannot parse %q as %s: %w", rawVal, fieldVal.Type(), err)
+	}
+	return true, setWithReflect(fieldVal, reflect.ValueOf(addr).Elem())
+}
@@ -0,0 +1,66 @@
+package xconfigdotenv_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddrs(t *testing.T) {
+	var config struct {
+		Listen  *net.TCPAddr
+		Metrics net.TCPAddr
+		Any     net.TCPAddr
+		DNS     []net.UDPAddr
+		Peers   []*net.TCPAddr
+	}
+
+	data := []byte(`LISTEN=1.2.3.4:80
+METRICS=[::1]:9090
+ANY=:8080
+DNS=8.8.8.8:53,[2001:4860:4860::8888]:53
+PEERS_0=[fe80::1%eth0]:7000`)
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+	assert.Equal(t, &net.TCPAddr{IP: net.IP{1, 2, 3, 4}, Port: 80}, config.Listen)
+	assert.Equal(t, net.TCPAddr{IP: net.IPv6loopback, Port: 9090}, config.Metrics)
+	assert.Equal(t, net.TCPAddr{Port: 8080}, config.Any)
+	assert.Equal(t, []net.UDPAddr{
+		{IP: net.IP{8, 8, 8, 8}, Port: 53},
+		{IP: net.ParseIP("2001:4860:4860::8888"), Port: 53},
+	}, config.DNS)
+	assert.Equal(t, []*net.TCPAddr{{IP: net.ParseIP("fe80::1"), Port: 7000, Zone: "eth0"}}, config.Peers)
+
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, `LISTEN=1.2.3.4:80
+METRICS="[::1]:9090"
+ANY=:8080
+DNS="8.8.8.8:53,[2001:4860:4860::8888]:53"
+PEERS_0="[fe80::1%eth0]:7000"
+`, string(out))
+
+	// host names are not looked up by default
+	err = decoder.Unmarshal([]byte("LISTEN=localhost:80"), &config)
+	assert.ErrorContains(t, err, `expecting an IP address, got "localhost", which is only resolved with WithResolveAddrs`)
+	err = decoder.Unmarshal([]byte("LISTEN=1.2.3.4:http"), &config)
+	assert.ErrorContains(t, err, `expecting a port number, got "http"`)
+	err = decoder.Unmarshal([]byte("LISTEN=1.2.3.4"), &config)
+	assert.ErrorContains(t, err, `cannot parse "1.2.3.4" as net.TCPAddr`)
+
+	// resolving parses IP addresses and service names without a lookup
+	var resolved struct {
+		Listen *net.TCPAddr
+		DNS    []net.UDPAddr
+	}
+	resolving := xconfigdotenv.New(xconfigdotenv.WithResolveAddrs())
+	assert.NoError(t, resolving.Unmarshal([]byte("LISTEN=[::1]:80\nDNS=8.8.8.8:53"), &resolved))
+	assert.Equal(t, &net.TCPAddr{IP: net.IPv6loopback, Port: 80}, resolved.Listen)
+	if assert.Len(t, resolved.DNS, 1) {
+		assert.Equal(t, "8.8.8.8:53", resolved.DNS[0].String())
+	}
+	err = resolving.Unmarshal([]byte("LISTEN=1.2.3.4"), &resolved)
+	assert.ErrorContains(t, err, `cannot parse "1.2.3.4" as net.TCPAddr`)
+}
@@ -0,0 +1,108 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxAnyIndex bounds the slice indices of the values built for any, which
+// have no `maxlen` tag: a single key with a huge index would otherwise
+// allocate a slice as large.
+const maxAnyIndex = 1 << 16
+
+// isAnyType reports whether t is the empty interface.
+func isAnyType(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.NumMethod() == 0
+}
+
+// setAnyMapValue stores rawVal in mapVal, a map with string keys and any
+// values, under the key segments, building nested values as needed.
+//
+// A numeric segment is a slice index, a run of other segments is joined
+// back with '_' into a single map key:
+//
+//	META_build_id=7       → {"build_id": "7"}
+//	META_tags_0=a         → {"tags": ["a"]}
+//	META_hosts_0_port=80  → {"hosts": [{"port": "80"}]}
+//
+// The first segment is always part of the key of mapVal. A value set by a
+// key is replaced by a container when a longer key descends into it.
+func setAnyMapValue(mapVal reflect.Value, segments []string, rawVal string) error {
+	if mapVal.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("unsupported map key type %s; only string keys allowed", mapVal.Type().Key().Kind())
+	}
+
+	key, rest := splitAnyKey(segments)
+
+	var cur any
+	if existing := mapVal.MapIndex(reflect.ValueOf(key).Convert(mapVal.Type().Key())); existing.IsValid() {
+		cur = existing.Interface()
+	}
+
+	value, err := setAnyPath(cur, rest, rawVal)
+	if err != nil {
+		return err
+	}
+	return storeMapValue(mapVal, key, reflect.ValueOf(value))
+}
+
+// setAnyPath stores rawVal in cur under the segments and returns the updated
+// value; cur is replaced when it is not a container of the expected kind.
+// An index beyond maxAnyIndex is an error.
+func setAnyPath(cur any, segments []string, rawVal string) (any, error) {
+	if len(segments) == 0 {
+		return rawVal, nil
+	}
+
+	if isIndex(segments[0]) {
+		ix, err := strconv.Atoi(segments[0])
+		if err != nil || ix > maxAnyIndex {
+			return nil, fmt.Errorf("index %s is beyond the limit of %d", segments[0], maxAnyIndex)
+		}
+		list, _ := cur.([]any)
+		if ix >= len(list) {
+			list = append(list, make([]any, ix+1-len(list))...)
+		}
+		if list[ix], err = setAnyPath(list[ix], segments[1:], rawVal); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+
+	key, rest := splitAnyKey(segments)
+
+	m, ok := cur.(map[string]any)
+	if !ok {
+		m = make(map[string]any)
+	}
+	value, err := setAnyPath(m[key], rest, rawVal)
+	if err != nil {
+		return nil, err
+	}
+	m[key] = value
+	return m, nil
+}
+
+// splitAnyKey joins the leading segments up to the next index into a map key.
+func splitAnyKey(segments []string) (string, []string) {
+	i := 1
+	for i < len(segments) && !isIndex(segments[i]) {
+		i++
+	}
+	return strings.Join(segments[:i], "_"), segments[i:]
+}
+
+// isIndex reports whether the segment is a slice index.
+func isIndex(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
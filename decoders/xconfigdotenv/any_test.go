@@ -0,0 +1,50 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnyMapNesting(t *testing.T) {
+	data := []byte(`
+META_build_id=7
+META_tags_0=a
+META_tags_1=b
+META_hosts_0_name=x
+META_hosts_0_port=80
+META_hosts_1_name=y
+META_matrix_1_0=m
+META_replaced=scalar
+META_replaced_0=item
+`)
+
+	var config struct {
+		Meta map[string]any
+	}
+	err := xconfigdotenv.New().Unmarshal(data, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"build_id": "7",
+		"tags":     []any{"a", "b"},
+		"hosts": []any{
+			map[string]any{"name": "x", "port": "80"},
+			map[string]any{"name": "y"},
+		},
+		"matrix":   []any{nil, []any{"m"}},
+		"replaced": []any{"item"},
+	}, config.Meta)
+}
+
+func TestAnyMapIndexLimit(t *testing.T) {
+	var config struct {
+		Meta map[string]any
+	}
+	err := xconfigdotenv.New().Unmarshal([]byte("META_tags_999999999=a"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "META_tags_999999999": index 999999999 is beyond the limit of 65536`)
+
+	assert.NoError(t, xconfigdotenv.New().Unmarshal([]byte("META_tags_2=a"), &config))
+	assert.Equal(t, map[string]any{"tags": []any{nil, nil, "a"}}, config.Meta)
+}
@@ -0,0 +1,60 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+)
+
+// atomicTypes maps the typed values of sync/atomic to the type they hold.
+var atomicTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeFor[atomic.Bool]():    reflect.TypeFor[bool](),
+	reflect.TypeFor[atomic.Int32]():   reflect.TypeFor[int32](),
+	reflect.TypeFor[atomic.Int64]():   reflect.TypeFor[int64](),
+	reflect.TypeFor[atomic.Uint32]():  reflect.TypeFor[uint32](),
+	reflect.TypeFor[atomic.Uint64]():  reflect.TypeFor[uint64](),
+	reflect.TypeFor[atomic.Uintptr](): reflect.TypeFor[uintptr](),
+}
+
+// isAtomicType reports whether t is a typed value of sync/atomic, such as
+// atomic.Int64, decoded as the scalar it holds.
+func isAtomicType(t reflect.Type) bool {
+	_, ok := atomicTypes[t]
+	return ok
+}
+
+// atomicPointer returns a pointer to the atomic value v, which must be
+// addressable; unexported fields are reached through unsafe.
+func atomicPointer(v reflect.Value) (reflect.Value, error) {
+	if !v.CanAddr() {
+		return reflect.Value{}, fmt.Errorf("cannot access the unaddressable %s", v.Type())
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())), nil
+}
+
+// setAtomicValue parses rawVal as the value held by the atomic fieldVal and
+// stores it with Store.
+func (s *decodeState) setAtomicValue(fieldVal reflect.Value, rawVal string) error {
+	ptr, err := atomicPointer(fieldVal)
+	if err != nil {
+		return err
+	}
+	value := reflect.New(atomicTypes[fieldVal.Type()]).Elem()
+	if err := s.setBasicValue(value, rawVal); err != nil {
+		return err
+	}
+	ptr.MethodByName("Store").Call([]reflect.Value{value})
+	return nil
+}
+
+// atomicValue returns the value held by the atomic v, read with Load.
+func atomicValue(v reflect.Value) reflect.Value {
+	if !v.CanAddr() {
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(v)
+		v = cp
+	}
+	ptr, _ := atomicPointer(v)
+	return ptr.MethodByName("Load").Call(nil)[0]
+}
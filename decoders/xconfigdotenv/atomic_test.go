@@ -0,0 +1,47 @@
+package xconfigdotenv_test
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAtomicFields(t *testing.T) {
+	type config struct {
+		MaxConns atomic.Int64
+		Enabled  atomic.Bool
+		Limit    atomic.Uint32 `default:"100"`
+		Pool     *atomic.Int32
+		Sizes    struct {
+			Cache atomic.Uint64
+		}
+		retries atomic.Int32
+	}
+
+	var cfg struct{ App *config }
+	decoder := xconfigdotenv.New()
+	data := []byte("APP_MAX_CONNS=10\nAPP_ENABLED=true\nAPP_POOL=4\nAPP_SIZES_CACHE=65536\nAPP_RETRIES=3")
+	assert.NoError(t, decoder.Unmarshal(data, &cfg))
+	if assert.NotNil(t, cfg.App) {
+		assert.Equal(t, int64(10), cfg.App.MaxConns.Load())
+		assert.True(t, cfg.App.Enabled.Load())
+		assert.Equal(t, uint32(100), cfg.App.Limit.Load())
+		if assert.NotNil(t, cfg.App.Pool) {
+			assert.Equal(t, int32(4), cfg.App.Pool.Load())
+		}
+		assert.Equal(t, uint64(64<<10), cfg.App.Sizes.Cache.Load())
+		assert.Equal(t, int32(3), cfg.App.retries.Load())
+	}
+
+	out, err := decoder.Marshal(&cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "APP_MAX_CONNS=10\nAPP_ENABLED=true\nAPP_LIMIT=100\nAPP_POOL=4\nAPP_SIZES_CACHE=65536\nAPP_RETRIES=3\n", string(out))
+
+	err = decoder.Unmarshal([]byte("APP_MAX_CONNS=ten"), &cfg)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "APP_MAX_CONNS": cannot parse "ten" as int: strconv.ParseInt: parsing "ten": invalid syntax`)
+
+	err = decoder.Unmarshal([]byte("APP_MAX_CONNS_V=1"), &cfg)
+	assert.ErrorContains(t, err, `shape mismatch: cannot assign subkey "V" to struct field "MaxConns"; did you mean APP_MAX_CONNS?`)
+}
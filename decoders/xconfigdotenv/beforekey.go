@@ -0,0 +1,44 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"sort"
+)
+
+// beforeEachKey applies the hook of WithBeforeEachKey to the keys of
+// flatMap, in key order, and returns the resulting keys. A key the hook
+// renames onto another key is handled by the DuplicateKeyPolicy, as a key
+// defined twice: the last one in key order wins.
+func (s *decodeState) beforeEachKey(flatMap map[string]string) (map[string]string, error) {
+	if s.opts.beforeEachKey == nil {
+		return flatMap, nil
+	}
+
+	keys := make([]string, 0, len(flatMap))
+	for key := range flatMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make(map[string]string, len(flatMap))
+	from := make(map[string]string, len(flatMap))
+	for _, key := range keys {
+		newKey, value, keep := s.opts.beforeEachKey(key, flatMap[key])
+		if !keep {
+			continue
+		}
+		if first, ok := from[newKey]; ok {
+			switch s.opts.duplicateKeyPolicy {
+			case DuplicateError:
+				return nil, &KeyError{Key: newKey, Err: fmt.Errorf("%w: given by keys %s and %s", ErrDuplicateKey, first, key)}
+			case DuplicateWarn:
+				s.key = newKey
+				s.warn("key given by keys %s and %s, the last value wins", first, key)
+				s.key = ""
+			}
+		}
+		out[newKey] = value
+		from[newKey] = key
+	}
+	return out, nil
+}
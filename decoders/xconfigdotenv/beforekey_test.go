@@ -0,0 +1,85 @@
+package xconfigdotenv_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBeforeEachKey(t *testing.T) {
+	type config struct {
+		Host  string
+		DBURL string `env:"DB_URL"`
+		Port  int
+		Debug bool
+	}
+
+	data := []byte(`BASE=example.com
+LEGACY_HOST=api.${BASE}
+DATABASE=postgres://db/app
+PORT=8080
+TMP_DEBUG=true`)
+	var seen []string
+	decoder := xconfigdotenv.New(xconfigdotenv.WithBeforeEachKey(func(key, value string) (string, string, bool) {
+		seen = append(seen, key+"="+value)
+		switch {
+		case strings.HasPrefix(value, "postgres://"):
+			// routed on the value
+			return "DB_URL", value, true
+		case strings.HasPrefix(key, "TMP_"):
+			return key, value, false
+		}
+		return strings.TrimPrefix(key, "LEGACY_"), strings.TrimSpace(value), true
+	}))
+
+	var c config
+	meta, err := decoder.UnmarshalWithMetadata(data, &c)
+	assert.NoError(t, err)
+	assert.Equal(t, config{Host: "api.example.com", DBURL: "postgres://db/app", Port: 8080}, c)
+	// the hook sees the expanded values, in key order
+	assert.Equal(t, []string{
+		"BASE=example.com",
+		"DATABASE=postgres://db/app",
+		"LEGACY_HOST=api.example.com",
+		"PORT=8080",
+		"TMP_DEBUG=true",
+	}, seen)
+	// dropped keys are gone before matching
+	assert.NotContains(t, meta.Flat, "TMP_DEBUG")
+	assert.Equal(t, 4, meta.Metrics.KeysProcessed)
+	assert.Equal(t, 1, meta.Metrics.KeysIgnored)
+}
+
+func TestBeforeEachKeyCollision(t *testing.T) {
+	type config struct{ Host string }
+
+	data := []byte("HOST=a\nOLD_HOST=b")
+	rename := func(key, value string) (string, string, bool) {
+		return strings.TrimPrefix(key, "OLD_"), value, true
+	}
+
+	// the last key in key order wins
+	var c config
+	err := xconfigdotenv.New(xconfigdotenv.WithBeforeEachKey(rename)).Unmarshal(data, &c)
+	assert.NoError(t, err)
+	assert.Equal(t, config{Host: "b"}, c)
+
+	meta, err := xconfigdotenv.New(
+		xconfigdotenv.WithBeforeEachKey(rename),
+		xconfigdotenv.WithDuplicateKeyPolicy(xconfigdotenv.DuplicateWarn),
+	).UnmarshalWithMetadata(data, &c)
+	assert.NoError(t, err)
+	assert.Equal(t, []xconfigdotenv.Warning{
+		{Key: "HOST", Message: "key given by keys HOST and OLD_HOST, the last value wins"},
+	}, meta.Warnings)
+
+	err = xconfigdotenv.New(
+		xconfigdotenv.WithBeforeEachKey(rename),
+		xconfigdotenv.WithDuplicateKeyPolicy(xconfigdotenv.DuplicateError),
+	).Unmarshal(data, &c)
+	assert.True(t, errors.Is(err, xconfigdotenv.ErrDuplicateKey))
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "HOST": duplicate key: given by keys HOST and OLD_HOST`)
+}
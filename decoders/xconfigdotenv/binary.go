@@ -0,0 +1,52 @@
+package xconfigdotenv
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+)
+
+var (
+	binaryUnmarshalerType = reflect.TypeFor[encoding.BinaryUnmarshaler]()
+	binaryMarshalerType   = reflect.TypeFor[encoding.BinaryMarshaler]()
+)
+
+// isBinaryType reports whether the values of t are decoded by their
+// UnmarshalBinary method: whether *t implements encoding.BinaryUnmarshaler,
+// such as url.URL, with no converter registered for t, which comes first.
+// The types implementing encoding.TextUnmarshaler as well, such as
+// time.Time and netip.Addr, are left out, as their binary form is not
+// their text.
+func isBinaryType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr || converter(t) != nil {
+		return false
+	}
+	pt := reflect.PointerTo(t)
+	return pt.Implements(binaryUnmarshalerType) && !pt.Implements(textUnmarshalerType)
+}
+
+// setBinaryValue sets fieldVal, of a type for which isBinaryType is true,
+// to the value UnmarshalBinary decodes from the bytes of rawVal, as they
+// are. It serves fields, slice elements and map values alike, so a type
+// decoding a hex or base64 text in its UnmarshalBinary method may be used
+// in any of them.
+func setBinaryValue(fieldVal reflect.Value, rawVal string) error {
+	ptr := reflect.New(fieldVal.Type())
+	if err := ptr.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary([]byte(rawVal)); err != nil {
+		return fmt.Errorf("cannot parse %q as %s: %w", rawVal, fieldVal.Type(), err)
+	}
+	return setWithReflect(fieldVal, ptr.Elem())
+}
+
+// binaryText returns the bytes MarshalBinary gives for v, when its type is
+// decoded by setBinaryValue, so Marshal writes what Unmarshal reads.
+func binaryText(v reflect.Value) (string, bool, error) {
+	if !isBinaryType(v.Type()) || !reflect.PointerTo(v.Type()).Implements(binaryMarshalerType) {
+		return "", false, nil
+	}
+	// Copy v, whose method may need a pointer receiver
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	data, err := ptr.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+	return string(data), true, err
+}
@@ -0,0 +1,87 @@
+package xconfigdotenv_test
+
+import (
+	"encoding/hex"
+	"net/url"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+// hexKey decodes itself from a hex text with UnmarshalBinary.
+type hexKey struct {
+	b []byte
+}
+
+func (k *hexKey) UnmarshalBinary(data []byte) error {
+	b, err := hex.DecodeString(string(data))
+	if err != nil {
+		return err
+	}
+	k.b = b
+	return nil
+}
+
+func (k *hexKey) MarshalBinary() ([]byte, error) {
+	return []byte(hex.EncodeToString(k.b)), nil
+}
+
+func TestBinaryUnmarshaler(t *testing.T) {
+	var config struct {
+		Key     hexKey
+		Backup  *hexKey
+		Keys    []hexKey
+		KeysMap map[string]hexKey
+		Mirrors []*url.URL
+		Hooks   map[string]url.URL
+	}
+
+	data := []byte(`KEY=cafe
+BACKUP=00ff
+KEYS=01,0203
+KEYS_MAP_PRIMARY=beef
+MIRRORS=https://a.example/x,https://b.example
+HOOKS_DEPLOY=https://ci.example/hook?id=1`)
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+	assert.Equal(t, []byte{0xca, 0xfe}, config.Key.b)
+	if assert.NotNil(t, config.Backup) {
+		assert.Equal(t, []byte{0x00, 0xff}, config.Backup.b)
+	}
+	assert.Equal(t, []hexKey{{[]byte{0x01}}, {[]byte{0x02, 0x03}}}, config.Keys)
+	assert.Equal(t, map[string]hexKey{"PRIMARY": {[]byte{0xbe, 0xef}}}, config.KeysMap)
+	if assert.Len(t, config.Mirrors, 2) {
+		assert.Equal(t, "a.example", config.Mirrors[0].Host)
+		assert.Equal(t, "/x", config.Mirrors[0].Path)
+		assert.Equal(t, "b.example", config.Mirrors[1].Host)
+	}
+	hook := config.Hooks["DEPLOY"]
+	assert.Equal(t, "ci.example", hook.Host)
+	assert.Equal(t, "id=1", hook.RawQuery)
+
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, `KEY=cafe
+BACKUP=00ff
+KEYS=01,0203
+KEYS_MAP_PRIMARY=beef
+MIRRORS_0=https://a.example/x
+MIRRORS_1=https://b.example
+HOOKS_DEPLOY="https://ci.example/hook?id=1"
+`, string(out))
+
+	var decoded struct {
+		Key     hexKey
+		Backup  *hexKey
+		Keys    []hexKey
+		KeysMap map[string]hexKey
+		Mirrors []*url.URL
+		Hooks   map[string]url.URL
+	}
+	assert.NoError(t, decoder.Unmarshal(out, &decoded))
+	assert.Equal(t, config, decoded)
+
+	err = decoder.Unmarshal([]byte("KEYS_MAP_PRIMARY=xyz"), &config)
+	assert.ErrorContains(t, err, `cannot parse "xyz" as xconfigdotenv_test.hexKey: encoding/hex: invalid byte`)
+}
@@ -0,0 +1,33 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BoolTokens are extra tokens of boolean values, see WithBoolTokens.
+type BoolTokens struct {
+	// True and False list the tokens of true and false.
+	True  []string
+	False []string
+	// CaseSensitive compares the tokens as they are, instead of ignoring
+	// their case.
+	CaseSensitive bool
+}
+
+// parseBool parses the boolean rawVal: the tokens of WithBoolTokens come
+// first, then the literals of strconv.ParseBool.
+func (s *decodeState) parseBool(rawVal string) (bool, error) {
+	if b, ok := s.opts.boolTokens[rawVal]; ok {
+		return b, nil
+	}
+	if b, ok := s.opts.foldedBoolTokens[strings.ToLower(rawVal)]; ok {
+		return b, nil
+	}
+	b, err := strconv.ParseBool(rawVal)
+	if err != nil {
+		return false, fmt.Errorf("cannot parse %q as bool: %w", rawVal, err)
+	}
+	return b, nil
+}
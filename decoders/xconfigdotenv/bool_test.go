@@ -0,0 +1,45 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoolTokens(t *testing.T) {
+	type config struct {
+		Cache   bool
+		Debug   bool
+		Metrics bool
+		Tracing bool
+		Legacy  bool
+		Flags   []bool
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithBoolTokens(xconfigdotenv.BoolTokens{
+		True:  []string{"yes", "sí"},
+		False: []string{"no"},
+	}))
+	var c config
+	assert.NoError(t, decoder.Unmarshal([]byte("CACHE=yes\nDEBUG=NO\nMETRICS=Sí\nTRACING=true\nLEGACY=0\nFLAGS=yes,no,1"), &c))
+	assert.Equal(t, config{Cache: true, Metrics: true, Tracing: true, Flags: []bool{true, false, true}}, c)
+
+	err := decoder.Unmarshal([]byte("CACHE=maybe"), &c)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "CACHE": cannot parse "maybe" as bool: strconv.ParseBool: parsing "maybe": invalid syntax`)
+
+	// case-sensitive tokens only match as they are
+	decoder = xconfigdotenv.New(xconfigdotenv.WithBoolTokens(xconfigdotenv.BoolTokens{
+		True:          []string{"Y"},
+		False:         []string{"N"},
+		CaseSensitive: true,
+	}))
+	c = config{}
+	assert.NoError(t, decoder.Unmarshal([]byte("CACHE=Y\nDEBUG=N"), &c))
+	assert.True(t, c.Cache)
+	assert.False(t, c.Debug)
+	assert.Error(t, decoder.Unmarshal([]byte("CACHE=y"), &c))
+
+	// without tokens only the strconv.ParseBool literals are accepted
+	assert.Error(t, xconfigdotenv.New().Unmarshal([]byte("CACHE=yes"), &c))
+}
@@ -0,0 +1,67 @@
+package xconfigdotenv
+
+import (
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"reflect"
+	"strings"
+)
+
+// parseRGBA parses a color.RGBA from a hex color, see parseHexColor. The
+// components are kept as written, so a translucent color is expected
+// premultiplied by its alpha, as color.RGBA holds it.
+func parseRGBA(rawVal string) (color.RGBA, error) {
+	c, err := parseHexColor(rawVal)
+	return color.RGBA(c), err
+}
+
+// parseNRGBA parses a color.NRGBA from a hex color, see parseHexColor.
+func parseNRGBA(rawVal string) (color.NRGBA, error) {
+	return parseHexColor(rawVal)
+}
+
+// parseHexColor parses a hex color of 3, 4, 6 or 8 digits, with or
+// without a leading '#': #F80, #F80C, #FF8800 or #FF8800CC, the short
+// forms repeating each digit. The alpha is 0xFF when left out.
+func parseHexColor(rawVal string) (color.NRGBA, error) {
+	digits := strings.TrimPrefix(strings.TrimSpace(rawVal), "#")
+	switch len(digits) {
+	case 3, 4:
+		var long strings.Builder
+		for _, d := range digits {
+			long.WriteString(string([]rune{d, d}))
+		}
+		digits = long.String()
+	case 6, 8:
+	default:
+		return color.NRGBA{}, fmt.Errorf("expecting a hex color of 3, 4, 6 or 8 digits, got %d", len(digits))
+	}
+	b, err := hex.DecodeString(digits)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("expecting a hex color: %w", err)
+	}
+	if len(b) == 3 {
+		b = append(b, 0xFF)
+	}
+	return color.NRGBA{R: b[0], G: b[1], B: b[2], A: b[3]}, nil
+}
+
+// colorText returns the hex color of v, in the form parseHexColor reads,
+// when v is a color.RGBA or a color.NRGBA: #FF8800, or #FF880080 when the
+// color is not opaque.
+func colorText(v reflect.Value) (string, bool) {
+	var c color.NRGBA
+	switch v.Type() {
+	case reflect.TypeFor[color.RGBA]():
+		c = color.NRGBA(v.Interface().(color.RGBA))
+	case reflect.TypeFor[color.NRGBA]():
+		c = v.Interface().(color.NRGBA)
+	default:
+		return "", false
+	}
+	if c.A == 0xFF {
+		return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B), true
+	}
+	return fmt.Sprintf("#%02X%02X%02X%02X", c.R, c.G, c.B, c.A), true
+}
@@ -0,0 +1,46 @@
+package xconfigdotenv_test
+
+import (
+	imagecolor "image/color"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHexColors(t *testing.T) {
+	var config struct {
+		BG      imagecolor.RGBA
+		FG      imagecolor.NRGBA
+		Accent  *imagecolor.RGBA
+		Overlay imagecolor.NRGBA
+		Palette []imagecolor.RGBA
+	}
+
+	data := []byte(`BG=#FF8800
+FG=ff8800cc
+ACCENT=#F80
+OVERLAY=#0008
+PALETTE=#000,#FFFFFF`)
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+	assert.Equal(t, imagecolor.RGBA{R: 0xFF, G: 0x88, B: 0x00, A: 0xFF}, config.BG)
+	assert.Equal(t, imagecolor.NRGBA{R: 0xFF, G: 0x88, B: 0x00, A: 0xCC}, config.FG)
+	assert.Equal(t, &imagecolor.RGBA{R: 0xFF, G: 0x88, B: 0x00, A: 0xFF}, config.Accent)
+	assert.Equal(t, imagecolor.NRGBA{A: 0x88}, config.Overlay)
+	assert.Equal(t, []imagecolor.RGBA{{A: 0xFF}, {R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}}, config.Palette)
+
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, `BG="#FF8800"
+FG="#FF8800CC"
+ACCENT="#FF8800"
+OVERLAY="#00000088"
+PALETTE="#000000,#FFFFFF"
+`, string(out))
+
+	err = decoder.Unmarshal([]byte("BG=#FF880"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "BG": cannot parse "#FF880" as color.RGBA: expecting a hex color of 3, 4, 6 or 8 digits, got 5`)
+	err = decoder.Unmarshal([]byte("BG=#FF88ZZ"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "BG": cannot parse "#FF88ZZ" as color.RGBA: expecting a hex color: encoding/hex: invalid byte: U+005A 'Z'`)
+}
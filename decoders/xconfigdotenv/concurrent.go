@@ -0,0 +1,130 @@
+package xconfigdotenv
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// decodeConcurrent works like decodeKeys, decoding the keys of every
+// top-level field of elem on its own goroutine (see WithConcurrentDecode).
+func (s *decodeState) decodeConcurrent(elem reflect.Value, keys []string, flatMap map[string]string, partial *PartialError) error {
+	// Partition the keys by the top-level field they match
+	groups := make(map[int][]string)
+	var order []int
+	var rest []string
+	for _, rawKey := range keys {
+		parts := s.opts.splitKey(rawKey)
+		i, _, _, err := s.match(elem.Type(), parts)
+		_, mapped := s.opts.keyPath(rawKey)
+		prefixed, _ := s.matchPrefix(parts)
+		if mapped || prefixed != nil || err != nil || i < 0 {
+			rest = append(rest, rawKey)
+			continue
+		}
+		if _, ok := groups[i]; !ok {
+			order = append(order, i)
+		}
+		groups[i] = append(groups[i], rawKey)
+	}
+
+	workers := make([]*decodeState, len(order))
+	parts := make([]PartialError, len(order))
+	errs := make([]error, len(order))
+	panics := make([]any, len(order))
+	var wg sync.WaitGroup
+	for w, i := range order {
+		workers[w] = s.worker()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { panics[w] = recover() }()
+			errs[w] = workers[w].decodeKeys(elem, groups[i], flatMap, &parts[w])
+		}()
+	}
+	wg.Wait()
+
+	// Panics of the decoding, e.g. in a converter, are raised by the caller
+	for _, p := range panics {
+		if p != nil {
+			panic(p)
+		}
+	}
+
+	var failed []error
+	for w, ws := range workers {
+		s.merge(ws)
+		if errs[w] != nil {
+			failed = append(failed, errs[w])
+		}
+		partial.Errors = append(partial.Errors, parts[w].Errors...)
+	}
+	if err := s.decodeKeys(elem, rest, flatMap, partial); err != nil {
+		failed = append(failed, err)
+	}
+	sortByKey(failed)
+	sortByKey(partial.Errors)
+	if len(failed) > 0 {
+		return failed[0]
+	}
+	return nil
+}
+
+// worker returns the state of a goroutine of decodeConcurrent, whose
+// results are merged back into s.
+func (s *decodeState) worker() *decodeState {
+	w := &decodeState{
+		opts:       s.opts,
+		assigned:   make(map[string][]int),
+		flat:       s.flat,
+		prefixes:   s.prefixes,
+		sliceSizes: s.sliceSizes,
+	}
+	if s.meta != nil {
+		w.meta = &Metadata{Flat: make(map[string]string)}
+	}
+	return w
+}
+
+// merge adds the metadata, the assigned fields, the Unmarshaler fields and
+// the reset fields of the worker w to s.
+func (s *decodeState) merge(w *decodeState) {
+	s.pending = append(s.pending, w.pending...)
+	for path, ranks := range w.assigned {
+		s.assigned[path] = ranks
+	}
+	for path := range w.resetPaths {
+		if s.resetPaths == nil {
+			s.resetPaths = make(map[string]bool)
+		}
+		s.resetPaths[path] = true
+	}
+	if s.meta == nil {
+		return
+	}
+	s.meta.Metrics.KeysProcessed += w.meta.Metrics.KeysProcessed
+	s.meta.Metrics.KeysMatched += w.meta.Metrics.KeysMatched
+	s.meta.Metrics.KeysIgnored += w.meta.Metrics.KeysIgnored
+	s.meta.Warnings = append(s.meta.Warnings, w.meta.Warnings...)
+	sort.SliceStable(s.meta.Warnings, func(i, j int) bool { return s.meta.Warnings[i].Key < s.meta.Warnings[j].Key })
+	// The worker Flat only holds the masked values
+	for k, v := range w.meta.Flat {
+		s.meta.Flat[k] = v
+	}
+}
+
+// sortByKey sorts the errors by the key of their KeyError, keeping the
+// order of errors of the same key.
+func sortByKey(errs []error) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errorKey(errs[i]) < errorKey(errs[j])
+	})
+}
+
+// errorKey returns the key of the KeyError err, or "".
+func errorKey(err error) string {
+	if keyErr, ok := err.(*KeyError); ok {
+		return keyErr.Key
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package xconfigdotenv_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type concurrentSection struct {
+	Host   string
+	Port   int
+	Tags   []string
+	Labels map[string]string
+	Token  string `redact:"true"`
+}
+
+type concurrentConfig struct {
+	Name  string
+	API   concurrentSection
+	DB    *concurrentSection
+	Cache concurrentSection
+	Hosts []concurrentSection
+	Extra map[string]string `env:",inline"`
+}
+
+// concurrentInput returns an input with n keys per section.
+func concurrentInput(n int) []byte {
+	var b strings.Builder
+	b.WriteString("NAME=app\nUNKNOWN=1\n")
+	for _, section := range []string{"API", "DB", "CACHE", "HOSTS_0", "HOSTS_1"} {
+		fmt.Fprintf(&b, "%s_HOST=%s.local\n%s_PORT=80\n%s_TAGS=a,b\n%s_TOKEN=t0k\n", section, section, section, section, section)
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(&b, "%s_LABELS_k%d=v%d\n", section, i, i)
+		}
+	}
+	return []byte(b.String())
+}
+
+func TestWithConcurrentDecode(t *testing.T) {
+	data := append(concurrentInput(20), "DB_PORT=eighty\nCACHE_PORT=x\nHOSTS_1_PORT=y\nCACHE_TOKEN=s3cr3t\n"...)
+
+	var sequential, concurrent concurrentConfig
+	seqMeta, seqErr := xconfigdotenv.New(xconfigdotenv.WithAllowPartial()).UnmarshalWithMetadata(data, &sequential)
+	conMeta, conErr := xconfigdotenv.New(xconfigdotenv.WithAllowPartial(), xconfigdotenv.WithConcurrentDecode(10)).UnmarshalWithMetadata(data, &concurrent)
+
+	assert.Equal(t, sequential, concurrent)
+	assert.Equal(t, seqErr, conErr)
+	assert.Len(t, conErr.(*xconfigdotenv.PartialError).Errors, 3)
+	assert.Equal(t, seqMeta.Flat, conMeta.Flat)
+	assert.Equal(t, seqMeta.Warnings, conMeta.Warnings)
+	seqMeta.Metrics.Duration, conMeta.Metrics.Duration = 0, 0
+	assert.Equal(t, seqMeta.Metrics, conMeta.Metrics)
+
+	assert.Equal(t, "1", concurrent.Extra["UNKNOWN"])
+	assert.Equal(t, "API.local", concurrent.API.Host)
+	assert.Len(t, concurrent.Hosts, 2)
+
+	// fail-fast reports the error of the first failing key
+	err := xconfigdotenv.New(xconfigdotenv.WithConcurrentDecode(10)).Unmarshal(data, &concurrentConfig{})
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "CACHE_PORT": cannot parse "x" as int: strconv.ParseInt: parsing "x": invalid syntax`)
+
+	// below the threshold, keys are decoded sequentially
+	var small concurrentConfig
+	assert.NoError(t, xconfigdotenv.New(xconfigdotenv.WithConcurrentDecode(0)).Unmarshal([]byte("NAME=app\nAPI_PORT=1"), &small))
+	assert.Equal(t, 1, small.API.Port)
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	data := concurrentInput(2000)
+	for _, bench := range []struct {
+		name string
+		opts []xconfigdotenv.Option
+	}{
+		{"Sequential", nil},
+		{"Concurrent", []xconfigdotenv.Option{xconfigdotenv.WithConcurrentDecode(0)}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			decoder := xconfigdotenv.New(bench.opts...)
+			for i := 0; i < b.N; i++ {
+				var config concurrentConfig
+				if err := decoder.Unmarshal(data, &config); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
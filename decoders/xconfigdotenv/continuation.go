@@ -0,0 +1,54 @@
+package xconfigdotenv
+
+import "bytes"
+
+// joinContinuations joins the lines of src continued by a trailing
+// backslash, see WithLineContinuations. Quoted values and comments are
+// copied as they are.
+func joinContinuations(src []byte) []byte {
+	if !bytes.Contains(src, []byte("\\\n")) && !bytes.Contains(src, []byte("\\\r\n")) {
+		return src
+	}
+
+	var buf bytes.Buffer
+	rest := src
+	for len(rest) > 0 {
+		_, valStart, ok := parseKeyLine(rest)
+		if ok && len(rest) > valStart && (rest[valStart] == '"' || rest[valStart] == '\'') {
+			// the remainder of the line (comment, newline) is copied by the next iteration
+			n := valStart + valueLen(rest[valStart:])
+			buf.Write(rest[:n])
+			rest = rest[n:]
+			continue
+		}
+
+		for ok {
+			end := bytes.IndexByte(rest, '\n')
+			if end < 0 {
+				break
+			}
+			value := rest[valStart : valStart+valueLen(rest[valStart:])]
+			if valStart+len(value) != len(bytes.TrimRight(rest[:end], " \t\r")) || !continued(value) {
+				break
+			}
+			buf.Write(rest[:valStart+len(value)-1])
+			rest = bytes.TrimLeft(rest[end+1:], " \t")
+			valStart = 0
+		}
+
+		end := bytes.IndexByte(rest, '\n') + 1
+		if end == 0 {
+			end = len(rest)
+		}
+		buf.Write(rest[:end])
+		rest = rest[end:]
+	}
+	return buf.Bytes()
+}
+
+// continued reports whether the unquoted value ends with an odd number of
+// backslashes, the last one continuing the value on the next line.
+func continued(value []byte) bool {
+	n := len(value) - len(bytes.TrimRight(value, "\\"))
+	return n%2 == 1
+}
@@ -0,0 +1,54 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineContinuations(t *testing.T) {
+	type config struct {
+		Opts    string
+		Hosts   []string
+		Quoted  string
+		Single  string
+		Path    string
+		Comment string
+		Last    string
+		Plain   string
+	}
+
+	data := []byte(`OPTS=-Xms256m \
+    -Xmx1g \
+	-Dx=1
+HOSTS=a,\
+  b,\` + "\r\n" + `  c
+QUOTED="two \
+lines"
+SINGLE='kept \
+as is'
+PATH=C:\dir\\
+COMMENT=value # note \
+PLAIN=plain
+LAST=end\`)
+
+	var c config
+	assert.NoError(t, xconfigdotenv.New(xconfigdotenv.WithLineContinuations()).Unmarshal(data, &c))
+	assert.Equal(t, config{
+		Opts:    "-Xms256m -Xmx1g -Dx=1",
+		Hosts:   []string{"a", "b", "c"},
+		Quoted:  "two \nlines", // as godotenv reads it: the escape is dropped, not the line break
+		Single:  "kept \\\nas is",
+		Path:    `C:\dir\\`,
+		Comment: "value",
+		Plain:   "plain",
+		Last:    `end\`,
+	}, c)
+
+	// without the option the lines are read on their own
+	c = config{}
+	assert.NoError(t, xconfigdotenv.New().Unmarshal([]byte("OPTS=a \\\nPLAIN=b"), &c))
+	assert.Equal(t, `a \`, c.Opts)
+	assert.Equal(t, "b", c.Plain)
+}
@@ -0,0 +1,193 @@
+package xconfigdotenv
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math/big"
+	"net"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// convertFunc converts a raw value into a value of the type it is
+// registered for.
+type convertFunc func(rawVal string) (reflect.Value, error)
+
+// converters holds the converters registered with RegisterConverter, by
+// type. The map is never modified once stored: a registration stores a
+// modified copy, so decoding reads it without locking.
+var converters struct {
+	// mu serializes the registrations.
+	mu sync.Mutex
+	m  atomic.Pointer[map[reflect.Type]convertFunc]
+}
+
+func init() {
+	RegisterConverter(parseSlogLevel)
+	RegisterConverter(parseSlogLeveler)
+	RegisterConverter(net.ParseMAC)
+	RegisterConverter(regexp.Compile)
+	RegisterConverter(parseRat)
+	RegisterConverter(parseTCPAddr)
+	RegisterConverter(parseUDPAddr)
+	RegisterConverter(parseUserinfo)
+	RegisterConverter(parseRGBA)
+	RegisterConverter(parseNRGBA)
+	RegisterConverter(parseTime)
+	RegisterConverter(parseText[Interval]())
+	RegisterConverter(parseText[Bytes]())
+	RegisterConverter(parseText[Percent]())
+}
+
+// RegisterConverter registers convert as the conversion of raw values into
+// values of type T, for every Decoder. It takes precedence over the built-in
+// conversions and replaces any converter previously registered for T; a
+// field of type *T is allocated and gets the converted value.
+//
+// Parse functions of common libraries can be registered as they are:
+//
+//	xconfigdotenv.RegisterConverter(zapcore.ParseLevel)
+//	xconfigdotenv.RegisterConverter(logrus.ParseLevel)
+//
+// So is the constructor of decimal types such as the common money type of
+// github.com/shopspring/decimal, which this package does not depend on:
+//
+//	xconfigdotenv.RegisterConverter(decimal.NewFromString)
+//
+// Marshal writes such types back with their MarshalText or String method.
+//
+// Converters for slog.Level (see parseSlogLevel), slog.Leveler (see
+// parseSlogLeveler), net.HardwareAddr (see
+// net.ParseMAC), *regexp.Regexp (see regexp.Compile), big.Rat (see
+// parseRat), net.TCPAddr (see parseTCPAddr), net.UDPAddr (see
+// parseUDPAddr), url.Userinfo (see parseUserinfo), color.RGBA (see
+// parseRGBA), color.NRGBA (see parseNRGBA), time.Time (see parseTime) and
+// the helper types Interval, Bytes and Percent are registered by default.
+//
+// Registering is safe while other goroutines decode, which never wait for
+// it, but a decoding already running may or may not see the new converter:
+// register converters before the first use of the types, typically from
+// an init function.
+func RegisterConverter[T any](convert func(rawVal string) (T, error)) {
+	converters.mu.Lock()
+	defer converters.mu.Unlock()
+
+	m := make(map[reflect.Type]convertFunc)
+	if old := converters.m.Load(); old != nil {
+		m = maps.Clone(*old)
+	}
+	m[reflect.TypeFor[T]()] = func(rawVal string) (reflect.Value, error) {
+		v, err := convert(rawVal)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(&v).Elem(), nil
+	}
+	converters.m.Store(&m)
+}
+
+// RegisterInterfaceConverter registers convert as the conversion of raw
+// values into values of the interface type I, for every Decoder, like
+// RegisterConverter does for its type, but with a convert function
+// returning any: the sentinel errors, or the singletons of a status or
+// policy interface, are given by name.
+//
+//	xconfigdotenv.RegisterInterfaceConverter[Policy](func(rawVal string) (any, error) {
+//		switch rawVal {
+//		case "allow":
+//			return Allow, nil
+//		case "reject":
+//			return Reject, nil
+//		}
+//		return nil, errors.New("expecting allow or reject")
+//	})
+//
+// The value returned must be assignable to I: its type implements I, with
+// the methods of I on a pointer receiver only found on pointers, or it is
+// nil, which leaves the field nil. Other values fail the key. A field of
+// type I, a pointer to one, or the elements and values of slices and maps
+// of I are then decoded from a single value.
+//
+// RegisterInterfaceConverter panics when I is not an interface type.
+func RegisterInterfaceConverter[I any](convert func(rawVal string) (any, error)) {
+	iface := reflect.TypeFor[I]()
+	if iface.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("xconfigdotenv: RegisterInterfaceConverter: %s is not an interface type", iface))
+	}
+
+	converters.mu.Lock()
+	defer converters.mu.Unlock()
+
+	m := make(map[reflect.Type]convertFunc)
+	if old := converters.m.Load(); old != nil {
+		m = maps.Clone(*old)
+	}
+	m[iface] = func(rawVal string) (reflect.Value, error) {
+		v, err := convert(rawVal)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if v == nil {
+			return reflect.Zero(iface), nil
+		}
+		if !reflect.TypeOf(v).AssignableTo(iface) {
+			return reflect.Value{}, fmt.Errorf("converter returned %T, which does not implement %s", v, iface)
+		}
+		cv := reflect.New(iface).Elem()
+		cv.Set(reflect.ValueOf(v))
+		return cv, nil
+	}
+	converters.m.Store(&m)
+}
+
+// converter returns the converter registered for t, or nil.
+func converter(t reflect.Type) convertFunc {
+	m := converters.m.Load()
+	if m == nil {
+		return nil
+	}
+	return (*m)[t]
+}
+
+// parseSlogLevel parses a slog.Level from its name, case-insensitively and
+// with an optional offset (DEBUG, info, WARN+2, error-1), or from its number
+// (-4, 0, 4, 8).
+func parseSlogLevel(rawVal string) (slog.Level, error) {
+	rawVal = strings.TrimSpace(rawVal)
+	if n, err := strconv.Atoi(rawVal); err == nil {
+		return slog.Level(n), nil
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(rawVal)); err != nil {
+		return 0, fmt.Errorf("expecting a level name or number: %w", err)
+	}
+	return level, nil
+}
+
+// parseSlogLeveler parses a slog.Leveler as parseSlogLevel does, holding
+// the slog.Level, so a field of the interface slog.HandlerOptions takes
+// for its threshold is set from a level name.
+func parseSlogLeveler(rawVal string) (slog.Leveler, error) {
+	level, err := parseSlogLevel(rawVal)
+	if err != nil {
+		return nil, err
+	}
+	return level, nil
+}
+
+// parseRat parses a big.Rat from a fraction (3/4) or a decimal (0.75, 1e-3),
+// exactly.
+func parseRat(rawVal string) (big.Rat, error) {
+	var r big.Rat
+	if _, ok := r.SetString(strings.TrimSpace(rawVal)); !ok {
+		return big.Rat{}, errors.New("expecting a fraction or a decimal")
+	}
+	return r, nil
+}
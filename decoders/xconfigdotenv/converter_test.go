@@ -0,0 +1,345 @@
+package xconfigdotenv_test
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogLevel(t *testing.T) {
+	var config struct {
+		Log      slog.Level
+		Debug    slog.Level
+		Verbose  slog.Level
+		Numeric  slog.Level
+		Negative *slog.Level
+		Levels   map[string]slog.Level
+	}
+
+	data := []byte("LOG=info\nDEBUG=DEBUG\nVERBOSE=debug-2\nNUMERIC=8\nNEGATIVE=-4\nLEVELS_http=warn\nLEVELS_db=12")
+	err := xconfigdotenv.New().Unmarshal(data, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, slog.LevelInfo, config.Log)
+	assert.Equal(t, slog.LevelDebug, config.Debug)
+	assert.Equal(t, slog.LevelDebug-2, config.Verbose)
+	assert.Equal(t, slog.LevelError, config.Numeric)
+	if assert.NotNil(t, config.Negative) {
+		assert.Equal(t, slog.LevelDebug, *config.Negative)
+	}
+	assert.Equal(t, map[string]slog.Level{"http": slog.LevelWarn, "db": 12}, config.Levels)
+
+	err = xconfigdotenv.New().Unmarshal([]byte("LOG=loud"), &config)
+	assert.ErrorContains(t, err, `cannot parse "loud" as slog.Level: expecting a level name or number`)
+}
+
+func TestSlogLeveler(t *testing.T) {
+	var config struct {
+		LogLevel slog.Leveler
+		Handlers map[string]slog.Leveler
+	}
+
+	decoder := xconfigdotenv.New()
+	err := decoder.Unmarshal([]byte("LOG_LEVEL=warn\nHANDLERS_http=debug+2"), &config)
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, config.LogLevel)
+	assert.Equal(t, map[string]slog.Leveler{"http": slog.LevelDebug + 2}, config.Handlers)
+
+	data, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "LOG_LEVEL=WARN\nHANDLERS_http=DEBUG+2\n", string(data))
+
+	err = decoder.Unmarshal([]byte("LOG_LEVEL=loud"), &config)
+	assert.ErrorContains(t, err, `cannot parse "loud" as slog.Leveler: expecting a level name or number`)
+}
+
+type upperString string
+
+func TestRegisterConverter(t *testing.T) {
+	xconfigdotenv.RegisterConverter(func(rawVal string) (upperString, error) {
+		if rawVal == "" {
+			return "", errors.New("empty")
+		}
+		return upperString(strings.ToUpper(rawVal)), nil
+	})
+
+	var config struct {
+		Name  upperString
+		Names []upperString
+	}
+
+	err := xconfigdotenv.New().Unmarshal([]byte("NAME=app\nNAMES=a,b"), &config)
+	assert.NoError(t, err)
+	assert.Equal(t, upperString("APP"), config.Name)
+	assert.Equal(t, []upperString{"A", "B"}, config.Names)
+
+	err = xconfigdotenv.New().Unmarshal([]byte("NAME="), &config)
+	assert.ErrorContains(t, err, `cannot parse "" as xconfigdotenv_test.upperString: empty`)
+}
+
+// policy is an interface whose values are singletons given by name.
+type policy interface {
+	Allows(host string) bool
+}
+
+type allowPolicy string
+
+func (allowPolicy) Allows(string) bool { return true }
+
+type rejectPolicy struct{ reason string }
+
+func (*rejectPolicy) Allows(string) bool { return false }
+
+var (
+	allowAll  = allowPolicy("allow")
+	rejectAll = &rejectPolicy{reason: "closed"}
+)
+
+func TestRegisterInterfaceConverter(t *testing.T) {
+	xconfigdotenv.RegisterInterfaceConverter[policy](func(rawVal string) (any, error) {
+		switch rawVal {
+		case "allow":
+			return allowAll, nil
+		case "reject":
+			return rejectAll, nil
+		case "none":
+			return nil, nil
+		case "wrong":
+			// the methods of rejectPolicy are on its pointer
+			return rejectPolicy{}, nil
+		}
+		return nil, errors.New("expecting allow or reject")
+	})
+
+	var config struct {
+		Default  policy
+		Fallback *policy
+		Hosts    map[string]policy
+		Chain    []policy
+		Unset    policy
+	}
+
+	data := []byte("DEFAULT=reject\nFALLBACK=allow\nHOSTS_api=allow\nCHAIN=allow,reject\nUNSET=none")
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &config))
+	assert.Same(t, rejectAll, config.Default)
+	if assert.NotNil(t, config.Fallback) {
+		assert.Equal(t, allowAll, *config.Fallback)
+	}
+	assert.Equal(t, map[string]policy{"api": allowAll}, config.Hosts)
+	assert.Equal(t, []policy{allowAll, rejectAll}, config.Chain)
+	assert.Nil(t, config.Unset)
+
+	err := xconfigdotenv.New().Unmarshal([]byte("DEFAULT=maybe"), &config)
+	assert.ErrorContains(t, err, `cannot parse "maybe" as xconfigdotenv_test.policy: expecting allow or reject`)
+	err = xconfigdotenv.New().Unmarshal([]byte("DEFAULT=wrong"), &config)
+	assert.ErrorContains(t, err, "converter returned xconfigdotenv_test.rejectPolicy, which does not implement xconfigdotenv_test.policy")
+
+	assert.PanicsWithValue(t, "xconfigdotenv: RegisterInterfaceConverter: xconfigdotenv_test.allowPolicy is not an interface type", func() {
+		xconfigdotenv.RegisterInterfaceConverter[allowPolicy](func(string) (any, error) { return nil, nil })
+	})
+}
+
+type concurrentLevel int
+
+func TestRegisterConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				xconfigdotenv.RegisterConverter(func(rawVal string) (concurrentLevel, error) {
+					return concurrentLevel(len(rawVal)), nil
+				})
+				xconfigdotenv.RegisterType("http", func() handler { return &httpHandler{} })
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				var config struct {
+					Log  slog.Level
+					Main handler
+				}
+				err := xconfigdotenv.New().Unmarshal([]byte("LOG=warn\nMAIN_TYPE=http\nMAIN_PORT=80"), &config)
+				if assert.NoError(t, err) {
+					assert.Equal(t, slog.LevelWarn, config.Log)
+					assert.Equal(t, &httpHandler{Port: 80, Path: "/"}, config.Main)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var config struct{ Level concurrentLevel }
+	assert.NoError(t, xconfigdotenv.New().Unmarshal([]byte("LEVEL=abc"), &config))
+	assert.Equal(t, concurrentLevel(3), config.Level)
+}
+
+func TestHardwareAddr(t *testing.T) {
+	var config struct {
+		MAC     net.HardwareAddr
+		Pinned  []net.HardwareAddr `sep:" "`
+		Gateway *net.HardwareAddr
+	}
+
+	decoder := xconfigdotenv.New()
+	err := decoder.Unmarshal([]byte("MAC=00:1a:2b:3c:4d:5e\nPINNED=\"aa-bb-cc-dd-ee-ff 0000.5e00.5301\"\nGATEWAY=02:00:00:00:00:01"), &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, net.HardwareAddr{0x00, 0x1a, 0x2b, 0x3c, 0x4d, 0x5e}, config.MAC)
+	assert.Equal(t, []net.HardwareAddr{
+		{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		{0x00, 0x00, 0x5e, 0x00, 0x53, 0x01},
+	}, config.Pinned)
+	if assert.NotNil(t, config.Gateway) {
+		assert.Equal(t, "02:00:00:00:00:01", config.Gateway.String())
+	}
+
+	data, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "MAC=00:1a:2b:3c:4d:5e\nPINNED=\"aa:bb:cc:dd:ee:ff 00:00:5e:00:53:01\"\nGATEWAY=02:00:00:00:00:01\n", string(data))
+
+	err = decoder.Unmarshal([]byte("MAC=00:1a:2b"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "MAC": cannot parse "00:1a:2b" as net.HardwareAddr: address 00:1a:2b: invalid MAC address`)
+}
+
+// amount stands for decimal types such as shopspring's decimal.Decimal,
+// registered through their string constructor.
+type amount struct {
+	r *big.Rat
+}
+
+func newAmountFromString(s string) (amount, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return amount{}, fmt.Errorf("can't convert %s to decimal", s)
+	}
+	return amount{r}, nil
+}
+
+func (a amount) String() string {
+	return a.r.FloatString(2)
+}
+
+func TestDecimalConverter(t *testing.T) {
+	xconfigdotenv.RegisterConverter(newAmountFromString)
+
+	var config struct {
+		Price amount
+		Fees  []amount
+		Limit *amount
+	}
+
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal([]byte("PRICE=19.99\nFEES=0.10,1.5\nLIMIT=1000"), &config))
+	assert.Equal(t, "19.99", config.Price.String())
+	if assert.Len(t, config.Fees, 2) {
+		assert.Equal(t, "1.50", config.Fees[1].String())
+	}
+	if assert.NotNil(t, config.Limit) {
+		assert.Equal(t, "1000.00", config.Limit.String())
+	}
+
+	data, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "PRICE=19.99\nFEES=0.10,1.50\nLIMIT=1000.00\n", string(data))
+
+	err = decoder.Unmarshal([]byte("PRICE=1,5"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "PRICE": cannot parse "1,5" as xconfigdotenv_test.amount: can't convert 1,5 to decimal`)
+}
+
+func TestRegexp(t *testing.T) {
+	var config struct {
+		Allow  *regexp.Regexp
+		Deny   []*regexp.Regexp
+		Routes []*regexp.Regexp
+		Hosts  []*regexp.Regexp `sep:";"`
+		Rules  map[string]*regexp.Regexp
+	}
+
+	data := []byte(`ALLOW=^/api/
+DENY="^/admin/\\d{1,3}$\n\n^/debug/.*, or not$\n"
+ROUTES_0=^/a,b$
+ROUTES_1=^/c/
+HOSTS=^a[.]example$;^b,c$
+RULES_admin=^/admin/
+RULES_public=^/(pub|www)/`)
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &config))
+	assert.Equal(t, "^/api/", config.Allow.String())
+	assert.Equal(t, []string{`^/admin/\d{1,3}$`, "^/debug/.*, or not$"}, regexpStrings(config.Deny))
+	assert.Equal(t, []string{"^/a,b$", "^/c/"}, regexpStrings(config.Routes))
+	assert.Equal(t, []string{"^a[.]example$", "^b,c$"}, regexpStrings(config.Hosts))
+	assert.Len(t, config.Rules, 2)
+	assert.True(t, config.Rules["admin"].MatchString("/admin/users"))
+	assert.True(t, config.Rules["public"].MatchString("/www/index"))
+
+	// every element compiles on its own, errors name the element or key
+	var bad struct {
+		Deny  []*regexp.Regexp
+		Rules map[string]*regexp.Regexp
+	}
+	err := xconfigdotenv.New().Unmarshal([]byte(`DENY="^a$\n(b"`), &bad)
+	assert.ErrorContains(t, err, `key "DENY": element 1: cannot parse "(b" as *regexp.Regexp`)
+
+	err = xconfigdotenv.New().Unmarshal([]byte(`RULES_admin=[a`), &bad)
+	assert.ErrorContains(t, err, `key "RULES_admin": cannot parse "[a" as *regexp.Regexp`)
+
+	// newline separated regexps are written back as they are read
+	decoder := xconfigdotenv.New()
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	var decoded struct {
+		Allow  *regexp.Regexp
+		Deny   []*regexp.Regexp
+		Routes []*regexp.Regexp
+		Hosts  []*regexp.Regexp `sep:";"`
+		Rules  map[string]*regexp.Regexp
+	}
+	assert.NoError(t, decoder.Unmarshal(out, &decoded))
+	assert.Equal(t, regexpStrings(config.Deny), regexpStrings(decoded.Deny))
+	assert.Equal(t, regexpStrings(config.Routes), regexpStrings(decoded.Routes))
+	assert.Equal(t, config.Rules["public"].String(), decoded.Rules["public"].String())
+}
+
+func regexpStrings(rs []*regexp.Regexp) []string {
+	var s []string
+	for _, r := range rs {
+		s = append(s, r.String())
+	}
+	return s
+}
+
+func TestBigRat(t *testing.T) {
+	var config struct {
+		Ratio  big.Rat
+		Share  *big.Rat
+		Rate   *big.Rat
+		Splits []big.Rat
+	}
+
+	data := []byte("RATIO=3/4\nSHARE=0.75\nRATE=1e-3\nSPLITS=1/3,2/3")
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+	assert.Equal(t, "3/4", config.Ratio.String())
+	assert.Equal(t, "3/4", config.Share.String())
+	assert.Equal(t, "1/1000", config.Rate.String())
+	assert.Len(t, config.Splits, 2)
+	assert.Equal(t, "2/3", config.Splits[1].String())
+
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "RATIO=3/4\nSHARE=3/4\nRATE=1/1000\nSPLITS=1/3,2/3\n", string(out))
+
+	err = decoder.Unmarshal([]byte("RATIO=three"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "RATIO": cannot parse "three" as big.Rat: expecting a fraction or a decimal`)
+}
@@ -0,0 +1,183 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const defaultTag = "default"
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// newValue allocates a pointer to a new value of type t. When t is a struct,
+// its fields are filled from their `default` tags.
+func (s *decodeState) newValue(t reflect.Type) (reflect.Value, error) {
+	ptr := reflect.New(t)
+	if t.Kind() == reflect.Struct {
+		if err := s.applyDefaults(ptr.Elem()); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return ptr, nil
+}
+
+// applyDefaults sets every zero field of the struct v which has a `default` tag,
+// descending into nested (non-pointer) structs.
+func (s *decodeState) applyDefaults(v reflect.Value) error {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if s.opts.skipsField(field) {
+			continue
+		}
+		fieldVal := getFieldValue(v, i)
+
+		value, ok := field.Tag.Lookup(s.opts.tagNames.Default)
+		if !ok {
+			if fieldVal.Kind() == reflect.Struct {
+				if err := s.applyDefaults(fieldVal); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if !fieldVal.IsZero() {
+			continue
+		}
+		s.timeLayout = s.opts.timeLayout(field)
+		if fieldVal.Type() == timeType {
+			t, err := s.timeDefault(value)
+			if err != nil {
+				return fmt.Errorf("default of field %q: %w", field.Name, err)
+			}
+			if err := setWithReflect(fieldVal, reflect.ValueOf(t)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.setBasicValue(fieldVal, value); err != nil {
+			return fmt.Errorf("default of field %q: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// timeDefault parses the default of a time.Time field: now, now+<duration>
+// or now-<duration>, relative to the clock of the options, or a time in the
+// layout of its `timeformat` tag, RFC 3339 by default.
+func (s *decodeState) timeDefault(value string) (time.Time, error) {
+	rest, ok := strings.CutPrefix(value, "now")
+	if !ok && s.timeLayout != "" {
+		t, err := time.Parse(s.timeLayout, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot parse %q as time: expecting now, now+<duration>, now-<duration> or a time in the layout %q", value, s.timeLayout)
+		}
+		return t, nil
+	}
+	if !ok {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot parse %q as time: expecting now, now+<duration>, now-<duration> or an RFC 3339 time", value)
+		}
+		return t, nil
+	}
+
+	now := time.Now
+	if s.opts.clock != nil {
+		now = s.opts.clock
+	}
+	if rest == "" {
+		return now(), nil
+	}
+	if rest[0] != '+' && rest[0] != '-' {
+		return time.Time{}, fmt.Errorf("cannot parse %q as time: expecting + or - after now", value)
+	}
+	d, err := time.ParseDuration(rest)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse %q as time: %w", value, err)
+	}
+	return now().Add(d), nil
+}
+
+// hasDefaults reports whether the struct type t or any struct reachable
+// through its fields has a field with a `default` tag or a required one,
+// which the allocated struct then gets checked for.
+func (o *options) hasDefaults(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if o.skipsField(field) {
+			continue
+		}
+		if _, ok := field.Tag.Lookup(o.tagNames.Default); ok || o.isRequired(field) {
+			return true
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && o.hasDefaults(ft, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// allocateNilStructs walks the struct v and allocates every nil pointer
+// substruct which has defaults (see NilStructAllocate).
+func (s *decodeState) allocateNilStructs(v reflect.Value) error {
+	return s.allocateNilStructsPath(v, map[reflect.Type]bool{v.Type(): true})
+}
+
+// allocateNilStructsPath does the work of allocateNilStructs. Types holds the
+// struct types allocated on the current path, so recursive types get only
+// one level allocated.
+func (s *decodeState) allocateNilStructsPath(v reflect.Value, types map[reflect.Type]bool) error {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		if s.opts.skipsField(typ.Field(i)) {
+			continue
+		}
+		fieldVal := getFieldValue(v, i)
+
+		switch fieldVal.Kind() {
+		case reflect.Struct:
+			if err := s.allocateNilStructsPath(fieldVal, types); err != nil {
+				return err
+			}
+		case reflect.Ptr:
+			elemType := fieldVal.Type().Elem()
+			if elemType.Kind() != reflect.Struct || types[elemType] {
+				continue
+			}
+			if fieldVal.IsNil() {
+				if !s.opts.hasDefaults(elemType, map[reflect.Type]bool{}) {
+					continue
+				}
+				newPtr, err := s.newValue(elemType)
+				if err != nil {
+					return fmt.Errorf("field %q: %w", typ.Field(i).Name, err)
+				}
+				if err := setWithReflect(fieldVal, newPtr); err != nil {
+					return err
+				}
+			}
+
+			types[elemType] = true
+			err := s.allocateNilStructsPath(fieldVal.Elem(), types)
+			delete(types, elemType)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type poolSettings struct {
+	Size    int           `default:"10"`
+	Timeout time.Duration `default:"5s"`
+	Name    string
+}
+
+type cacheSettings struct {
+	Host string
+}
+
+type sectionsConfig struct {
+	Pool  *poolSettings
+	Cache *cacheSettings
+	Name  string
+}
+
+type treeNode struct {
+	Value int `default:"1"`
+	Next  *treeNode
+}
+
+func TestNilStructPolicyKeep(t *testing.T) {
+	var config sectionsConfig
+	err := xconfigdotenv.New().Unmarshal([]byte("NAME=app"), &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "app", config.Name)
+	assert.Nil(t, config.Pool)
+	assert.Nil(t, config.Cache)
+}
+
+func TestNilStructPolicyAllocate(t *testing.T) {
+	decoder := xconfigdotenv.New(xconfigdotenv.WithNilStructPolicy(xconfigdotenv.NilStructAllocate))
+
+	var config sectionsConfig
+	err := decoder.Unmarshal([]byte("NAME=app"), &config)
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, config.Pool) {
+		assert.Equal(t, 10, config.Pool.Size)
+		assert.Equal(t, 5*time.Second, config.Pool.Timeout)
+	}
+	// no defaults in cacheSettings, so it is left alone
+	assert.Nil(t, config.Cache)
+}
+
+func TestNilStructPolicyAllocateRequired(t *testing.T) {
+	decoder := xconfigdotenv.New(xconfigdotenv.WithNilStructPolicy(xconfigdotenv.NilStructAllocate))
+
+	var config struct {
+		Auth *struct {
+			Token string `required:"true"`
+		}
+	}
+	err := decoder.Unmarshal([]byte(""), &config)
+	assert.ErrorIs(t, err, xconfigdotenv.ErrMissingRequired)
+	assert.ErrorContains(t, err, "Auth.Token")
+	assert.NotNil(t, config.Auth)
+}
+
+func TestNilStructPolicyRecursiveType(t *testing.T) {
+	decoder := xconfigdotenv.New(xconfigdotenv.WithNilStructPolicy(xconfigdotenv.NilStructAllocate))
+
+	var config struct {
+		Root *treeNode
+	}
+	err := decoder.Unmarshal([]byte(""), &config)
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, config.Root) {
+		assert.Equal(t, 1, config.Root.Value)
+		assert.Nil(t, config.Root.Next)
+	}
+}
+
+func TestMatchedPointerStructGetsDefaults(t *testing.T) {
+	for name, decoder := range map[string]*xconfigdotenv.Decoder{
+		"keep":     xconfigdotenv.New(),
+		"allocate": xconfigdotenv.New(xconfigdotenv.WithNilStructPolicy(xconfigdotenv.NilStructAllocate)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var config sectionsConfig
+			err := decoder.Unmarshal([]byte("POOL_NAME=main\nPOOL_TIMEOUT=1s\nCACHE_HOST=localhost"), &config)
+			assert.NoError(t, err)
+
+			if assert.NotNil(t, config.Pool) {
+				assert.Equal(t, "main", config.Pool.Name)
+				assert.Equal(t, 10, config.Pool.Size)
+				assert.Equal(t, time.Second, config.Pool.Timeout)
+			}
+			if assert.NotNil(t, config.Cache) {
+				assert.Equal(t, "localhost", config.Cache.Host)
+			}
+		})
+	}
+}
+
+func TestUnmatchedPointerStructStaysNil(t *testing.T) {
+	var config struct {
+		Pool    *poolSettings
+		Replica *cacheSettings `env:",prefix=PG"`
+		Root    *treeNode
+		Hosts   []*cacheSettings
+	}
+
+	var unknown []string
+	decoder := xconfigdotenv.New(xconfigdotenv.WithUnknownKeyCallback(func(key, _ string) error {
+		unknown = append(unknown, key)
+		return nil
+	}))
+	data := []byte("POOL_UNKNOWN=1\nPG_PORT=5432\nROOT_NEXT_NEXT_BOGUS=1\nHOSTS_0_ADDR=h1")
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+	assert.Nil(t, config.Pool)
+	assert.Nil(t, config.Replica)
+	assert.Nil(t, config.Root)
+	assert.Equal(t, []*cacheSettings{nil}, config.Hosts)
+	assert.Equal(t, []string{"HOSTS_0_ADDR", "PG_PORT", "POOL_UNKNOWN", "ROOT_NEXT_NEXT_BOGUS"}, unknown)
+
+	// a failed assignment does not leave an allocated section either
+	config.Hosts = nil
+	decoder = xconfigdotenv.New(xconfigdotenv.WithAllowPartial())
+	assert.Error(t, decoder.Unmarshal([]byte("POOL_SIZE=ten"), &config))
+	assert.Nil(t, config.Pool)
+
+	// a section set by a key is kept even when another key misses it
+	assert.NoError(t, decoder.Unmarshal([]byte("POOL_NAME=main\nPOOL_UNKNOWN=1"), &config))
+	if assert.NotNil(t, config.Pool) {
+		assert.Equal(t, "main", config.Pool.Name)
+	}
+}
+
+func TestTimeDefaults(t *testing.T) {
+	type config struct {
+		Created time.Time `default:"now"`
+		Expires time.Time `default:"now+24h"`
+		Purge   time.Time `default:"now-1h30m"`
+		Epoch   time.Time `default:"2024-01-02T03:04:05Z"`
+	}
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	decoder := xconfigdotenv.New(
+		xconfigdotenv.WithClock(func() time.Time { return now }),
+		xconfigdotenv.WithNilStructPolicy(xconfigdotenv.NilStructAllocate),
+	)
+
+	var c struct{ App *config }
+	assert.NoError(t, decoder.Unmarshal([]byte("OTHER=1"), &c))
+	if assert.NotNil(t, c.App) {
+		assert.Equal(t, now, c.App.Created)
+		assert.Equal(t, now.Add(24*time.Hour), c.App.Expires)
+		assert.Equal(t, now.Add(-90*time.Minute), c.App.Purge)
+		assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), c.App.Epoch)
+	}
+
+	type bad struct {
+		At time.Time `default:"now*2"`
+	}
+	var b struct{ App *bad }
+	err := decoder.Unmarshal([]byte("APP_X=1"), &b)
+	assert.ErrorContains(t, err, `default of field "At": cannot parse "now*2" as time: expecting + or - after now`)
+}
@@ -0,0 +1,37 @@
+package xconfigdotenv
+
+import (
+	"reflect"
+	"strings"
+)
+
+// deprecatedTag is the tag marking a field as deprecated, its value telling
+// what to use instead, e.g. `deprecated:"use DB_URL instead"`.
+const deprecatedTag = "deprecated"
+
+// warnDeprecatedKey warns about the current key if it is one of the keys
+// of WithDeprecatedKeys. The value of the key is decoded all the same.
+func (s *decodeState) warnDeprecatedKey() {
+	if s.meta == nil || len(s.opts.deprecatedKeys) == 0 {
+		return
+	}
+	var note string
+	var ok bool
+	if s.opts.caseSensitive {
+		note, ok = s.opts.deprecatedKeys[s.key]
+	} else {
+		note, ok = s.opts.foldedDeprecatedKeys[strings.ToUpper(s.key)]
+	}
+	if ok {
+		s.warn("key is deprecated: %s", note)
+	}
+}
+
+// warnDeprecatedField warns about the current key if it matched field and
+// field is tagged as deprecated. A key matching a field inside a deprecated
+// struct field is warned about as well.
+func (s *decodeState) warnDeprecatedField(field reflect.StructField) {
+	if note, ok := field.Tag.Lookup(s.opts.tagNames.Deprecated); ok {
+		s.warn("field %s is deprecated: %s", field.Name, note)
+	}
+}
@@ -0,0 +1,52 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecatedKeys(t *testing.T) {
+	var config struct {
+		DBHost  string
+		DBURL   string
+		Timeout int `deprecated:"use DEADLINE instead"`
+		Legacy  struct {
+			Mode string
+		} `deprecated:"drop the LEGACY keys"`
+	}
+
+	data := []byte(`db_host=db1
+DB_URL=postgres://db2
+TIMEOUT=5
+LEGACY_MODE=on`)
+	decoder := xconfigdotenv.New(xconfigdotenv.WithDeprecatedKeys(map[string]string{
+		"DB_HOST": "use DB_URL instead",
+	}))
+	meta, err := decoder.UnmarshalWithMetadata(data, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, "db1", config.DBHost)
+	assert.Equal(t, 5, config.Timeout)
+	assert.Equal(t, "on", config.Legacy.Mode)
+	assert.Equal(t, []xconfigdotenv.Warning{
+		{Key: "LEGACY_MODE", Message: "field Legacy is deprecated: drop the LEGACY keys"},
+		{Key: "TIMEOUT", Message: "field Timeout is deprecated: use DEADLINE instead"},
+		{Key: "db_host", Message: "key is deprecated: use DB_URL instead"},
+	}, meta.Warnings)
+
+	// case-sensitive keys only match as written
+	meta, err = xconfigdotenv.New(xconfigdotenv.WithCaseSensitive(), xconfigdotenv.WithDeprecatedKeys(map[string]string{
+		"DB_HOST": "use DB_URL instead",
+	})).UnmarshalWithMetadata([]byte("db_host=db1"), &struct{ DBHost string }{})
+	assert.NoError(t, err)
+	assert.Empty(t, meta.Warnings)
+
+	// the tag key may be renamed
+	meta, err = xconfigdotenv.New(xconfigdotenv.WithTagNames(xconfigdotenv.TagNames{Deprecated: "obsolete"})).
+		UnmarshalWithMetadata([]byte("PORT=80"), &struct {
+			Port int `obsolete:"use LISTEN instead"`
+		}{})
+	assert.NoError(t, err)
+	assert.Equal(t, []xconfigdotenv.Warning{{Key: "PORT", Message: "field Port is deprecated: use LISTEN instead"}}, meta.Warnings)
+}
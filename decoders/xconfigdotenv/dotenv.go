@@ -1,8 +1,13 @@
 package xconfigdotenv
 
 import (
+	"encoding"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -12,10 +17,18 @@ import (
 )
 
 // Decoder Pars .env and laid out values in an arbitrary Go structure.
-type Decoder struct{}
+type Decoder struct {
+	opts options
+}
 
 // New function create new Decoder.
-func New() *Decoder { return &Decoder{} }
+func New(opts ...Option) *Decoder {
+	d := &Decoder{opts: options{tagNames: defaultTagNames}}
+	for _, opt := range opts {
+		opt(&d.opts)
+	}
+	return d
+}
 
 // Format return decoder format name.
 func (d *Decoder) Format() string {
@@ -23,160 +36,646 @@ func (d *Decoder) Format() string {
 }
 
 // Unmarshal pars []byte (.env format) and fill v – pointer on struct.
+//
+// V may also point to an interface holding a struct (or a pointer to one),
+// which is the usual shape of configs passed around as any: a struct held
+// by value is not addressable, so it is decoded into a copy which then
+// replaces the value in the interface. A failed call leaves the interface
+// unchanged, unless WithAllowPartial is set. A pointer to a map with string keys
+// receives the parsed key/value pairs as is.
 func (d *Decoder) Unmarshal(data []byte, v any) error {
+	return d.unmarshal(data, v, nil)
+}
+
+// UnmarshalWithMetadata works like Unmarshal and also returns the metadata
+// of the run. The metadata is returned even when decoding fails.
+func (d *Decoder) UnmarshalWithMetadata(data []byte, v any) (*Metadata, error) {
+	meta := &Metadata{}
+	start := time.Now()
+
+	err := d.unmarshal(data, v, meta)
+	var partial *PartialError
+	switch {
+	case errors.As(err, &partial):
+		meta.Metrics.Errors += len(partial.Errors)
+	case err != nil:
+		meta.Metrics.Errors++
+	}
+
+	meta.Metrics.Duration = time.Since(start)
+	return meta, err
+}
+
+// unmarshal does the work of Unmarshal, collecting metadata in meta unless it is nil.
+func (d *Decoder) unmarshal(data []byte, v any, meta *Metadata) error {
+	s := d.newState(meta)
+
 	// 1) unmarshal .env → map[string]string
-	flatMap, err := godotenv.UnmarshalBytes(data)
+	flatMap, err := s.parse(data)
 	if err != nil {
 		return err
 	}
+	return s.decodeInto(flatMap, v)
+}
 
+// decodeInto decodes the parsed keys flatMap into v, see unmarshal.
+func (s *decodeState) decodeInto(flatMap map[string]string, v any) error {
 	// 2) Check, v – not empty pointer on struct
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Pointer || rv.IsNil() {
 		return fmt.Errorf("xconfigdotenv: Unmarshal: v must be a non-nil pointer to a struct, got %T", v)
 	}
 	elem := rv.Elem()
-	if elem.Kind() != reflect.Struct {
+
+	switch elem.Kind() {
+	case reflect.Struct:
+		return s.decodeStruct(elem, flatMap)
+
+	case reflect.Map:
+		return s.decodeMap(elem, flatMap)
+
+	case reflect.Interface:
+		if elem.IsNil() {
+			return fmt.Errorf("xconfigdotenv: Unmarshal: v points to a nil %s", elem.Type())
+		}
+		inner := elem.Elem()
+		if inner.Kind() == reflect.Pointer && !inner.IsNil() && inner.Elem().Kind() == reflect.Struct {
+			return s.decodeStruct(inner.Elem(), flatMap)
+		}
+		if inner.Kind() != reflect.Struct {
+			return fmt.Errorf("xconfigdotenv: Unmarshal: v points to an interface holding %s, expecting a struct", inner.Type())
+		}
+
+		// The struct held by the interface is not addressable: decode into a
+		// copy and put it back, unless the decoding failed without WithAllowPartial
+		cp := reflect.New(inner.Type()).Elem()
+		cp.Set(inner)
+		err := s.decodeStruct(cp, flatMap)
+		if err == nil || s.opts.allowPartial {
+			elem.Set(cp)
+		}
+		return err
+
+	default:
 		return fmt.Errorf("xconfigdotenv: Unmarshal: v must point to a struct, got pointer to %s", elem.Kind())
 	}
+}
+
+// UnmarshalValue works like Unmarshal for tooling holding the destination
+// as a reflect.Value: rv must be an addressable struct value, such as
+// reflect.ValueOf(&config).Elem() or a struct field of one.
+func (d *Decoder) UnmarshalValue(data []byte, rv reflect.Value) error {
+	if !rv.IsValid() {
+		return errors.New("xconfigdotenv: UnmarshalValue: invalid reflect.Value")
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("xconfigdotenv: UnmarshalValue: rv must be a struct, got %s", rv.Type())
+	}
+	if !rv.CanAddr() {
+		return fmt.Errorf("xconfigdotenv: UnmarshalValue: rv must be addressable, got an unaddressable %s", rv.Type())
+	}
+
+	s := d.newState(nil)
+	flatMap, err := s.parse(data)
+	if err != nil {
+		return err
+	}
+	return s.decodeStruct(rv, flatMap)
+}
+
+// parse reads the keys and values of the .env data.
+func (s *decodeState) parse(data []byte) (map[string]string, error) {
+	if s.opts.lineContinuations {
+		data = joinContinuations(data)
+	}
+	if err := s.checkDuplicates(data); err != nil {
+		return nil, err
+	}
+	parse := godotenv.UnmarshalBytes
+	if s.opts.parser != nil {
+		parse = s.opts.parser
+	}
+	flatMap, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.beforeEachKey(flatMap)
+}
+
+// Parser reads the keys and values of the input, which are then decoded
+// into the fields they match, see WithParser.
+type Parser func(data []byte) (map[string]string, error)
+
+// newState returns the state of a new decode run, collecting metadata in
+// meta unless it is nil.
+func (d *Decoder) newState(meta *Metadata) *decodeState {
+	return &decodeState{
+		opts:     &d.opts,
+		meta:     meta,
+		assigned: make(map[string][]int),
+	}
+}
+
+// decodeState holds the state of a single Unmarshal call.
+type decodeState struct {
+	opts *options
+	// meta collects the metadata of the run, it is nil when nobody asked for it.
+	meta *Metadata
+
+	// assigned holds, for every assigned field path, the name ranks of the
+	// key which set it (see claim).
+	assigned map[string][]int
+	// key is the key being decoded, and parts its segments.
+	key   string
+	parts []string
+	// field is the path of the deepest field the current key matched.
+	field string
+	// redacted is set once the current key matched a redacted field, and
+	// redactions holds the values to remove from its messages.
+	redacted   bool
+	redactions []string
+	// ranks holds the ranks of the names matched so far by the current key.
+	ranks []int
+	// resolved is set once the secret of the current key is resolved.
+	resolved bool
+
+	// pending holds the keys collected for Unmarshaler fields, in the order
+	// the fields were first matched, and pendingByPath the same by path.
+	pending       []*pendingEnv
+	pendingByPath map[string]*pendingEnv
+	// flush is the Unmarshaler field being given its keys (see flushEnv).
+	flush *pendingEnv
+	// flat holds the keys of the input, for the lookup of TYPE keys, and
+	// flatIndex their values by segments (see lookupKey), once a lookup
+	// needs them.
+	flat      map[string]string
+	flatIndex map[string]string
+	// prefixes holds the fields of the top-level struct with a prefix.
+	prefixes []prefixedField
+	// resetPaths holds the paths of the fields reset by WithReset.
+	resetPaths map[string]bool
+	// sliceSizes holds the sizes of the slices found by the pre-scan of
+	// WithEagerSliceSizing (see scanSliceSizes), nil without it.
+	sliceSizes map[string]int
+	// timeLayout is the layout of the `timeformat` tag of the field being
+	// decoded (see timeLayout), empty without it.
+	timeLayout string
+}
+
+// decodeStruct fill the struct elem from flatMap.
+func (s *decodeState) decodeStruct(elem reflect.Value, flatMap map[string]string) error {
+	flatMap = s.dropIgnored(flatMap)
+	s.flat, s.flatIndex = flatMap, nil
+	s.prefixes = s.opts.prefixedFields(elem.Type())
+	s.recordFlat(flatMap)
+
+	// A struct implementing Unmarshaler decodes itself
+	if u, ok, err := unmarshaler(elem); ok || err != nil {
+		if err == nil {
+			for range flatMap {
+				s.countKey(true)
+			}
+			err = u.UnmarshalEnv(flatMap)
+		}
+		if err != nil {
+			return fmt.Errorf("xconfigdotenv: Unmarshal: %w", err)
+		}
+		return nil
+	}
+
+	// 3) For each key from .env, we disassemble the line in the desired field.
+	// Keys are sorted, so the result does not depend on the map iteration order
+	keys := make([]string, 0, len(flatMap))
+	for rawKey := range flatMap {
+		keys = append(keys, rawKey)
+	}
+	sort.Strings(keys)
+	if s.opts.eagerSliceSizing {
+		s.sliceSizes = s.opts.scanSliceSizes(keys)
+	}
+
+	var partial PartialError
+	decode := s.decodeKeys
+	if s.opts.concurrentMinKeys > 0 && len(keys) >= s.opts.concurrentMinKeys {
+		decode = s.decodeConcurrent
+	}
+	if err := decode(elem, keys, flatMap, &partial); err != nil {
+		return err
+	}
+
+	// Give the Unmarshaler fields the keys collected for them
+	if err := s.flushEnv(elem, &partial); err != nil {
+		return err
+	}
+
+	// 4) Allocate the pointer substructs which are still nil, if the policy asks for it
+	if s.opts.nilStructPolicy == NilStructAllocate {
+		if err := s.allocateNilStructs(elem); err != nil {
+			err = fmt.Errorf("xconfigdotenv: Unmarshal: %w", err)
+			if !s.opts.allowPartial {
+				return err
+			}
+			partial.Errors = append(partial.Errors, err)
+		}
+	}
+
+	// 5) Check the lengths of the slices and maps tagged with a maximum, now that every element is assigned
+	if err := s.checkMaxLens(elem, ""); err != nil {
+		err = fmt.Errorf("xconfigdotenv: Unmarshal: %w", err)
+		if !s.opts.allowPartial {
+			return err
+		}
+		partial.Errors = append(partial.Errors, err)
+	}
+
+	// 6) Sort the slices tagged with the field to sort them by
+	if err := s.sortSlices(elem, ""); err != nil {
+		err = fmt.Errorf("xconfigdotenv: Unmarshal: %w", err)
+		if !s.opts.allowPartial {
+			return err
+		}
+		partial.Errors = append(partial.Errors, err)
+	}
 
-	// 3) For each key from .env, we disassemble the line in the desired field
-	for rawKey, rawVal := range flatMap {
-		parts := strings.Split(rawKey, "_")
+	// 7) Check that the required fields are set, once every other value is in place
+	if err := s.checkRequired(elem); err != nil {
+		err = fmt.Errorf("xconfigdotenv: Unmarshal: %w", err)
+		if !s.opts.allowPartial {
+			return err
+		}
+		partial.Errors = append(partial.Errors, err)
+	}
+
+	return partial.orNil()
+}
+
+// decodeKeys decodes the keys of flatMap, in the order of keys, into elem.
+// Errors are collected in partial when the options allow it.
+func (s *decodeState) decodeKeys(elem reflect.Value, keys []string, flatMap map[string]string, partial *PartialError) error {
+	for _, rawKey := range keys {
+		parts := s.opts.splitKey(rawKey)
 		if len(parts) == 0 {
 			continue
 		}
-		if err := assignValue(elem, parts, rawVal); err != nil {
-			return fmt.Errorf("xconfigdotenv: Unmarshal: key %q: %w", rawKey, err)
+		err := s.decodeKey(elem, rawKey, parts, flatMap[rawKey])
+		if err == nil {
+			continue
+		}
+		err = &KeyError{Key: rawKey, Field: s.field, Err: s.redactError(err)}
+		if !s.opts.allowPartial {
+			return err
+		}
+		partial.Errors = append(partial.Errors, err)
+	}
+	return nil
+}
+
+// decodeKey assigns the value of a key to the field of elem it matches.
+func (s *decodeState) decodeKey(elem reflect.Value, rawKey string, parts []string, rawVal string) error {
+	s.key, s.parts, s.field, s.timeLayout = rawKey, parts, "", ""
+	s.redacted, s.redactions = false, s.redactions[:0]
+	if err := s.checkValue(rawVal); err != nil {
+		return err
+	}
+	s.warnDeprecatedKey()
+	s.ranks = s.ranks[:0]
+	s.resolved = false
+
+	// Keys of the key map go to their field path, bypassing the matching
+	if fieldPath, ok := s.opts.keyPath(rawKey); ok {
+		s.countKey(true)
+		rawVal, err := s.resolveOnce(rawVal)
+		if err != nil {
+			return err
+		}
+		return s.assignPath(elem, reflect.StructField{}, strings.Split(fieldPath, "."), rawVal, "")
+	}
+
+	// Keys starting with the prefix of a field go to that field
+	matched, err := s.assignPrefixed(elem, parts, rawVal)
+	if !matched && err == nil {
+		matched, err = s.assignValue(elem, parts, rawVal, "")
+	}
+	s.countKey(matched)
+	if err == nil && !matched && s.opts.unknownKey != nil {
+		err = s.opts.unknownKey(rawKey, rawVal)
+	}
+	return err
+}
+
+// decodeMap puts every key of flatMap as is in the map elem.
+func (s *decodeState) decodeMap(elem reflect.Value, flatMap map[string]string) error {
+	if elem.IsNil() {
+		elem.Set(reflect.MakeMap(elem.Type()))
+	}
+	flatMap = s.dropIgnored(flatMap)
+	s.recordFlat(flatMap)
+	keys := make([]string, 0, len(flatMap))
+	for rawKey := range flatMap {
+		keys = append(keys, rawKey)
+	}
+	sort.Strings(keys)
+
+	var partial PartialError
+	for _, rawKey := range keys {
+		rawVal := flatMap[rawKey]
+		s.key = rawKey
+		err := s.checkValue(rawVal)
+		if err == nil {
+			s.warnDeprecatedKey()
+			s.countKey(true)
+			rawVal, err = s.resolveSecret(rawVal)
+		}
+		if err == nil {
+			err = s.reportEntry(s.setMapValue(elem, rawKey, rawVal), elem, "", rawKey)
+		}
+		if err == nil {
+			continue
+		}
+		err = &KeyError{Key: rawKey, Err: err}
+		if !s.opts.allowPartial {
+			return err
 		}
+		partial.Errors = append(partial.Errors, err)
 	}
+	return partial.orNil()
+}
 
+// checkValue checks rawVal against the limits set by the options.
+func (s *decodeState) checkValue(rawVal string) error {
+	if s.opts.maxValueLength > 0 && len(rawVal) > s.opts.maxValueLength {
+		return fmt.Errorf("value of %d bytes exceeds the maximum length of %d bytes", len(rawVal), s.opts.maxValueLength)
+	}
 	return nil
 }
 
-// assignValue trying to put rawVal line in the field v (reflect.Value of a struct)
-func assignValue(v reflect.Value, parts []string, rawVal string) error {
+// claim reports whether the current key may set the field at path and, if
+// so, records the key as the one which set it. A field set by a key matched
+// through higher priority names (see fieldNames) is not overwritten by a key
+// matched through lower priority ones.
+func (s *decodeState) claim(path string) bool {
+	if prev, ok := s.assigned[path]; ok && slices.Compare(prev, s.ranks) < 0 {
+		return false
+	}
+	s.assigned[path] = slices.Clone(s.ranks)
+	return true
+}
+
+// descendPtr calls assign with the struct the pointer ptr points to,
+// allocating it with its defaults when nil. A pointer allocated for the call
+// is set back to nil unless a field of the struct took the key without
+// failing, so that an optional *Sub section only reached by a key matching
+// none of its fields, such as DB_FOO for a DB *struct{ Host string }, stays
+// nil and "is this section configured?" checks keep working.
+func (s *decodeState) descendPtr(ptr reflect.Value, assign func(elem reflect.Value) (bool, error)) (bool, error) {
+	if !ptr.IsNil() {
+		return assign(ptr.Elem())
+	}
+	newPtr, err := s.newValue(ptr.Type().Elem())
+	if err != nil {
+		return true, err
+	}
+	if err := setWithReflect(ptr, newPtr); err != nil {
+		return true, err
+	}
+
+	matched, err := assign(ptr.Elem())
+	if !matched || err != nil {
+		if resetErr := setWithReflect(ptr, reflect.Zero(ptr.Type())); err == nil {
+			err = resetErr
+		}
+	}
+	return matched, err
+}
+
+// assignValue trying to put rawVal line in the field v (reflect.Value of a struct), path is the path of v.
+// It reports whether a field matched the key.
+func (s *decodeState) assignValue(v reflect.Value, parts []string, rawVal, path string) (bool, error) {
 	typ := v.Type()
 
-	// We sort out all the prefixes from complete to the minimum
-	for prefixLen := len(parts); prefixLen >= 1; prefixLen-- {
-		prefixJoined := strings.Join(parts[:prefixLen], "_")
-		normalizedPrefix := normalize(prefixJoined)
+	// We look for the field matching the longest prefix of the key
+	i, prefixLen, rank, err := s.match(typ, parts)
+	if err != nil {
+		return false, err
+	}
+	if i < 0 {
+		// Not a single prefix was found - capture the key in the inline map, if any, or just ignore this key
+		if i = s.opts.inlineField(typ); i >= 0 {
+			return true, s.assignInline(getFieldValue(v, i), typ.Field(i), parts, rawVal, joinPath(path, typ.Field(i).Name))
+		}
+		return false, nil
+	}
 
-		for i := 0; i < typ.NumField(); i++ {
-			field := typ.Field(i)
-			// normalize The name of the field and the name of his type
-			fieldNameNorm := normalize(field.Name)
-			fieldTypeNameNorm := normalize(field.Type.Name())
+	field := typ.Field(i)
+	s.ranks = append(s.ranks, rank)
+	fieldPath := joinPath(path, field.Name)
+	s.field = fieldPath
+	s.markField(field)
+	if s.redacted {
+		s.redactValue(rawVal, s.opts.sliceSep(field))
+	}
+	if rawVal, err = s.resolveOnce(rawVal); err != nil {
+		return true, err
+	}
+	if rawVal, err = s.readFile(field, rawVal, fieldPath); err != nil {
+		return true, err
+	}
+	if rawVal, err = s.preprocess(field, rawVal); err != nil {
+		return true, err
+	}
+	if s.redacted {
+		s.redactValue(rawVal, s.opts.sliceSep(field))
+	}
 
-			// If neither the name of the field, nor the name of its type coincide with NormalizedPrefix, we miss
-			if fieldNameNorm != normalizedPrefix && fieldTypeNameNorm != normalizedPrefix {
-				continue
-			}
+	// Found a suitable field - we get it through Unsafe to work with private fields
+	fieldVal := getFieldValue(v, i)
+	leftover := parts[prefixLen:] // сегменты «после» текущего префикса
 
-			// Found a suitable field - we get it through Unsafe to work with private fields
-			fieldVal := getFieldValue(v, i)
-			leftover := parts[prefixLen:] // сегменты «после» текущего префикса
+	// Unmarshaler fields get their keys once every key is decoded
+	if isUnmarshaler(fieldVal.Type()) {
+		return true, s.collectEnv(fieldVal, leftover, rawVal, fieldPath)
+	}
+	if err := s.resetField(fieldVal, fieldPath); err != nil {
+		return true, err
+	}
 
-			// 1) If Leftover is empty, this is the “final” field: the basic type or pointer to the base
-			if len(leftover) == 0 {
-				return setBasicValue(fieldVal, rawVal)
-			}
+	// 1) If Leftover is empty, this is the “final” field: the basic type or pointer to the base
+	if len(leftover) == 0 {
+		if fieldVal.Kind() == reflect.Map && s.opts.hasFormat(field, formatQuery) {
+			return true, s.reportSet(s.assignQuery(fieldVal, rawVal, fieldPath), fieldPath, fieldVal)
+		}
+		if acceptsMembers(fieldVal.Type()) {
+			return true, s.reportSet(s.assignSet(fieldVal, rawVal, s.opts.sliceSep(field), fieldPath), fieldPath, fieldVal)
+		}
+		if !acceptsScalar(fieldVal.Type()) {
+			return true, s.scalarToContainer(field, fieldVal.Type())
+		}
+		if isIndexedSlice(fieldVal.Type()) && !s.acceptsSliceValue(fieldPath) {
+			return true, nil
+		}
+		return true, s.assignField(fieldVal, field, rawVal, fieldPath)
+	}
 
-			// 2) Otherwise you need to "go down" or put in a container
-			switch fieldVal.Kind() {
-			case reflect.Ptr:
-				// Pointer: if nil - create a new one; Then we expect Struct and recursively descend
-				if fieldVal.IsNil() {
-					newPtr := reflect.New(fieldVal.Type().Elem())
-					if err := setWithReflect(fieldVal, newPtr); err != nil {
-						return err
-					}
-				}
-				elem := fieldVal.Elem()
-				if elem.Kind() == reflect.Struct {
-					return assignValue(elem, leftover, rawVal)
-				}
-				return fmt.Errorf("cannot descend into pointer field %q (kind %s), leftover %v", field.Name, elem.Kind(), leftover)
+	// 2) Otherwise you need to "go down" or put in a container
+	if setter, ok, err := mapSetter(fieldVal); ok || err != nil {
+		if err != nil {
+			return true, err
+		}
+		mapKey := strings.Join(leftover, "_")
+		if !s.claim(fieldPath + "[" + mapKey + "]") {
+			return true, nil
+		}
+		return true, s.reportSet(setter.Set(mapKey, s.opts.formatValue(field, rawVal)), fieldPath+"["+mapKey+"]", fieldVal)
+	}
 
-			case reflect.Struct:
-				// Invested structure - recursively descend
-				return assignValue(fieldVal, leftover, rawVal)
+	if fieldVal.Type() == mimeHeaderType {
+		return true, s.assignHeader(fieldVal, field, leftover, rawVal, fieldPath)
+	}
+	if derefType(fieldVal.Type()) == syncMapType {
+		return true, s.assignSyncMap(fieldVal, field, leftover, rawVal, fieldPath)
+	}
+
+	switch fieldVal.Kind() {
+	case reflect.Ptr:
+		// Pointer: we expect Struct and recursively descend, creating it if nil
+		if elemType := fieldVal.Type().Elem(); elemType.Kind() != reflect.Struct {
+			return true, s.containerToScalar(field, elemType, leftover)
+		}
+		return s.descendPtr(fieldVal, func(elem reflect.Value) (bool, error) {
+			return s.assignValue(elem, leftover, rawVal, fieldPath)
+		})
 
+	case reflect.Struct:
+		if isAtomicType(fieldVal.Type()) || isBinaryType(fieldVal.Type()) || isPflagType(fieldVal.Type()) {
+			return true, s.containerToScalar(field, fieldVal.Type(), leftover)
+		}
+		// Invested structure - recursively descend
+		return s.assignValue(fieldVal, leftover, rawVal, fieldPath)
+
+	case reflect.Map:
+		// Map: leftover gives the key, and the fields of struct values
+		return s.assignMapValue(fieldVal, field, leftover, rawVal, fieldPath)
+
+	case reflect.Interface:
+		// Interface: the TYPE subkey gives the concrete type to descend into
+		if isRegisteredInterface(fieldVal.Type()) {
+			return s.assignRegistered(fieldVal, field, leftover, rawVal, fieldPath)
+		}
+		return true, s.containerToScalar(field, fieldVal.Type(), leftover)
+
+	case reflect.Slice:
+		//Cut: Leftover [0] - index (number), leftover [1:] - investment inside the element (if any)
+		idxStr := leftover[0]
+		ix, err := strconv.Atoi(idxStr)
+		if err != nil || ix < 0 {
+			return true, fmt.Errorf("cannot parse slice index %q for field %q", idxStr, field.Name)
+		}
+		if err := s.opts.checkIndex(field, ix); err != nil {
+			return true, err
+		}
+		if ok, err := s.acceptsSliceIndex(fieldVal, fieldPath); !ok || err != nil {
+			return true, err
+		}
+		if err := s.presizeSlice(fieldVal, field, leftover); err != nil {
+			return true, err
+		}
+		// We take out the element, growing the slice if necessary
+		elemVal, err := sliceElem(fieldVal, ix)
+		if err != nil {
+			return true, err
+		}
+		elemPath := fieldPath + "[" + strconv.Itoa(ix) + "]"
+		// If after the index there is an investment
+		if len(leftover) > 1 {
+			switch elemVal.Kind() {
+			case reflect.Ptr:
+				return s.descendPtr(elemVal, func(elem reflect.Value) (bool, error) {
+					return s.assignValue(elem, leftover[1:], rawVal, elemPath)
+				})
+			case reflect.Struct:
+				return s.assignValue(elemVal, leftover[1:], rawVal, elemPath)
 			case reflect.Map:
-				// Map: leftover We combine, get the key; Rawval - meaning
-				if len(leftover) == 0 {
-					return fmt.Errorf("map field %q but no key given (leftover is empty)", field.Name)
-				}
-				if fieldVal.IsNil() { // initialize map if it needed
-					newMap := reflect.MakeMap(fieldVal.Type())
-					if err := setWithReflect(fieldVal, newMap); err != nil {
-						return err
-					}
-				}
-				mapKey := strings.Join(leftover, "_")
-				return setMapValue(fieldVal, mapKey, rawVal)
-
-			case reflect.Slice:
-				//Cut: Leftover [0] - index (number), leftover [1:] - investment inside the element (if any)
-				idxStr := leftover[0]
-				ix, err := strconv.Atoi(idxStr)
-				if err != nil {
-					return fmt.Errorf("cannot parse slice index %q for field %q", idxStr, field.Name)
-				}
-				// If the nil slice is initialized empty
-				if fieldVal.IsNil() {
-					newSlice := reflect.MakeSlice(fieldVal.Type(), 0, 0)
-					if err := setWithReflect(fieldVal, newSlice); err != nil {
-						return err
-					}
-				}
-				// We expand the cut if necessary
-				curLen := fieldVal.Len()
-				if ix >= curLen {
-					newLen := ix + 1
-					newSlice := reflect.MakeSlice(fieldVal.Type(), newLen, newLen)
-					// Copy elements in a new cut
-					for j := 0; j < curLen; j++ {
-						elem := fieldVal.Index(j)
-						target := newSlice.Index(j)
-						setWithReflect(target, elem)
-					}
-					if err := setWithReflect(fieldVal, newSlice); err != nil {
-						return err
-					}
-				}
-				// We take out the element
-				elemVal := fieldVal.Index(ix)
-				// If after the index there is an investment
-				if len(leftover) > 1 {
-					switch elemVal.Kind() {
-					case reflect.Ptr:
-						if elemVal.IsNil() {
-							newPtr := reflect.New(elemVal.Type().Elem())
-							if err := setWithReflect(elemVal, newPtr); err != nil {
-								return err
-							}
-						}
-						return assignValue(elemVal.Elem(), leftover[1:], rawVal)
-					case reflect.Struct:
-						return assignValue(elemVal, leftover[1:], rawVal)
-					default:
-						return fmt.Errorf("cannot descend into slice element kind %s for field %q", elemVal.Kind(), field.Name)
-					}
+				// A map element: the remaining segments give its key, as for a map field
+				return s.assignMapValue(elemVal, field, leftover[1:], rawVal, elemPath)
+			case reflect.Interface:
+				if isRegisteredInterface(elemVal.Type()) {
+					return s.assignRegistered(elemVal, field, leftover[1:], rawVal, elemPath)
 				}
-				// Otherwise - just the basic assignment in the element
-				return setBasicValue(elemVal, rawVal)
-
+				fallthrough
 			default:
-				// Not a container, but there is Leftover - an incorrect attachment
-				return fmt.Errorf("cannot descend into field %q (kind %s), leftover %v", field.Name, fieldVal.Kind(), leftover)
+				return true, fmt.Errorf("cannot descend into slice element kind %s for field %q", elemVal.Kind(), field.Name)
 			}
 		}
+		// Otherwise - just the basic assignment in the element
+		if !s.claim(elemPath) {
+			return true, nil
+		}
+		return true, s.reportSet(s.setFieldValue(elemVal, field, rawVal), elemPath, elemVal)
+
+	default:
+		// Not a container, but there is Leftover - an incorrect attachment
+		return true, s.containerToScalar(field, fieldVal.Type(), leftover)
 	}
+}
 
-	// Not a single prefix was found - just ignore this key
-	return nil
+// sliceElem returns the element ix of the slice fieldVal, growing the slice
+// to ix+1 elements when it is shorter.
+func sliceElem(fieldVal reflect.Value, ix int) (reflect.Value, error) {
+	// If the nil slice is initialized empty
+	if fieldVal.IsNil() {
+		newSlice := reflect.MakeSlice(fieldVal.Type(), 0, 0)
+		if err := setWithReflect(fieldVal, newSlice); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	// We expand the cut if necessary
+	curLen := fieldVal.Len()
+	if ix >= curLen {
+		newLen := ix + 1
+		newSlice := reflect.MakeSlice(fieldVal.Type(), newLen, newLen)
+		// Copy elements in a new cut
+		for j := 0; j < curLen; j++ {
+			elem := fieldVal.Index(j)
+			target := newSlice.Index(j)
+			setWithReflect(target, elem)
+		}
+		if err := setWithReflect(fieldVal, newSlice); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return fieldVal.Index(ix), nil
+}
+
+// assignInline puts rawVal in the inline map field under the whole remaining key
+func (s *decodeState) assignInline(fieldVal reflect.Value, field reflect.StructField, parts []string, rawVal, fieldPath string) error {
+	if err := s.resetField(fieldVal, fieldPath); err != nil {
+		return err
+	}
+	if fieldVal.IsNil() {
+		if err := setWithReflect(fieldVal, reflect.MakeMap(fieldVal.Type())); err != nil {
+			return err
+		}
+	}
+	mapKey := strings.Join(parts, "_")
+	if !s.claim(fieldPath + "[" + mapKey + "]") {
+		return nil
+	}
+	rawVal, err := s.resolveOnce(rawVal)
+	if err != nil {
+		return err
+	}
+	if rawVal, err = s.preprocess(field, rawVal); err != nil {
+		return err
+	}
+	return s.reportEntry(s.setMapValue(fieldVal, mapKey, s.opts.formatValue(field, rawVal)), fieldVal, fieldPath, mapKey)
+}
+
+// joinPath appends the field name to the path of its struct
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
 }
 
 // getFieldValue receives the value of the field by index with support for private fields through unsafe
@@ -199,8 +698,60 @@ func getFieldValue(structVal reflect.Value, fieldIndex int) reflect.Value {
 	return field
 }
 
-// setBasicValue Converts the rawVal line into the basic type FieldVal.type ()
-func setBasicValue(fieldVal reflect.Value, rawVal string) error {
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// setBasicValue Converts the rawVal line into the basic type FieldVal.type (),
+// then runs the post-processor of the type, see WithValuePostProcessors.
+func (s *decodeState) setBasicValue(fieldVal reflect.Value, rawVal string) error {
+	if err := s.convertBasicValue(fieldVal, rawVal); err != nil {
+		return err
+	}
+	return s.postProcess(fieldVal)
+}
+
+// convertBasicValue does the conversion of setBasicValue.
+func (s *decodeState) convertBasicValue(fieldVal reflect.Value, rawVal string) error {
+	// The typed values of sync/atomic store the value they hold
+	if isAtomicType(fieldVal.Type()) {
+		return s.setAtomicValue(fieldVal, rawVal)
+	}
+
+	// Empty values give the zero value of scalars, if the options ask for it
+	if rawVal == "" && s.opts.zeroEmptyStrings && isZeroableKind(fieldVal.Kind()) {
+		return setWithReflect(fieldVal, reflect.Zero(fieldVal.Type()))
+	}
+
+	// Host names of addresses are looked up, if the options ask for it
+	if s.opts.resolveAddrs {
+		if ok, err := setResolvedAddr(fieldVal, rawVal); ok {
+			return err
+		}
+	}
+
+	// Times in the layout of a `timeformat` tag
+	if s.timeLayout != "" && fieldVal.Type() == timeType {
+		return s.setTimeValue(fieldVal, rawVal)
+	}
+
+	// Registered converters come first
+	if convert := converter(fieldVal.Type()); convert != nil {
+		cv, err := convert(rawVal)
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as %s: %w", rawVal, fieldVal.Type(), err)
+		}
+		return setWithReflect(fieldVal, cv)
+	}
+
+	// Then the types decoding themselves from bytes
+	if isBinaryType(fieldVal.Type()) {
+		return setBinaryValue(fieldVal, rawVal)
+	}
+
+	// And the pflag values, setting themselves from the text
+	if isPflagType(fieldVal.Type()) {
+		return setPflagValue(fieldVal, rawVal)
+	}
+
 	// A special case: time.Duration
 	if fieldVal.Type() == reflect.TypeOf(time.Duration(0)) {
 		dur, err := time.ParseDuration(rawVal)
@@ -213,32 +764,55 @@ func setBasicValue(fieldVal reflect.Value, rawVal string) error {
 	ft := fieldVal.Type()
 	kind := ft.Kind()
 
+	// A special case: json.RawMessage receives the raw bytes, without parsing
+	if ft == rawMessageType {
+		return setWithReflect(fieldVal, reflect.ValueOf(json.RawMessage(rawVal)))
+	}
+
 	var cv reflect.Value
 	switch kind {
 	case reflect.String:
-		cv = reflect.ValueOf(rawVal).Convert(ft)
+		cv = reflect.ValueOf(s.intern(rawVal)).Convert(ft)
 	case reflect.Bool:
-		b, err := strconv.ParseBool(rawVal)
+		b, err := s.parseBool(rawVal)
 		if err != nil {
-			return fmt.Errorf("cannot parse %q as bool: %w", rawVal, err)
+			return err
 		}
 		cv = reflect.ValueOf(b).Convert(ft)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		i, err := strconv.ParseInt(rawVal, 10, ft.Bits())
+		num, err := s.numberText(rawVal, "int")
+		if err != nil {
+			return err
+		}
+		i, err := strconv.ParseInt(num, 10, ft.Bits())
 		if err != nil {
 			return fmt.Errorf("cannot parse %q as int: %w", rawVal, err)
 		}
+		if err := s.checkExact(rawVal, num, "int", strconv.FormatInt(i, 10)); err != nil {
+			return err
+		}
 		cv = reflect.ValueOf(i).Convert(ft)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		u, err := strconv.ParseUint(rawVal, 10, ft.Bits())
+		num, err := s.numberText(rawVal, "uint")
+		if err != nil {
+			return err
+		}
+		u, err := strconv.ParseUint(num, 10, ft.Bits())
 		if err != nil {
 			return fmt.Errorf("cannot parse %q as uint: %w", rawVal, err)
 		}
+		if err := s.checkExact(rawVal, num, "uint", strconv.FormatUint(u, 10)); err != nil {
+			return err
+		}
 		cv = reflect.ValueOf(u).Convert(ft)
 	case reflect.Float32, reflect.Float64:
-		f, err := strconv.ParseFloat(rawVal, ft.Bits())
+		num, err := s.numberText(rawVal, "float")
 		if err != nil {
-			return fmt.Errorf("cannot parse %q as float: %w", rawVal, err)
+			return err
+		}
+		f, err := s.parseFloat(rawVal, num, ft.Bits())
+		if err != nil {
+			return err
 		}
 		cv = reflect.ValueOf(f).Convert(ft)
 	case reflect.Complex64, reflect.Complex128:
@@ -255,7 +829,14 @@ func setBasicValue(fieldVal reflect.Value, rawVal string) error {
 				return err
 			}
 		}
-		return setBasicValue(fieldVal.Elem(), rawVal)
+		return s.setBasicValue(fieldVal.Elem(), rawVal)
+	case reflect.Slice:
+		// slice given as a single value: []byte takes the bytes, other slices split it
+		if ft.Elem().Kind() == reflect.Uint8 {
+			cv = bytesValue(ft, rawVal)
+			break
+		}
+		return s.setSliceValue(fieldVal, rawVal, defaultSliceSep)
 	default:
 		return fmt.Errorf("unsupported kind %s for value %q", kind, rawVal)
 	}
@@ -263,6 +844,118 @@ func setBasicValue(fieldVal reflect.Value, rawVal string) error {
 	return setWithReflect(fieldVal, cv)
 }
 
+// bytesValue returns the bytes of rawVal as a value of the byte slice type t,
+// whose elements may be of a named byte type, which Convert rejects.
+func bytesValue(t reflect.Type, rawVal string) reflect.Value {
+	v := reflect.New(t).Elem()
+	v.SetBytes([]byte(rawVal))
+	return v
+}
+
+// defaultSliceSep separates the elements of a slice given as a single value.
+const defaultSliceSep = ","
+
+// sepTag is the tag giving the separator of slice elements, see sliceSep.
+const sepTag = "sep"
+
+// lineSep separates elements given one per line.
+const lineSep = "\n"
+
+// splitElems splits rawVal into slice elements on sep. A newline separator
+// gives the non-blank lines, and any other separator made of whitespace
+// splits on runs of any whitespace, so "1s  2s" and "1s\t2s" give the two
+// elements 1s and 2s, without empty ones. Other separators are not split
+// on inside double quotes, see splitQuoted.
+func splitElems(rawVal, sep string) []string {
+	if sep == lineSep {
+		var lines []string
+		for _, line := range strings.Split(rawVal, lineSep) {
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+			}
+		}
+		return lines
+	}
+	if strings.TrimSpace(sep) == "" {
+		return strings.Fields(rawVal)
+	}
+	if rawVal == "" {
+		return nil
+	}
+	if strings.Contains(rawVal, `"`) && !strings.Contains(sep, `"`) {
+		if elems, ok := splitQuoted(rawVal, sep); ok {
+			return elems
+		}
+	}
+	return strings.Split(rawVal, sep)
+}
+
+// splitQuoted splits rawVal on the occurrences of sep outside double quotes,
+// then strips the quotes around whole elements, so a:"x,y",b:z gives
+// a:"x,y" and b:z, and "x,y",z gives x,y and z. It reports false when a
+// quote is left open, the quotes then being taken literally.
+func splitQuoted(rawVal, sep string) ([]string, bool) {
+	var elems []string
+	start := 0
+	quoted := false
+	for i := 0; i < len(rawVal); {
+		switch {
+		case rawVal[i] == '"':
+			quoted = !quoted
+			i++
+		case !quoted && strings.HasPrefix(rawVal[i:], sep):
+			elems = append(elems, unquoteElem(rawVal[start:i]))
+			i += len(sep)
+			start = i
+		default:
+			i++
+		}
+	}
+	if quoted {
+		return nil, false
+	}
+	return append(elems, unquoteElem(rawVal[start:])), true
+}
+
+// unquoteElem strips the double quotes around the element elem, ignoring
+// the spaces around them.
+func unquoteElem(elem string) string {
+	trimmed := strings.TrimSpace(elem)
+	if len(trimmed) >= 2 && trimmed[0] == '"' && trimmed[len(trimmed)-1] == '"' {
+		return trimmed[1 : len(trimmed)-1]
+	}
+	return elem
+}
+
+// isZeroableKind reports whether an empty value gives the zero value of the
+// kind under WithZeroEmptyStrings: numbers, booleans and durations.
+func isZeroableKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	}
+	return false
+}
+
+// setSliceValue splits rawVal on sep and converts every element, trimmed of
+// spaces, into a new slice set in fieldVal: HOSTS=a, b gives [a b]. A value
+// without sep is a single element, so HOSTS=a gives [a] without having to
+// be written HOSTS_0=a. An empty value gives an empty slice.
+func (s *decodeState) setSliceValue(fieldVal reflect.Value, rawVal, sep string) error {
+	elems := splitElems(rawVal, sep)
+
+	newSlice := reflect.MakeSlice(fieldVal.Type(), len(elems), len(elems))
+	for i, elem := range elems {
+		if err := s.setBasicValue(newSlice.Index(i), strings.TrimSpace(elem)); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	return setWithReflect(fieldVal, newSlice)
+}
+
 // setWithReflect writes cv in FieldVal, supporting private fields via Unsafe
 func setWithReflect(fieldVal, cv reflect.Value) error {
 	// Пытаемся обычный способ для экспортируемых полей
@@ -283,30 +976,65 @@ func setWithReflect(fieldVal, cv reflect.Value) error {
 }
 
 // setMapValue Load rawVal (string) in map[string]x
-func setMapValue(mapVal reflect.Value, mapKey, rawVal string) error {
-	keyType := mapVal.Type().Key()
+func (s *decodeState) setMapValue(mapVal reflect.Value, mapKey, rawVal string) error {
 	valType := mapVal.Type().Elem()
 
-	// We support only string keys
-	if keyType.Kind() != reflect.String {
-		return fmt.Errorf("unsupported map key type %s; only string keys allowed", keyType.Kind())
+	// Check the key before parsing the value
+	if _, err := mapKeyValue(mapVal.Type().Key(), mapKey); err != nil {
+		return err
 	}
 
 	// We convert rawVal to the type of Valtype
 	var cv reflect.Value
-	if valType.Kind() == reflect.Interface && valType.NumMethod() == 0 {
-		cv = reflect.ValueOf(rawVal)
-	} else {
+	switch {
+	case isAnyType(valType):
+		cv = reflect.ValueOf(s.intern(rawVal))
+	case isSetType(mapVal.Type()):
+		// The key is the set member, the value is ignored
+		cv = reflect.Zero(valType)
+	case isFlagMapType(mapVal.Type()) && strings.TrimSpace(rawVal) == "":
+		// A boolean key listed without a value is present, so true
+		cv = reflect.ValueOf(true).Convert(valType)
+	default:
 		tmp := reflect.New(valType).Elem()
-		if err := setBasicValue(tmp, rawVal); err != nil {
+		if err := s.setBasicValue(tmp, rawVal); err != nil {
 			return err
 		}
 		cv = tmp
 	}
 
+	return storeMapValue(mapVal, mapKey, cv)
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// mapKeyValue converts mapKey into a key of keyType: string kinds are
+// converted, and key types implementing encoding.TextUnmarshaler (through a
+// pointer) decode the key with UnmarshalText.
+func mapKeyValue(keyType reflect.Type, mapKey string) (reflect.Value, error) {
+	if reflect.PointerTo(keyType).Implements(textUnmarshalerType) {
+		key := reflect.New(keyType)
+		if err := key.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(mapKey)); err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot parse map key %q as %s: %w", mapKey, keyType, err)
+		}
+		return key.Elem(), nil
+	}
+	if keyType.Kind() != reflect.String {
+		return reflect.Value{}, fmt.Errorf("unsupported map key type %s; only string and encoding.TextUnmarshaler keys allowed", keyType)
+	}
+	return reflect.ValueOf(mapKey).Convert(keyType), nil
+}
+
+// storeMapValue set cv in MAP under mapKey, supporting private map fields
+func storeMapValue(mapVal reflect.Value, mapKey string, cv reflect.Value) error {
+	key, err := mapKeyValue(mapVal.Type().Key(), mapKey)
+	if err != nil {
+		return err
+	}
+
 	// Set the value in MAP
 	if mapVal.CanSet() {
-		mapVal.SetMapIndex(reflect.ValueOf(mapKey), cv)
+		mapVal.SetMapIndex(key, cv)
 		return nil
 	}
 
@@ -314,15 +1042,9 @@ func setMapValue(mapVal reflect.Value, mapKey, rawVal string) error {
 	if mapVal.CanAddr() {
 		ptr := unsafe.Pointer(mapVal.UnsafeAddr())
 		realMap := reflect.NewAt(mapVal.Type(), ptr).Elem()
-		realMap.SetMapIndex(reflect.ValueOf(mapKey), cv)
+		realMap.SetMapIndex(key, cv)
 		return nil
 	}
 
 	return fmt.Errorf("cannot set map key %q on unexported field", mapKey)
 }
-
-// Normalize delete everything '_' and translates the line to the lower register
-func normalize(s string) string {
-	s = strings.ToLower(s)
-	return strings.ReplaceAll(s, "_", "")
-}
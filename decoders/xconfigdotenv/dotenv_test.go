@@ -1,6 +1,9 @@
 package xconfigdotenv_test
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -237,3 +240,372 @@ func TestDecoderUnmarshalToStruct(t *testing.T) {
 	assert.Equal(t, "snake_case", config.test_3)
 	assert.Equal(t, "Mixed_Snake_Case", config.Test_4)
 }
+
+type anyConfig struct {
+	Host string
+	Port int
+}
+
+func TestDecoderUnmarshalInterface(t *testing.T) {
+	decoder := xconfigdotenv.New()
+	data := []byte("HOST=localhost\nPORT=8080")
+
+	t.Run("struct value", func(t *testing.T) {
+		var config any = anyConfig{Host: "default"}
+		err := decoder.Unmarshal(data, &config)
+		assert.NoError(t, err)
+		assert.Equal(t, anyConfig{Host: "localhost", Port: 8080}, config)
+	})
+
+	t.Run("struct value failing", func(t *testing.T) {
+		var config any = anyConfig{Host: "default"}
+		err := decoder.Unmarshal([]byte("HOST=localhost\nPORT=x"), &config)
+		assert.Error(t, err)
+		assert.Equal(t, anyConfig{Host: "default"}, config)
+
+		// with partial errors the keys which decoded are kept
+		err = xconfigdotenv.New(xconfigdotenv.WithAllowPartial()).Unmarshal([]byte("HOST=localhost\nPORT=x"), &config)
+		assert.Error(t, err)
+		assert.Equal(t, anyConfig{Host: "localhost"}, config)
+	})
+
+	t.Run("struct pointer", func(t *testing.T) {
+		target := &anyConfig{}
+		var config any = target
+		err := decoder.Unmarshal(data, &config)
+		assert.NoError(t, err)
+		assert.Same(t, target, config)
+		assert.Equal(t, anyConfig{Host: "localhost", Port: 8080}, *target)
+	})
+
+	t.Run("nil interface", func(t *testing.T) {
+		var config any
+		err := decoder.Unmarshal(data, &config)
+		assert.ErrorContains(t, err, "nil interface")
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		var config any = 42
+		err := decoder.Unmarshal(data, &config)
+		assert.ErrorContains(t, err, "interface holding int")
+	})
+}
+
+func TestDecoderUnmarshalSliceValue(t *testing.T) {
+	var config struct {
+		Hosts []string
+		Ports []int
+		Empty []string
+		Data  []byte
+	}
+	err := xconfigdotenv.New().Unmarshal([]byte("HOSTS=a, b,c\nPORTS=80,443\nEMPTY=\nDATA=bytes"), &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"a", "b", "c"}, config.Hosts)
+	assert.Equal(t, []int{80, 443}, config.Ports)
+	assert.Equal(t, []string{}, config.Empty)
+	assert.Equal(t, []byte("bytes"), config.Data)
+
+	err = xconfigdotenv.New().Unmarshal([]byte("PORTS=80,http"), &config)
+	assert.ErrorContains(t, err, "element 1")
+}
+
+func TestDecoderUnmarshalNegative(t *testing.T) {
+	var config struct {
+		Delta    int
+		Small    int8
+		Ratio    float64
+		Offset   time.Duration
+		Offsets  []time.Duration
+		Deltas   []int
+		Indexed  []float32
+		Weights  map[string]int
+		Timeouts map[string]time.Duration
+		Unsigned uint
+	}
+	data := []byte(`
+DELTA=-3
+SMALL=-128
+RATIO=-0.5
+OFFSET=-5s
+OFFSETS=-1s,2s,-1m30s
+DELTAS=-1, -2,3
+INDEXED_0=-1.5
+WEIGHTS_A=-10
+TIMEOUTS_B=-2h
+`)
+	err := xconfigdotenv.New().Unmarshal(data, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, -3, config.Delta)
+	assert.Equal(t, int8(-128), config.Small)
+	assert.Equal(t, -0.5, config.Ratio)
+	assert.Equal(t, -5*time.Second, config.Offset)
+	assert.Equal(t, []time.Duration{-time.Second, 2 * time.Second, -90 * time.Second}, config.Offsets)
+	assert.Equal(t, []int{-1, -2, 3}, config.Deltas)
+	assert.Equal(t, []float32{-1.5}, config.Indexed)
+	assert.Equal(t, map[string]int{"A": -10}, config.Weights)
+	assert.Equal(t, map[string]time.Duration{"B": -2 * time.Hour}, config.Timeouts)
+
+	// a sign is out of range for unsigned and small types
+	assert.Error(t, xconfigdotenv.New().Unmarshal([]byte("UNSIGNED=-1"), &config))
+	assert.Error(t, xconfigdotenv.New().Unmarshal([]byte("SMALL=-129"), &config))
+}
+
+func TestDecoderUnmarshalPointerScalars(t *testing.T) {
+	type pool struct {
+		Name  string `default:"main"`
+		Flag  *bool
+		Size  *int
+		Limit *int `default:"5"`
+	}
+
+	type config struct {
+		Flag  *bool
+		Count *int
+		Name  *string
+		Pool  *pool
+	}
+
+	for name, decoder := range map[string]*xconfigdotenv.Decoder{
+		"keep":     xconfigdotenv.New(),
+		"allocate": xconfigdotenv.New(xconfigdotenv.WithNilStructPolicy(xconfigdotenv.NilStructAllocate)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var absent config
+			assert.NoError(t, decoder.Unmarshal([]byte(""), &absent))
+			assert.Nil(t, absent.Flag)
+			assert.Nil(t, absent.Count)
+			assert.Nil(t, absent.Name)
+			if absent.Pool != nil {
+				// allocated for its defaults, the scalars without default stay nil
+				assert.Nil(t, absent.Pool.Flag)
+				assert.Nil(t, absent.Pool.Size)
+				if assert.NotNil(t, absent.Pool.Limit) {
+					assert.Equal(t, 5, *absent.Pool.Limit)
+				}
+			}
+
+			var zero config
+			assert.NoError(t, decoder.Unmarshal([]byte("FLAG=false\nCOUNT=0\nNAME=\nPOOL_FLAG=false"), &zero))
+			if assert.NotNil(t, zero.Flag) {
+				assert.False(t, *zero.Flag)
+			}
+			if assert.NotNil(t, zero.Count) {
+				assert.Equal(t, 0, *zero.Count)
+			}
+			if assert.NotNil(t, zero.Name) {
+				assert.Equal(t, "", *zero.Name)
+			}
+			if assert.NotNil(t, zero.Pool) && assert.NotNil(t, zero.Pool.Flag) {
+				assert.False(t, *zero.Pool.Flag)
+				assert.Nil(t, zero.Pool.Size)
+			}
+		})
+	}
+}
+
+func TestDecoderUnmarshalValue(t *testing.T) {
+	var config struct {
+		Name string
+		DB   struct {
+			Host string
+		}
+	}
+
+	decoder := xconfigdotenv.New()
+	err := decoder.UnmarshalValue([]byte("NAME=app\nDB_HOST=db"), reflect.ValueOf(&config).Elem())
+	assert.NoError(t, err)
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, "db", config.DB.Host)
+
+	// a struct field is addressable too
+	err = decoder.UnmarshalValue([]byte("HOST=other"), reflect.ValueOf(&config).Elem().Field(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "other", config.DB.Host)
+
+	err = decoder.UnmarshalValue(nil, reflect.Value{})
+	assert.EqualError(t, err, "xconfigdotenv: UnmarshalValue: invalid reflect.Value")
+
+	err = decoder.UnmarshalValue(nil, reflect.ValueOf(&config))
+	assert.ErrorContains(t, err, "rv must be a struct, got *struct")
+
+	err = decoder.UnmarshalValue(nil, reflect.ValueOf(config))
+	assert.ErrorContains(t, err, "rv must be addressable")
+}
+
+func TestDecoderUnmarshalSliceSep(t *testing.T) {
+	var config struct {
+		Queues  []string
+		Paths   []string            `sep:";"`
+		Ports   []int               `sep:" "`
+		Modules map[string]struct{} `sep:"|"`
+		Hosts   []string
+	}
+
+	data := []byte("QUEUES=mail_out,mail_in\nPATHS=/a,b;/c_d\nPORTS=\"80 443\"\nMODULES=auth_v2|billing\nHOSTS_0=db_main\nHOSTS_1=db_replica")
+	err := xconfigdotenv.New().Unmarshal(data, &config)
+	assert.NoError(t, err)
+
+	// elements are never split on the key delimiter
+	assert.Equal(t, []string{"mail_out", "mail_in"}, config.Queues)
+	assert.Equal(t, []string{"/a,b", "/c_d"}, config.Paths)
+	assert.Equal(t, []int{80, 443}, config.Ports)
+	assert.Equal(t, map[string]struct{}{"auth_v2": {}, "billing": {}}, config.Modules)
+	assert.Equal(t, []string{"db_main", "db_replica"}, config.Hosts)
+
+	out, err := xconfigdotenv.New().Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "QUEUES=mail_out,mail_in\nPATHS=\"/a,b;/c_d\"\nPORTS=\"80 443\"\nMODULES=\"auth_v2|billing\"\nHOSTS=db_main,db_replica\n", string(out))
+}
+
+func TestDecoderUnmarshalSingleElementSlice(t *testing.T) {
+	var config struct {
+		Hosts    []string
+		Ports    []*int
+		Timeouts []time.Duration
+		Paths    []string `sep:";"`
+	}
+
+	data := []byte("HOSTS=a\nPORTS=80\nTIMEOUTS=1s\nPATHS=/a,b")
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &config))
+	assert.Equal(t, []string{"a"}, config.Hosts)
+	if assert.Len(t, config.Ports, 1) {
+		assert.Equal(t, 80, *config.Ports[0])
+	}
+	assert.Equal(t, []time.Duration{time.Second}, config.Timeouts)
+	// a comma is only a separator for the fields it separates
+	assert.Equal(t, []string{"/a,b"}, config.Paths)
+}
+
+func TestDecoderUnmarshalSliceQuotes(t *testing.T) {
+	var config struct {
+		KV    []string
+		Names []string
+		Roles map[string]struct{} `sep:"|"`
+		Notes []string
+	}
+
+	data := []byte(`KV='a:"x,y",b:z'
+NAMES='"Doe, John", Smith'
+ROLES='"admin|ops"|dev'
+NOTES='say "hi,there'`)
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &config))
+	assert.Equal(t, []string{`a:"x,y"`, "b:z"}, config.KV)
+	assert.Equal(t, []string{"Doe, John", "Smith"}, config.Names)
+	assert.Equal(t, map[string]struct{}{"admin|ops": {}, "dev": {}}, config.Roles)
+	// an open quote is taken literally
+	assert.Equal(t, []string{`say "hi`, "there"}, config.Notes)
+
+	config.Roles = nil
+	out, err := xconfigdotenv.New().Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, `KV_0='a:"x,y"'
+KV_1=b:z
+NAMES_0="Doe, John"
+NAMES_1=Smith
+NOTES_0="say \"hi"
+NOTES_1=there
+`, string(out))
+
+	var decoded struct {
+		KV    []string
+		Names []string
+		Notes []string
+	}
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(out, &decoded))
+	assert.Equal(t, config.KV, decoded.KV)
+	assert.Equal(t, config.Names, decoded.Names)
+	assert.Equal(t, config.Notes, decoded.Notes)
+}
+
+func TestDecoderUnmarshalSliceSepSpaces(t *testing.T) {
+	var config struct {
+		Backoffs []time.Duration     `sep:" "`
+		Weights  []float64           `sep:" "`
+		Tags     map[string]struct{} `sep:" "`
+	}
+
+	data := []byte("BACKOFFS=\"1s  2s \t4s \"\nWEIGHTS=' 0.5   1 '\nTAGS='a  b'")
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &config))
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, config.Backoffs)
+	assert.Equal(t, []float64{0.5, 1}, config.Weights)
+	assert.Equal(t, map[string]struct{}{"a": {}, "b": {}}, config.Tags)
+}
+
+type color int
+
+const (
+	colorRed color = iota + 1
+	colorGreen
+)
+
+func (c *color) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "red":
+		*c = colorRed
+	case "green":
+		*c = colorGreen
+	default:
+		return fmt.Errorf("unknown color %q", text)
+	}
+	return nil
+}
+
+func TestDecoderUnmarshalTextMapKeys(t *testing.T) {
+	var config struct {
+		Hex   map[color]string
+		Hosts map[color]struct{ Addr string }
+	}
+
+	data := []byte("HEX_RED=#f00\nHEX_GREEN=#0f0\nHOSTS_RED_ADDR=r:80")
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &config))
+	assert.Equal(t, map[color]string{colorRed: "#f00", colorGreen: "#0f0"}, config.Hex)
+	assert.Equal(t, "r:80", config.Hosts[colorRed].Addr)
+
+	err := xconfigdotenv.New().Unmarshal([]byte("HEX_BLUE=#00f"), &config)
+	assert.ErrorContains(t, err, `cannot parse map key "BLUE" as xconfigdotenv_test.color: unknown color "BLUE"`)
+
+	var ints struct{ Codes map[int]string }
+	err = xconfigdotenv.New().Unmarshal([]byte("CODES_1=a"), &ints)
+	assert.ErrorContains(t, err, "unsupported map key type int")
+}
+
+func TestDecoderWithParser(t *testing.T) {
+	var config struct {
+		Name string
+		DB   struct {
+			Port int
+		}
+	}
+
+	// a dialect of "key: value" lines, where # is no comment
+	parser := func(data []byte) (map[string]string, error) {
+		values := make(map[string]string)
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				return nil, fmt.Errorf("missing colon in %q", line)
+			}
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		return values, nil
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithParser(parser))
+	assert.NoError(t, decoder.Unmarshal([]byte("name: app #1\ndb_port: 5432\n"), &config))
+	assert.Equal(t, "app #1", config.Name)
+	assert.Equal(t, 5432, config.DB.Port)
+
+	assert.EqualError(t, decoder.Unmarshal([]byte("name=app"), &config), `missing colon in "name=app"`)
+
+	// a parser may give keys godotenv rejects, such as a negative index
+	var hosts struct {
+		Hosts []string
+	}
+	err := decoder.Unmarshal([]byte("hosts_-1: x"), &hosts)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "hosts_-1": cannot parse slice index "-1" for field "Hosts"`)
+}
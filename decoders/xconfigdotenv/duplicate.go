@@ -0,0 +1,79 @@
+package xconfigdotenv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrDuplicateKey is returned under DuplicateError for a key defined more
+// than once in the input.
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// DuplicateKeyPolicy defines what Unmarshal does with a key defined more
+// than once in the input, whose last value is the one decoded.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateIgnore silently keeps the last value. It is the default.
+	DuplicateIgnore DuplicateKeyPolicy = iota
+	// DuplicateWarn keeps the last value and reports the key in the
+	// Warnings of UnmarshalWithMetadata.
+	DuplicateWarn
+	// DuplicateError fails with an ErrDuplicateKey error before any key is
+	// decoded.
+	DuplicateError
+)
+
+// checkDuplicates applies the DuplicateKeyPolicy to the keys of data,
+// which the parsed keys no longer tell apart.
+func (s *decodeState) checkDuplicates(data []byte) error {
+	if s.opts.duplicateKeyPolicy == DuplicateIgnore {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	var keys []string
+	for _, key := range envKeys(data) {
+		if counts[key] == 0 {
+			keys = append(keys, key)
+		}
+		counts[key]++
+	}
+
+	for _, key := range keys {
+		n := counts[key]
+		if n < 2 {
+			continue
+		}
+		if s.opts.duplicateKeyPolicy == DuplicateError {
+			return &KeyError{Key: key, Err: fmt.Errorf("%w: defined %d times", ErrDuplicateKey, n)}
+		}
+		s.key = key
+		s.warn("key defined %d times, the last value wins", n)
+	}
+	s.key = ""
+	return nil
+}
+
+// envKeys returns the keys of the assignments of src in order, repeated
+// keys included. Quoted values spanning lines and comments are skipped.
+func envKeys(src []byte) []string {
+	var keys []string
+	rest := src
+	for len(rest) > 0 {
+		if key, valStart, ok := parseKeyLine(rest); ok {
+			keys = append(keys, key)
+			if len(rest) > valStart && (rest[valStart] == '"' || rest[valStart] == '\'') {
+				rest = rest[valStart+valueLen(rest[valStart:]):]
+			}
+		}
+
+		end := bytes.IndexByte(rest, '\n')
+		if end < 0 {
+			break
+		}
+		rest = rest[end+1:]
+	}
+	return keys
+}
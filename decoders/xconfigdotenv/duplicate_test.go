@@ -0,0 +1,46 @@
+package xconfigdotenv_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateKeyPolicy(t *testing.T) {
+	type config struct {
+		Host string
+		Port int
+		Note string
+	}
+
+	data := []byte(`# PORT=1 is a comment
+HOST=a
+export PORT=80
+NOTE="PORT=2
+HOST=b"
+PORT: 8080
+port=1
+HOST = c # again`)
+
+	// duplicates are ignored by default, the last value wins
+	var c config
+	meta, err := xconfigdotenv.New().UnmarshalWithMetadata(data, &c)
+	assert.NoError(t, err)
+	assert.Equal(t, config{Host: "c", Port: 1, Note: "PORT=2\nHOST=b"}, c)
+	assert.Empty(t, meta.Warnings)
+
+	meta, err = xconfigdotenv.New(xconfigdotenv.WithDuplicateKeyPolicy(xconfigdotenv.DuplicateWarn)).UnmarshalWithMetadata(data, &c)
+	assert.NoError(t, err)
+	assert.Equal(t, []xconfigdotenv.Warning{
+		{Key: "HOST", Message: "key defined 2 times, the last value wins"},
+		{Key: "PORT", Message: "key defined 2 times, the last value wins"},
+	}, meta.Warnings)
+
+	c = config{}
+	err = xconfigdotenv.New(xconfigdotenv.WithDuplicateKeyPolicy(xconfigdotenv.DuplicateError)).Unmarshal(data, &c)
+	assert.True(t, errors.Is(err, xconfigdotenv.ErrDuplicateKey))
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "HOST": duplicate key: defined 2 times`)
+	assert.Equal(t, config{}, c)
+}
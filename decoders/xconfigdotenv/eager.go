@@ -0,0 +1,56 @@
+package xconfigdotenv
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// scanSliceSizes returns, for every run of segments of the keys followed by an
+// index segment, the largest index plus one: HOSTS_0_ADDR and HOSTS_12_ADDR
+// give 13 for HOSTS. The runs are keyed as by sliceSizeKey.
+func (o *options) scanSliceSizes(keys []string) map[string]int {
+	sizes := make(map[string]int)
+	for _, rawKey := range keys {
+		parts := o.splitKey(rawKey)
+		for i := 1; i < len(parts); i++ {
+			ix, err := strconv.Atoi(parts[i])
+			if err != nil || ix < 0 {
+				continue
+			}
+			key := o.sliceSizeKey(parts[:i])
+			sizes[key] = max(sizes[key], ix+1)
+		}
+	}
+	return sizes
+}
+
+// sliceSizeKey joins the segments before an index, upper-cased unless
+// case-sensitive, so the keys of a slice written in several cases count
+// together.
+func (o *options) sliceSizeKey(parts []string) string {
+	key := strings.Join(parts, "_")
+	if o.caseSensitive {
+		return key
+	}
+	return strings.ToUpper(key)
+}
+
+// presizeSlice grows the slice field fieldVal at once to the size the
+// pre-scan of WithEagerSliceSizing found for it, leftover being the segments
+// of the current key from the index on. The size is bounded by the maxlen
+// of the field, whose larger indices fail on their own.
+func (s *decodeState) presizeSlice(fieldVal reflect.Value, field reflect.StructField, leftover []string) error {
+	if s.sliceSizes == nil || len(leftover) > len(s.parts) {
+		return nil
+	}
+	n := s.sliceSizes[s.opts.sliceSizeKey(s.parts[:len(s.parts)-len(leftover)])]
+	if limit, ok, _ := s.opts.maxLen(field); ok {
+		n = min(n, limit)
+	}
+	if n == 0 || (!fieldVal.IsNil() && fieldVal.Len() >= n) {
+		return nil
+	}
+	_, err := sliceElem(fieldVal, n-1)
+	return err
+}
@@ -0,0 +1,67 @@
+package xconfigdotenv_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type eagerConfig struct {
+	Hosts []struct {
+		Addr string
+		Tags []string
+	}
+	Ports   []int `maxlen:"2"`
+	Backups []*struct {
+		Path string
+	}
+}
+
+// eagerInput returns n indexed keys for each slice of eagerConfig.
+func eagerInput(n int) []byte {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "HOSTS_%d_ADDR=h%d\nHOSTS_%d_TAGS_%d=t\nBACKUPS_%d_PATH=/b%d\n", i, i, i, i%3, i, i)
+	}
+	return []byte(b.String())
+}
+
+func TestEagerSliceSizing(t *testing.T) {
+	data := append(eagerInput(25), "PORTS_0=80\nPORTS_1=443\nhosts_30_addr=last"...)
+	var incremental, eager eagerConfig
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &incremental))
+	assert.NoError(t, xconfigdotenv.New(xconfigdotenv.WithEagerSliceSizing()).Unmarshal(data, &eager))
+	assert.Equal(t, incremental, eager)
+	assert.Len(t, eager.Hosts, 31)
+	assert.Equal(t, "last", eager.Hosts[30].Addr)
+	assert.Len(t, eager.Hosts[2].Tags, 3)
+	assert.Equal(t, []int{80, 443}, eager.Ports)
+
+	// an index beyond maxlen still fails, without allocating its size first
+	err := xconfigdotenv.New(xconfigdotenv.WithEagerSliceSizing()).Unmarshal([]byte("PORTS_0=80\nPORTS_1000000000=1"), &eagerConfig{})
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "PORTS_1000000000": index 1000000000 of field Ports is beyond its maxlen of 2`)
+}
+
+func BenchmarkEagerSliceSizing(b *testing.B) {
+	data := eagerInput(2000)
+	for _, bench := range []struct {
+		name string
+		opts []xconfigdotenv.Option
+	}{
+		{"Incremental", nil},
+		{"Eager", []xconfigdotenv.Option{xconfigdotenv.WithEagerSliceSizing()}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			decoder := xconfigdotenv.New(bench.opts...)
+			for i := 0; i < b.N; i++ {
+				var config eagerConfig
+				if err := decoder.Unmarshal(data, &config); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
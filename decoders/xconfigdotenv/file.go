@@ -0,0 +1,68 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// TrailingTrim defines what is trimmed off the end of the contents of the
+// files read for fields tagged `env:",file"`, which editors and `echo` end
+// with a newline that tokens and passwords, e.g. Docker secrets, do not
+// hold.
+type TrailingTrim int
+
+const (
+	// TrailingTrimNewline trims a single trailing newline, "\n" or "\r\n", so
+	// a file holding "s3cr3t\n" gives "s3cr3t" and one holding "a\n\n"
+	// gives "a\n". It is the default.
+	TrailingTrimNewline TrailingTrim = iota
+	// TrailingTrimSpace trims all trailing white space, newlines, spaces and
+	// tabs alike.
+	TrailingTrimSpace
+	// TrailingTrimNone keeps the contents as they are, for files whose
+	// trailing newline is part of the value.
+	TrailingTrimNone
+)
+
+// trim trims the end of the contents data as the TrailingTrim says.
+func (t TrailingTrim) trim(data string) string {
+	switch t {
+	case TrailingTrimNewline:
+		if s, ok := strings.CutSuffix(data, "\n"); ok {
+			return strings.TrimSuffix(s, "\r")
+		}
+		return data
+	case TrailingTrimSpace:
+		return strings.TrimRightFunc(data, unicode.IsSpace)
+	}
+	return data
+}
+
+// readFile returns the contents of the file rawVal is the path of when
+// field, at fieldPath, is tagged `env:",file"`, and rawVal otherwise. The
+// option fixes the key of a field while its contents, such as a TLS
+// certificate, stay in their own file: TLS_CERT=/etc/tls/cert.pem gives the
+// PEM text. Only string and []byte fields, or pointers to them, take the
+// option, and an empty path leaves the value empty. The end of the contents
+// is trimmed as WithTrailingNewlineTrim says, a single trailing newline by
+// default. Marshal writes the contents, not the path.
+func (s *decodeState) readFile(field reflect.StructField, rawVal, fieldPath string) (string, error) {
+	if !s.opts.hasEnvOption(field, envOptionFile) {
+		return rawVal, nil
+	}
+	if t := derefType(field.Type); t.Kind() != reflect.String && (t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8) {
+		return "", fmt.Errorf("option %q of field %q: expecting a string or []byte field, got %s", envOptionFile, field.Name, field.Type)
+	}
+	if rawVal == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(rawVal)
+	if err != nil {
+		return "", fmt.Errorf("cannot read the file of field %s: %w", fieldPath, err)
+	}
+	return s.opts.trailingTrim.trim(string(data)), nil
+}
@@ -0,0 +1,87 @@
+package xconfigdotenv_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileOption(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	assert.NoError(t, os.WriteFile(certPath, []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"), 0o600))
+	assert.NoError(t, os.WriteFile(keyPath, []byte("key bytes"), 0o600))
+
+	var config struct {
+		TLS struct {
+			Cert string  `env:",file"`
+			Key  []byte  `env:"PRIVATE_KEY,file"`
+			CA   *string `env:",file"`
+		}
+	}
+	data := []byte("TLS_CERT=" + certPath + "\nTLS_PRIVATE_KEY=" + keyPath + "\nTLS_CA=")
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &config))
+	// the trailing newline is trimmed by default
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----", config.TLS.Cert)
+	assert.Equal(t, []byte("key bytes"), config.TLS.Key)
+	if assert.NotNil(t, config.TLS.CA) {
+		assert.Equal(t, "", *config.TLS.CA)
+	}
+
+	missing := filepath.Join(dir, "missing.pem")
+	err := xconfigdotenv.New().Unmarshal([]byte("TLS_CERT="+missing), &config)
+	assert.ErrorContains(t, err, "cannot read the file of field TLS.Cert: open "+missing)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	var wrong struct {
+		Port int `env:",file"`
+	}
+	assert.ErrorContains(t, xconfigdotenv.New().Unmarshal([]byte("PORT="+certPath), &wrong), `option "file" of field "Port": expecting a string or []byte field, got int`)
+}
+
+func TestTrailingNewlineTrim(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"token":  "s3cr3t\n",
+		"crlf":   "s3cr3t\r\n",
+		"blank":  "line\n\n",
+		"spaces": "s3cr3t \t\n",
+		"bare":   "s3cr3t",
+	}
+	for name, contents := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600))
+	}
+
+	type config struct {
+		Token  string `env:",file"`
+		CRLF   string `env:",file"`
+		Blank  string `env:",file"`
+		Spaces string `env:",file"`
+		Bare   string `env:",file"`
+		Inline string
+	}
+	data := []byte("TOKEN=" + filepath.Join(dir, "token") +
+		"\nCRLF=" + filepath.Join(dir, "crlf") +
+		"\nBLANK=" + filepath.Join(dir, "blank") +
+		"\nSPACES=" + filepath.Join(dir, "spaces") +
+		"\nBARE=" + filepath.Join(dir, "bare") +
+		"\nINLINE=\"value\\n\"")
+
+	tests := []struct {
+		trim xconfigdotenv.TrailingTrim
+		want config
+	}{
+		{xconfigdotenv.TrailingTrimNewline, config{"s3cr3t", "s3cr3t", "line\n", "s3cr3t \t", "s3cr3t", "value\n"}},
+		{xconfigdotenv.TrailingTrimSpace, config{"s3cr3t", "s3cr3t", "line", "s3cr3t", "s3cr3t", "value\n"}},
+		{xconfigdotenv.TrailingTrimNone, config{"s3cr3t\n", "s3cr3t\r\n", "line\n\n", "s3cr3t \t\n", "s3cr3t", "value\n"}},
+	}
+	for _, tt := range tests {
+		var c config
+		assert.NoError(t, xconfigdotenv.New(xconfigdotenv.WithTrailingNewlineTrim(tt.trim)).Unmarshal(data, &c))
+		assert.Equal(t, tt.want, c, tt.trim)
+	}
+}
@@ -0,0 +1,123 @@
+package xconfigdotenv_test
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type tlsConfig struct {
+	TLSKey  string   `format:"multiline"`
+	Chain   []string `format:"multiline"`
+	Comment string
+}
+
+func TestFormatMultiline(t *testing.T) {
+	block := &pem.Block{Type: "PRIVATE KEY", Bytes: []byte(strings.Repeat("0123456789abcdef", 8))}
+	key := string(pem.EncodeToMemory(block))
+	escaped := strings.ReplaceAll(key, "\n", `\n`)
+
+	tests := map[string]string{
+		"unquoted":      "TLS_KEY=" + escaped,
+		"single quoted": "TLS_KEY='" + escaped + "'",
+		"double quoted": `TLS_KEY="` + escaped + `"`,
+		"multiline":     `TLS_KEY="` + key + `"`,
+		"crlf":          "TLS_KEY='" + strings.ReplaceAll(escaped, `\n`, `\r\n`) + "'",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var config tlsConfig
+			err := xconfigdotenv.New().Unmarshal([]byte(data), &config)
+			assert.NoError(t, err)
+			assert.Equal(t, key, config.TLSKey)
+
+			decoded, rest := pem.Decode([]byte(config.TLSKey))
+			if assert.NotNil(t, decoded) {
+				assert.Equal(t, block.Bytes, decoded.Bytes)
+			}
+			assert.Empty(t, rest)
+		})
+	}
+}
+
+func TestFormatMultilineOnlyTagged(t *testing.T) {
+	var config tlsConfig
+	err := xconfigdotenv.New().Unmarshal([]byte(`COMMENT='a\nb'`+"\n"+`CHAIN_0='c\nd'`), &config)
+	assert.NoError(t, err)
+	assert.Equal(t, `a\nb`, config.Comment)
+	assert.Equal(t, []string{"c\nd"}, config.Chain)
+}
+
+func TestFormatRaw(t *testing.T) {
+	var config struct {
+		Payload json.RawMessage
+		Blob    []byte `format:"raw"`
+		Items   []json.RawMessage
+		Extra   map[string]json.RawMessage
+	}
+	data := []byte(`
+PAYLOAD='{"a": [1, 2]}'
+BLOB='\not parsed'
+ITEMS_0='{"b":true}'
+EXTRA_X='[1,2,3]'
+`)
+	err := xconfigdotenv.New().Unmarshal(data, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, json.RawMessage(`{"a": [1, 2]}`), config.Payload)
+	assert.Equal(t, []byte(`\not parsed`), config.Blob)
+	assert.Equal(t, []json.RawMessage{json.RawMessage(`{"b":true}`)}, config.Items)
+	assert.Equal(t, json.RawMessage(`[1,2,3]`), config.Extra["X"])
+
+	var v map[string][]int
+	assert.NoError(t, json.Unmarshal(config.Payload, &v))
+}
+
+func TestFormatQuery(t *testing.T) {
+	type queryConfig struct {
+		Params url.Values        `format:"query"`
+		First  map[string]int    `format:"query"`
+		Plain  map[string]string `format:"query"`
+	}
+
+	t.Run("query string", func(t *testing.T) {
+		var config queryConfig
+		err := xconfigdotenv.New().Unmarshal([]byte("PARAMS='a=1&a=2&b=x%20y'\nFIRST='n=1&n=2'"), &config)
+		assert.NoError(t, err)
+		assert.Equal(t, url.Values{"a": {"1", "2"}, "b": {"x y"}}, config.Params)
+		assert.Equal(t, map[string]int{"n": 1}, config.First)
+	})
+
+	t.Run("structured keys", func(t *testing.T) {
+		var config queryConfig
+		err := xconfigdotenv.New().Unmarshal([]byte("PARAMS_a=1,2\nPLAIN_b=x"), &config)
+		assert.NoError(t, err)
+		assert.Equal(t, url.Values{"a": {"1", "2"}}, config.Params)
+		assert.Equal(t, map[string]string{"b": "x"}, config.Plain)
+	})
+
+	t.Run("structured keys win", func(t *testing.T) {
+		for _, data := range []string{
+			"PARAMS='a=1&b=2'\nPARAMS_a=3",
+			"params='a=1&b=2'\nPARAMS_a=3",
+		} {
+			var config queryConfig
+			err := xconfigdotenv.New().Unmarshal([]byte(data), &config)
+			assert.NoError(t, err)
+			assert.Equal(t, url.Values{"a": {"3"}, "b": {"2"}}, config.Params)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var config queryConfig
+		err := xconfigdotenv.New().Unmarshal([]byte("PARAMS='a=%zz'"), &config)
+		assert.ErrorContains(t, err, `key "PARAMS"`)
+		assert.ErrorContains(t, err, "as query")
+	})
+}
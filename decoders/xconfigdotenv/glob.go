@@ -0,0 +1,69 @@
+package xconfigdotenv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ErrNoGlobMatch is returned by UnmarshalGlob when no file matches its
+// pattern, unless WithGlobAllowEmpty is set.
+var ErrNoGlobMatch = errors.New("no file matches the pattern")
+
+// UnmarshalGlob decodes into v the files matching the glob pattern, as
+// filepath.Glob matches it, for drop-in directories such as conf.d/*.env.
+// The files are read in the lexicographic order of their paths and their
+// keys merged, a later file overriding the keys of the earlier ones, so
+// conf.d/20-local.env overrides conf.d/10-base.env; keys are compared as
+// MarshalMerge compares them, case-insensitively unless WithCaseSensitive
+// is set. The merged keys are then decoded at once, as Unmarshal decodes
+// the keys of a single file.
+//
+// Each file is parsed on its own, so the DuplicateKeyPolicy applies within
+// a file and the variables a file expands are those of the file. No
+// matching file fails with ErrNoGlobMatch, or decodes nothing under
+// WithGlobAllowEmpty.
+func (d *Decoder) UnmarshalGlob(pattern string, v any) error {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("xconfigdotenv: UnmarshalGlob: %w", err)
+	}
+	if len(paths) == 0 {
+		if d.opts.globAllowEmpty {
+			return nil
+		}
+		return fmt.Errorf("xconfigdotenv: UnmarshalGlob: %q: %w", pattern, ErrNoGlobMatch)
+	}
+	sort.Strings(paths)
+
+	s := d.newState(nil)
+	merged := make(map[string]string)
+	// keys holds the keys of merged by merge key, see mergeKey
+	keys := make(map[string][]string)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("xconfigdotenv: UnmarshalGlob: %w", err)
+		}
+		flatMap, err := s.parse(data)
+		if err != nil {
+			return fmt.Errorf("xconfigdotenv: UnmarshalGlob: file %s: %w", path, err)
+		}
+		// The keys of the earlier files, in any case, are dropped first, so
+		// the keys of a file differing in case only are all kept
+		for key := range flatMap {
+			for _, prev := range keys[d.opts.mergeKey(key)] {
+				delete(merged, prev)
+			}
+			delete(keys, d.opts.mergeKey(key))
+		}
+		for key, value := range flatMap {
+			keys[d.opts.mergeKey(key)] = append(keys[d.opts.mergeKey(key)], key)
+			merged[key] = value
+		}
+	}
+
+	return s.decodeInto(merged, v)
+}
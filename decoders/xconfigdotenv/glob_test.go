@@ -0,0 +1,48 @@
+package xconfigdotenv_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalGlob(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"10-base.env":  "HOST=base\nPORT=80\nDEBUG=false\nDB_HOST=db",
+		"20-local.env": "port=8080\nDEBUG=true",
+		"30-extra.env": "DB_HOST=db2",
+		"notes.txt":    "HOST=ignored",
+	}
+	for name, contents := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600))
+	}
+
+	type config struct {
+		Host  string
+		Port  int
+		Debug bool
+		DB    struct{ Host string }
+	}
+
+	// later files override the earlier ones, whatever the case of their keys
+	var c config
+	assert.NoError(t, xconfigdotenv.New().UnmarshalGlob(filepath.Join(dir, "*.env"), &c))
+	want := config{Host: "base", Port: 8080, Debug: true}
+	want.DB.Host = "db2"
+	assert.Equal(t, want, c)
+
+	// no match fails unless allowed
+	empty := filepath.Join(dir, "conf.d", "*.env")
+	err := xconfigdotenv.New().UnmarshalGlob(empty, &c)
+	assert.ErrorIs(t, err, xconfigdotenv.ErrNoGlobMatch)
+	assert.EqualError(t, err, `xconfigdotenv: UnmarshalGlob: "`+empty+`": no file matches the pattern`)
+	assert.NoError(t, xconfigdotenv.New(xconfigdotenv.WithGlobAllowEmpty()).UnmarshalGlob(empty, &c))
+	assert.Equal(t, want, c)
+
+	err = xconfigdotenv.New().UnmarshalGlob("[", &c)
+	assert.ErrorContains(t, err, "xconfigdotenv: UnmarshalGlob: syntax error in pattern")
+}
@@ -0,0 +1,37 @@
+package xconfigdotenv
+
+import (
+	"net/textproto"
+	"reflect"
+	"strings"
+)
+
+var mimeHeaderType = reflect.TypeFor[textproto.MIMEHeader]()
+
+// assignHeader puts rawVal in the textproto.MIMEHeader fieldVal, at
+// fieldPath, under the header named by the leftover segments: they are
+// joined with '-' and canonicalized, so HEADERS_CONTENT_TYPE sets
+// Content-Type. The value is split into the values of the header on the
+// separator of field, a newline by default, since header values commonly
+// hold commas.
+func (s *decodeState) assignHeader(fieldVal reflect.Value, field reflect.StructField, leftover []string, rawVal, fieldPath string) error {
+	name := textproto.CanonicalMIMEHeaderKey(strings.Join(leftover, "-"))
+	if !s.claim(fieldPath + "[" + name + "]") {
+		return nil
+	}
+	if err := s.resetField(fieldVal, fieldPath); err != nil {
+		return err
+	}
+	if fieldVal.IsNil() {
+		if err := setWithReflect(fieldVal, reflect.MakeMap(fieldVal.Type())); err != nil {
+			return err
+		}
+	}
+
+	elems := splitElems(s.opts.formatValue(field, rawVal), s.opts.sliceSep(field))
+	values := make([]string, len(elems))
+	for i, elem := range elems {
+		values[i] = s.intern(strings.TrimSpace(elem))
+	}
+	return s.reportEntry(storeMapValue(fieldVal, name, reflect.ValueOf(values)), fieldVal, fieldPath, name)
+}
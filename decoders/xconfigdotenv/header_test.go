@@ -0,0 +1,42 @@
+package xconfigdotenv_test
+
+import (
+	"net/textproto"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMIMEHeader(t *testing.T) {
+	var config struct {
+		Headers textproto.MIMEHeader
+	}
+
+	data := []byte(`HEADERS_CONTENT_TYPE=application/json
+HEADERS_accept="text/html
+application/xml;q=0.9, */*;q=0.8"
+headers_x_request_id=abc`)
+
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+	assert.Equal(t, textproto.MIMEHeader{
+		"Content-Type": {"application/json"},
+		"Accept":       {"text/html", "application/xml;q=0.9, */*;q=0.8"},
+		"X-Request-Id": {"abc"},
+	}, config.Headers)
+	assert.Equal(t, "application/json", config.Headers.Get("content-type"))
+
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, `HEADERS_Accept="text/html\napplication/xml;q=0.9, */*;q=0.8"
+HEADERS_Content_Type=application/json
+HEADERS_X_Request_Id=abc
+`, string(out))
+
+	var decoded struct {
+		Headers textproto.MIMEHeader
+	}
+	assert.NoError(t, decoder.Unmarshal(out, &decoded))
+	assert.Equal(t, config, decoded)
+}
@@ -0,0 +1,46 @@
+package xconfigdotenv
+
+import (
+	"path"
+	"strings"
+)
+
+// dropIgnored returns flatMap without the keys matching the ignore patterns
+// of the options, counted as ignored.
+func (s *decodeState) dropIgnored(flatMap map[string]string) map[string]string {
+	if len(s.opts.ignorePatterns) == 0 {
+		return flatMap
+	}
+	kept := make(map[string]string, len(flatMap))
+	for rawKey, rawVal := range flatMap {
+		if s.opts.isIgnored(rawKey) {
+			s.countKey(false)
+			continue
+		}
+		kept[rawKey] = rawVal
+	}
+	return kept
+}
+
+// isIgnored reports whether the key matches one of the ignore patterns.
+func (o *options) isIgnored(rawKey string) bool {
+	key := rawKey
+	if !o.caseSensitive {
+		key = strings.ToUpper(key)
+	}
+	for _, pattern := range o.ignorePatterns {
+		if !o.caseSensitive {
+			pattern = strings.ToUpper(pattern)
+		}
+		if !strings.ContainsAny(pattern, "*?[") {
+			if strings.HasPrefix(key, pattern) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
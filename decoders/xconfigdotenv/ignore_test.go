@@ -0,0 +1,40 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithIgnorePrefix(t *testing.T) {
+	var config struct {
+		Name string
+		Path string
+	}
+
+	var unknown []string
+	decoder := xconfigdotenv.New(
+		xconfigdotenv.WithIgnorePrefix("path", "LS_"),
+		xconfigdotenv.WithIgnorePrefix("*_PID", "X?"),
+		xconfigdotenv.WithUnknownKeyCallback(func(key, _ string) error {
+			unknown = append(unknown, key)
+			return nil
+		}),
+	)
+	data := []byte("NAME=app\nPATH=/bin\nLS_COLORS=x\nAGENT_PID=1\nXY=2\nXYZ=3")
+	meta, err := decoder.UnmarshalWithMetadata(data, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "app", config.Name)
+	assert.Empty(t, config.Path)
+	assert.Equal(t, []string{"XYZ"}, unknown)
+	assert.Equal(t, map[string]string{"NAME": "app", "XYZ": "3"}, meta.Flat)
+	assert.Equal(t, 6, meta.Metrics.KeysProcessed)
+	assert.Equal(t, 5, meta.Metrics.KeysIgnored)
+
+	// case-sensitive names do not match prefixes in another case
+	err = xconfigdotenv.New(xconfigdotenv.WithCaseSensitive(), xconfigdotenv.WithIgnorePrefix("path")).Unmarshal([]byte("Path=/bin"), &config)
+	assert.NoError(t, err)
+	assert.Equal(t, "/bin", config.Path)
+}
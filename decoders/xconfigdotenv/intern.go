@@ -0,0 +1,12 @@
+package xconfigdotenv
+
+import "unique"
+
+// intern returns the canonical copy of v when the options ask for string
+// interning, and v otherwise.
+func (s *decodeState) intern(v string) string {
+	if !s.opts.internStrings {
+		return v
+	}
+	return unique.Make(v).Value()
+}
@@ -0,0 +1,72 @@
+package xconfigdotenv_test
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithStringInterner(t *testing.T) {
+	type config struct {
+		API    struct{ Level string }
+		DB     struct{ Level string }
+		Labels map[string]string
+	}
+
+	data := []byte("API_LEVEL=info\nDB_LEVEL=info\nLABELS_A=info")
+
+	var plain config
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &plain))
+	assert.NotSame(t, unsafe.StringData(plain.API.Level), unsafe.StringData(plain.DB.Level))
+
+	var interned config
+	assert.NoError(t, xconfigdotenv.New(xconfigdotenv.WithStringInterner()).Unmarshal(data, &interned))
+	assert.Equal(t, plain, interned)
+	assert.Same(t, unsafe.StringData(interned.API.Level), unsafe.StringData(interned.DB.Level))
+	assert.Same(t, unsafe.StringData(interned.API.Level), unsafe.StringData(interned.Labels["A"]))
+}
+
+// BenchmarkStringInterner decodes configs repeating their values and keeps
+// them, as reloads do, reporting the heap they retain.
+func BenchmarkStringInterner(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&sb, "LABELS_K%d=%s\n", i, strings.Repeat("info", 16))
+	}
+	data := []byte(sb.String())
+
+	for _, bench := range []struct {
+		name string
+		opts []xconfigdotenv.Option
+	}{
+		{"Off", nil},
+		{"On", []xconfigdotenv.Option{xconfigdotenv.WithStringInterner()}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			decoder := xconfigdotenv.New(bench.opts...)
+			kept := make([]map[string]string, 0, b.N)
+
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var config struct{ Labels map[string]string }
+				if err := decoder.Unmarshal(data, &config); err != nil {
+					b.Fatal(err)
+				}
+				kept = append(kept, config.Labels)
+			}
+			b.StopTimer()
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+			b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(b.N), "retained-B/op")
+			runtime.KeepAlive(kept)
+		})
+	}
+}
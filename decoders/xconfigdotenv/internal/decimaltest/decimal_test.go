@@ -0,0 +1,38 @@
+// Package decimaltest checks the decoder against github.com/shopspring/decimal
+// in a module of its own, so that the decoder does not depend on it.
+package decimaltest_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShopspringDecimal(t *testing.T) {
+	xconfigdotenv.RegisterConverter(decimal.NewFromString)
+
+	var config struct {
+		Price decimal.Decimal
+		Fees  []decimal.Decimal
+		Limit *decimal.Decimal
+	}
+
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal([]byte("PRICE=19.99\nFEES=0.10,1.5\nLIMIT=1000"), &config))
+	assert.True(t, decimal.RequireFromString("19.99").Equal(config.Price))
+	if assert.Len(t, config.Fees, 2) {
+		assert.True(t, decimal.RequireFromString("1.5").Equal(config.Fees[1]))
+	}
+	if assert.NotNil(t, config.Limit) {
+		assert.True(t, decimal.NewFromInt(1000).Equal(*config.Limit))
+	}
+
+	data, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "PRICE=19.99\nFEES=0.1,1.5\nLIMIT=1000\n", string(data))
+
+	err = decoder.Unmarshal([]byte("PRICE=1,5"), &config)
+	assert.ErrorContains(t, err, `key "PRICE": cannot parse "1,5" as decimal.Decimal`)
+}
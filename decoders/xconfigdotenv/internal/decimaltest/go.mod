@@ -0,0 +1,18 @@
+module github.com/dv-net/xconfig/decoders/xconfigdotenv/internal/decimaltest
+
+go 1.23.0
+
+require (
+	github.com/dv-net/xconfig/decoders/xconfigdotenv v0.0.0
+	github.com/shopspring/decimal v1.4.0
+	github.com/stretchr/testify v1.10.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/joho/godotenv v1.5.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/dv-net/xconfig/decoders/xconfigdotenv => ../..
@@ -0,0 +1,129 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// keyPath returns the field path the key map of the options gives for rawKey.
+func (o *options) keyPath(rawKey string) (string, bool) {
+	if o.caseSensitive {
+		path, ok := o.keyMap[rawKey]
+		return path, ok
+	}
+	path, ok := o.foldedKeyMap[strings.ToUpper(rawKey)]
+	return path, ok
+}
+
+// assignPath puts rawVal in v at the dotted field path segments given by the
+// key map, field being the struct field of v and path its path.
+func (s *decodeState) assignPath(v reflect.Value, field reflect.StructField, segments []string, rawVal, path string) error {
+	if len(segments) == 0 {
+		if acceptsMembers(v.Type()) {
+			return s.reportSet(s.assignSet(v, rawVal, s.opts.sliceSep(field), path), path, v)
+		}
+		if !acceptsScalar(v.Type()) {
+			return s.scalarToContainer(field, v.Type())
+		}
+		return s.assignField(v, field, rawVal, path)
+	}
+
+	segment := segments[0]
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			newPtr, err := s.newValue(v.Type().Elem())
+			if err != nil {
+				return err
+			}
+			if err := setWithReflect(v, newPtr); err != nil {
+				return err
+			}
+		}
+		return s.assignPath(v.Elem(), field, segments, rawVal, path)
+
+	case reflect.Struct:
+		i := -1
+		for j := 0; j < v.NumField(); j++ {
+			if v.Type().Field(j).Name == segment {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			return fmt.Errorf("key map: no field %q in %s", segment, v.Type())
+		}
+		sf := v.Type().Field(i)
+		if s.opts.skipsField(sf) {
+			return fmt.Errorf("key map: field %q of %s is unexported", segment, v.Type())
+		}
+		fieldPath := joinPath(path, sf.Name)
+		s.field = fieldPath
+		s.markField(sf)
+		if s.redacted {
+			s.redactValue(rawVal, s.opts.sliceSep(sf))
+		}
+		rawVal, err := s.readFile(sf, rawVal, fieldPath)
+		if err != nil {
+			return err
+		}
+		if rawVal, err = s.preprocess(sf, rawVal); err != nil {
+			return err
+		}
+		fieldVal := getFieldValue(v, i)
+		if err := s.resetField(fieldVal, fieldPath); err != nil {
+			return err
+		}
+		return s.assignPath(fieldVal, sf, segments[1:], rawVal, fieldPath)
+
+	case reflect.Slice:
+		ix, err := strconv.Atoi(segment)
+		if err != nil || ix < 0 {
+			return fmt.Errorf("key map: cannot parse slice index %q for field %q", segment, field.Name)
+		}
+		elem, err := sliceElem(v, ix)
+		if err != nil {
+			return err
+		}
+		return s.assignPath(elem, field, segments[1:], rawVal, path+"["+segment+"]")
+
+	case reflect.Map:
+		if v.IsNil() {
+			if err := setWithReflect(v, reflect.MakeMap(v.Type())); err != nil {
+				return err
+			}
+		}
+		elemPath := path + "[" + segment + "]"
+		if len(segments) == 1 && !isStructValue(v.Type().Elem()) {
+			if !s.claim(elemPath) {
+				return nil
+			}
+			return s.reportEntry(s.setMapValue(v, segment, s.opts.formatValue(field, rawVal)), v, path, segment)
+		}
+
+		// Map values are not addressable: work on a copy, then store it back
+		key, err := mapKeyValue(v.Type().Key(), segment)
+		if err != nil {
+			return err
+		}
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		} else if elem.Kind() == reflect.Struct {
+			newElem, err := s.newValue(elem.Type())
+			if err != nil {
+				return err
+			}
+			elem.Set(newElem.Elem())
+		}
+		if err := s.assignPath(elem, field, segments[1:], rawVal, elemPath); err != nil {
+			return err
+		}
+		return storeMapValue(v, segment, elem)
+
+	default:
+		return s.containerToScalar(field, v.Type(), segments)
+	}
+}
@@ -0,0 +1,55 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithKeyMap(t *testing.T) {
+	type route struct {
+		Target string
+		Weight int `default:"1"`
+	}
+	var config struct {
+		Name string
+		DB   *struct {
+			Host string
+			Port int
+		}
+		Upstreams []string
+		Labels    map[string]string
+		Routes    map[string]route
+		secret    string
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithKeyMap(map[string]string{
+		"PGHOST":        "DB.Host",
+		"PGPORT":        "DB.Port",
+		"UPSTREAM_1":    "Upstreams.1",
+		"TEAM_LABEL":    "Labels.team",
+		"ROUTE_USER_TO": "Routes.user.Target",
+		"APP_SECRET":    "secret",
+	}))
+	data := []byte("NAME=app\npghost=db\nPGPORT=5432\nUPSTREAM_1=b:80\nUPSTREAMS_0=a:80\nTEAM_LABEL=core\nROUTE_USER_TO=users:80\nAPP_SECRET=s3cr3t")
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+
+	assert.Equal(t, "app", config.Name)
+	if assert.NotNil(t, config.DB) {
+		assert.Equal(t, "db", config.DB.Host)
+		assert.Equal(t, 5432, config.DB.Port)
+	}
+	assert.Equal(t, []string{"a:80", "b:80"}, config.Upstreams)
+	assert.Equal(t, map[string]string{"team": "core"}, config.Labels)
+	assert.Equal(t, map[string]route{"user": {Target: "users:80", Weight: 1}}, config.Routes)
+	assert.Equal(t, "s3cr3t", config.secret)
+
+	decoder = xconfigdotenv.New(xconfigdotenv.WithKeyMap(map[string]string{"PGUSER": "DB.User"}))
+	err := decoder.Unmarshal([]byte("PGUSER=u"), &config)
+	assert.ErrorContains(t, err, `key "PGUSER": key map: no field "User" in struct`)
+
+	decoder = xconfigdotenv.New(xconfigdotenv.WithKeyMap(map[string]string{"PGPORT": "DB.Port"}))
+	err = decoder.Unmarshal([]byte("PGPORT=x"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "PGPORT": cannot parse "x" as int: strconv.ParseInt: parsing "x": invalid syntax`)
+}
@@ -0,0 +1,630 @@
+package xconfigdotenv
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// keyValue is a key of the output of Marshal with its value.
+type keyValue struct {
+	key   string
+	value string
+}
+
+// encodeState holds the state of a single Marshal call.
+type encodeState struct {
+	opts  *options
+	pairs []keyValue
+	// timeLayout is the layout of the `timeformat` tag of the field being
+	// encoded, empty without it.
+	timeLayout string
+}
+
+// Marshal encodes the struct v, or the struct v points to, as .env lines
+// which Unmarshal decodes back into an equal struct.
+//
+// Keys are written in field declaration order, map keys in sorted order.
+// A field is written under its primary name (see fieldNames): the `env`
+// tag name as is, or the field name in upper snake case (MaxConns gives
+// MAX_CONNS) unless WithCaseSensitive or WithKeyCaseOutput is set. Slices of scalars are written
+// as lists separated by commas, or by their `sep` tag, when no element holds
+// the separator, other slices with indexed keys (HOSTS_0_PORT). Nil
+// pointers, maps and slices are skipped.
+// Values are double-quoted when they hold anything but letters, digits and
+// the characters _ . , : / @ + - =, single-quoted when they end with a
+// double quote (see quoteValue).
+func (d *Decoder) Marshal(v any) ([]byte, error) {
+	pairs, err := d.encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, kv := range pairs {
+		value, err := quoteValue(kv.value)
+		if err != nil {
+			return nil, fmt.Errorf("xconfigdotenv: Marshal: key %q: %w", kv.key, err)
+		}
+		buf.WriteString(kv.key + "=" + value + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalMerge encodes v like Marshal into the existing .env content: the
+// lines of the keys of v get their value replaced, keeping their `export`
+// prefix and trailing comment, while comments, blank lines, ordering and
+// unrelated keys are kept as they are. Keys of v missing from existing are
+// appended at the end, in Marshal order.
+//
+// Keys are compared case-insensitively unless WithCaseSensitive is set.
+// A key present several times in existing has every occurrence updated.
+//
+// Lines of existing which would override a key of v in another shape are
+// removed, so that the result decodes to v: the keys under a key of v, such
+// as TAGS_0 and TAGS_1 when v writes TAGS, and the single value of a slice
+// v writes with indexed keys, such as HOSTS when v writes HOSTS_0_PORT.
+func (d *Decoder) MarshalMerge(existing []byte, v any) ([]byte, error) {
+	pairs, err := d.encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(pairs))
+	for _, kv := range pairs {
+		values[d.opts.mergeKey(kv.key)] = kv.value
+	}
+	stale := d.opts.staleKeys(pairs)
+
+	var buf bytes.Buffer
+	found := make(map[string]bool)
+	rest := existing
+	for len(rest) > 0 {
+		key, valStart, ok := parseKeyLine(rest)
+		value, known := values[d.opts.mergeKey(key)]
+		if ok && !known && stale(key) {
+			// drop the line, with its value spanning lines and its comment
+			rest = rest[valStart+valueLen(rest[valStart:]):]
+			if n := bytes.IndexByte(rest, '\n'); n >= 0 {
+				rest = rest[n+1:]
+			} else {
+				rest = nil
+			}
+			continue
+		}
+		if !ok || !known {
+			// copy the line as is
+			n := bytes.IndexByte(rest, '\n') + 1
+			if n == 0 {
+				n = len(rest)
+			}
+			buf.Write(rest[:n])
+			rest = rest[n:]
+			continue
+		}
+
+		quoted, err := quoteValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("xconfigdotenv: MarshalMerge: key %q: %w", key, err)
+		}
+		found[d.opts.mergeKey(key)] = true
+		buf.Write(rest[:valStart])
+		buf.WriteString(quoted)
+		// the remainder of the line (comment, newline) is copied by the next iteration
+		rest = rest[valStart+valueLen(rest[valStart:]):]
+	}
+
+	appended := false
+	for _, kv := range pairs {
+		if found[d.opts.mergeKey(kv.key)] {
+			continue
+		}
+		if !appended && buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		quoted, err := quoteValue(kv.value)
+		if err != nil {
+			return nil, fmt.Errorf("xconfigdotenv: MarshalMerge: key %q: %w", kv.key, err)
+		}
+		appended = true
+		found[d.opts.mergeKey(kv.key)] = true
+		buf.WriteString(kv.key + "=" + quoted + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// encode flattens the struct v into its keys and values.
+func (d *Decoder) encode(v any) ([]keyValue, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("xconfigdotenv: Marshal: v must be a struct or a pointer to a struct, got %T", v)
+	}
+
+	// Work on an addressable copy, so unexported fields can be read
+	cp := reflect.New(rv.Type()).Elem()
+	cp.Set(rv)
+
+	e := &encodeState{opts: &d.opts}
+	if err := e.encodeStruct(cp, ""); err != nil {
+		return nil, fmt.Errorf("xconfigdotenv: Marshal: %w", err)
+	}
+	return e.pairs, nil
+}
+
+// encodeStruct adds the fields of the struct v under the key prefix.
+func (e *encodeState) encodeStruct(v reflect.Value, prefix string) error {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if e.opts.skipsField(field) {
+			continue
+		}
+		fieldVal := getFieldValue(v, i)
+
+		if fieldVal.Kind() == reflect.Map && e.opts.hasEnvOption(field, envOptionInline) {
+			if err := e.encodeMap(fieldVal, field, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// A field with a prefix starts its keys again
+		if fieldPrefix, ok := e.opts.fieldPrefix(field); ok {
+			if err := e.encodeValue(fieldVal, field, fieldPrefix); err != nil {
+				return err
+			}
+			continue
+		}
+
+		names := e.opts.fieldNames(field)
+		if len(names) == 0 {
+			continue
+		}
+		name := names[0].value
+		if names[0].source == MatchFieldName {
+			name = e.opts.nameKey(name)
+		}
+		if prefix != "" {
+			name = e.opts.joinKey(prefix, name)
+		}
+		if err := e.encodeValue(fieldVal, field, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeValue adds the value v of field under key.
+func (e *encodeState) encodeValue(v reflect.Value, field reflect.StructField, key string) error {
+	e.timeLayout = e.opts.timeLayout(field)
+	if text, ok, err := e.scalarText(v); ok || err != nil {
+		if err != nil {
+			return fmt.Errorf("field %q: %w", field.Name, err)
+		}
+		e.add(key, text)
+		return nil
+	}
+
+	if m, ok := orderedMap(v); ok {
+		for _, k := range m.keys {
+			e.add(e.opts.joinKey(key, k), m.values[k])
+		}
+		return nil
+	}
+
+	if v.Type() == syncMapType {
+		return e.encodeSyncMap(v, field, key)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return e.encodeValue(v.Elem(), field, key)
+
+	case reflect.Struct:
+		return e.encodeStruct(v, key)
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		return e.encodeMap(v, field, key)
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.add(key, string(v.Bytes()))
+			return nil
+		}
+		sep := e.opts.sliceSep(field)
+		if texts, ok := e.sliceTexts(v, sep); ok {
+			e.add(key, strings.Join(texts, sep))
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encodeValue(v.Index(i), field, e.opts.joinKey(key, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if name, ok := registeredName(v.Type(), v.Elem().Type()); ok {
+			e.add(e.opts.joinKey(key, e.opts.nameKey(typeKey)), name)
+		}
+		return e.encodeValue(v.Elem(), field, key)
+
+	default:
+		return fmt.Errorf("field %q: unsupported kind %s", field.Name, v.Kind())
+	}
+}
+
+// encodeMap adds the entries of the map v under key, the map keys sorted.
+func (e *encodeState) encodeMap(v reflect.Value, field reflect.StructField, key string) error {
+	if v.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("field %q: unsupported map key type %s; only string keys allowed", field.Name, v.Type().Key().Kind())
+	}
+
+	keys := make([]string, 0, v.Len())
+	for _, mk := range v.MapKeys() {
+		keys = append(keys, mk.String())
+	}
+	sort.Strings(keys)
+
+	if isSetType(v.Type()) {
+		e.add(key, strings.Join(keys, e.opts.sliceSep(field)))
+		return nil
+	}
+
+	if e.opts.hasFormat(field, formatQuery) {
+		query := url.Values{}
+		for _, mk := range keys {
+			elem := v.MapIndex(reflect.ValueOf(mk).Convert(v.Type().Key()))
+			texts, ok := e.sliceTexts(elem, "")
+			if !ok {
+				text, _, err := e.scalarText(elem)
+				if err != nil {
+					return fmt.Errorf("field %q: %w", field.Name, err)
+				}
+				texts = []string{text}
+			}
+			query[mk] = texts
+		}
+		e.add(key, query.Encode())
+		return nil
+	}
+
+	for _, mk := range keys {
+		// Copy the value, so the unexported fields of structs can be read
+		elem := reflect.New(v.Type().Elem()).Elem()
+		elem.Set(v.MapIndex(reflect.ValueOf(mk).Convert(v.Type().Key())))
+		mapKey := mk
+		if v.Type() == mimeHeaderType {
+			// Header names hold '-', which keys cannot
+			mapKey = strings.ReplaceAll(mk, "-", "_")
+		}
+		if key != "" {
+			mapKey = e.opts.joinKey(key, mapKey)
+		}
+		if err := e.encodeValue(elem, field, mapKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeSyncMap adds the entries of the sync.Map v under key, sorted by key.
+func (e *encodeState) encodeSyncMap(v reflect.Value, field reflect.StructField, key string) error {
+	keys, entries, err := syncMapEntries(v)
+	if err != nil {
+		return fmt.Errorf("field %q: %w", field.Name, err)
+	}
+	for _, mk := range keys {
+		if entries[mk] == nil {
+			continue
+		}
+		if err := e.encodeValue(reflect.ValueOf(entries[mk]), field, e.opts.joinKey(key, mk)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sliceTexts returns the texts of the elements of the slice v when they are
+// all scalars without sep, nor double quotes which splitElems would read as
+// quoting, so v can be written as a single value. An empty sep accepts any
+// scalar.
+func (e *encodeState) sliceTexts(v reflect.Value, sep string) ([]string, bool) {
+	if v.Kind() != reflect.Slice {
+		return nil, false
+	}
+	texts := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		text, ok, err := e.scalarText(v.Index(i))
+		if !ok || err != nil || sep != "" && (strings.Contains(text, sep) || strings.Contains(text, `"`)) {
+			return nil, false
+		}
+		texts = append(texts, text)
+	}
+	return texts, true
+}
+
+// add adds a key and its value to the output.
+func (e *encodeState) add(key, value string) {
+	e.pairs = append(e.pairs, keyValue{key: key, value: value})
+}
+
+// scalarText returns the text of v when v is a single value rather than a
+// container: a basic kind, a time.Duration, a time.Time, in the layout of
+// its `timeformat` tag, a typed value of sync/atomic, a json.RawMessage, a
+// type implementing encoding.TextMarshaler, such as slog.Level, a type
+// decoded by UnmarshalBinary (see isBinaryType), such as url.URL, a
+// pflag.Value (see isPflagType), a color.RGBA or color.NRGBA, written in
+// hex, or a type with a converter implementing fmt.Stringer, such as
+// net.HardwareAddr. Floats are written with the decimal separator of the
+// NumberLocale.
+func (e *encodeState) scalarText(v reflect.Value) (string, bool, error) {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(v.Int()).String(), true, nil
+	}
+	if e.timeLayout != "" {
+		if t, ok := timeValue(v); ok {
+			return t.Format(e.timeLayout), true, nil
+		}
+	}
+	if isAtomicType(v.Type()) {
+		return e.scalarText(atomicValue(v))
+	}
+	if v.Type() == rawMessageType {
+		return string(v.Interface().(json.RawMessage)), true, nil
+	}
+	if m, ok := textMarshaler(v); ok {
+		text, err := m.MarshalText()
+		return string(text), true, err
+	}
+	if text, ok, err := binaryText(v); ok || err != nil {
+		return text, true, err
+	}
+	if text, ok := pflagText(v); ok {
+		return text, true, nil
+	}
+	if text, ok := colorText(v); ok {
+		return text, true, nil
+	}
+	if s, ok := stringer(v); ok && converter(v.Type()) != nil {
+		// The types with a converter, such as net.HardwareAddr, print as they parse
+		return s.String(), true, nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+	case reflect.Float32, reflect.Float64:
+		text := strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())
+		if e.opts.numberLocale == NumberLocaleComma {
+			text = strings.Replace(text, ".", ",", 1)
+		}
+		return text, true, nil
+	case reflect.Complex64, reflect.Complex128:
+		return strconv.FormatComplex(v.Complex(), 'g', -1, v.Type().Bits()), true, nil
+	}
+	return "", false, nil
+}
+
+// orderedMap returns v as an OrderedMap, when it is one.
+func orderedMap(v reflect.Value) (*OrderedMap, bool) {
+	switch m := v.Interface().(type) {
+	case OrderedMap:
+		return &m, true
+	case *OrderedMap:
+		return m, m != nil
+	}
+	return nil, false
+}
+
+// textMarshaler returns v, or a pointer to v, as an encoding.TextMarshaler.
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, false
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		return m, true
+	}
+	if v.CanAddr() {
+		m, ok := v.Addr().Interface().(encoding.TextMarshaler)
+		return m, ok
+	}
+	return nil, false
+}
+
+// stringer returns v as a fmt.Stringer, through a copy of v for a String
+// method with a pointer receiver, such as that of net.TCPAddr.
+func stringer(v reflect.Value) (fmt.Stringer, bool) {
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s, true
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	s, ok := ptr.Interface().(fmt.Stringer)
+	return s, ok
+}
+
+// snakeCase turns the Go name into snake case, MaxConns giving Max_Conns and
+// DBHost giving DB_Host, upper-cased when upper is set.
+func snakeCase(name string, upper bool) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && runes[i-1] != '_' &&
+			(!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	if upper {
+		return strings.ToUpper(b.String())
+	}
+	return b.String()
+}
+
+// quoteValue returns value as written in a .env file so that the parser
+// reads it back as is: as is when it only holds safe characters, and
+// double-quoted and escaped otherwise.
+//
+// The parser ends a quoted value at the first quote not preceded by a
+// backslash and then trims every quote at its end, so a double-quoted value
+// cannot end with an escaped quote or backslash: a value ending with a
+// double quote is single-quoted instead, single quotes taking everything
+// literally, and a value ending with a backslash is written unquoted, where
+// backslashes are kept as they are. A value which can be written none of
+// these ways is an error.
+func quoteValue(value string) (string, error) {
+	safe := value != ""
+	for _, r := range value {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_.,:/@+-=", r) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return value, nil
+	}
+
+	if !strings.HasSuffix(value, `"`) && !strings.HasSuffix(value, `\`) {
+		replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`)
+		return `"` + replacer.Replace(value) + `"`, nil
+	}
+	if !strings.HasSuffix(value, `\`) && !strings.ContainsAny(value, "'\n\r") {
+		return "'" + value + "'", nil
+	}
+	if !strings.ContainsAny(value, "\"'#$ \t\n\r") {
+		return value, nil
+	}
+	return "", fmt.Errorf("value %q cannot be written so that it reads back as is", value)
+}
+
+// mergeKey returns the key used to compare the keys in MarshalMerge.
+// staleKeys returns whether a key not written by pairs would still override
+// one of them in another shape, see MarshalMerge. Keys are compared by
+// their segments.
+func (o *options) staleKeys(pairs []keyValue) func(key string) bool {
+	segments := func(key string) []string {
+		parts := o.splitKey(key)
+		for i := range parts {
+			parts[i] = o.mergeKey(parts[i])
+		}
+		return parts
+	}
+
+	written := make(map[string]bool, len(pairs))
+	indexed := make(map[string]bool)
+	for _, kv := range pairs {
+		parts := segments(kv.key)
+		written[strings.Join(parts, "_")] = true
+		for i := 1; i < len(parts); i++ {
+			if isIndex(parts[i]) {
+				indexed[strings.Join(parts[:i], "_")] = true
+			}
+		}
+	}
+
+	return func(key string) bool {
+		parts := segments(key)
+		for i := 1; i < len(parts); i++ {
+			if written[strings.Join(parts[:i], "_")] {
+				return true
+			}
+		}
+		return indexed[strings.Join(parts, "_")]
+	}
+}
+
+func (o *options) mergeKey(key string) string {
+	if o.caseSensitive {
+		return key
+	}
+	return strings.ToUpper(key)
+}
+
+// parseKeyLine parses the line at the start of src as an assignment,
+// `[export] KEY=value` or `KEY: value`, and returns the key and the offset
+// of the value in src.
+func parseKeyLine(src []byte) (key string, valStart int, ok bool) {
+	end := bytes.IndexByte(src, '\n')
+	if end < 0 {
+		end = len(src)
+	}
+	line := string(src[:end])
+
+	i := len(line) - len(strings.TrimLeft(line, " \t"))
+	if strings.HasPrefix(line[i:], "export ") {
+		i += len("export ")
+		i += len(line[i:]) - len(strings.TrimLeft(line[i:], " \t"))
+	}
+
+	sep := strings.IndexAny(line[i:], "=:")
+	if sep < 0 {
+		return "", 0, false
+	}
+	key = strings.TrimRight(line[i:i+sep], " \t")
+	if key == "" || strings.HasPrefix(key, "#") || strings.ContainsAny(key, " \t") {
+		return "", 0, false
+	}
+
+	valStart = i + sep + 1
+	valStart += len(line[valStart:]) - len(strings.TrimLeft(line[valStart:], " \t"))
+	return key, valStart, true
+}
+
+// valueLen returns the length of the value at the start of src, like
+// godotenv reads it: up to the closing quote of a quoted value, possibly on
+// a later line, or up to the end of the line or a ` #` comment otherwise.
+func valueLen(src []byte) int {
+	if len(src) > 0 && (src[0] == '"' || src[0] == '\'') {
+		for i := 1; i < len(src); i++ {
+			if src[i] == src[0] && src[i-1] != '\\' {
+				return i + 1
+			}
+		}
+	}
+
+	end := bytes.IndexByte(src, '\n')
+	if end < 0 {
+		end = len(src)
+	}
+	line := src[:end]
+	for i := 1; i < len(line); i++ {
+		if line[i] == '#' && (line[i-1] == ' ' || line[i-1] == '\t') {
+			line = line[:i]
+			break
+		}
+	}
+	return len(bytes.TrimRight(line, " \t\r"))
+}
@@ -0,0 +1,198 @@
+package xconfigdotenv_test
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type marshalConfig struct {
+	Name     string
+	MaxConns int
+	Ratio    float64
+	Debug    bool
+	Timeout  time.Duration
+	Log      slog.Level
+	Banner   string `env:"BANNER"`
+	Tags     []string
+	Notes    []string
+	Labels   map[string]string
+	Features map[string]struct{}
+	DB       struct {
+		Host string
+		Port *int
+	}
+	Hosts []struct {
+		Addr string
+	}
+	Cache  *struct{ Size int }
+	secret string
+}
+
+func TestMarshal(t *testing.T) {
+	port := 5432
+	config := marshalConfig{
+		Name:     "app",
+		MaxConns: 10,
+		Ratio:    0.5,
+		Debug:    true,
+		Timeout:  3 * time.Second,
+		Log:      slog.LevelWarn,
+		Banner:   "Hello \"world\"\nprice: $5 \\o/",
+		Tags:     []string{"a", "b"},
+		Notes:    []string{"x,y", "z"},
+		Labels:   map[string]string{"team": "core", "env": "prod"},
+		Features: map[string]struct{}{"beta": {}, "dark": {}},
+		secret:   "s3cr3t",
+	}
+	config.DB.Host = "db"
+	config.DB.Port = &port
+	config.Hosts = append(config.Hosts, struct{ Addr string }{"h1:80"})
+
+	decoder := xconfigdotenv.New()
+	data, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, `NAME=app
+MAX_CONNS=10
+RATIO=0.5
+DEBUG=true
+TIMEOUT=3s
+LOG=WARN
+BANNER="Hello \"world\"\nprice: \$5 \\o/"
+TAGS=a,b
+NOTES_0=x,y
+NOTES_1=z
+LABELS_env=prod
+LABELS_team=core
+FEATURES=beta,dark
+DB_HOST=db
+DB_PORT=5432
+HOSTS_0_ADDR=h1:80
+SECRET=s3cr3t
+`, string(data))
+
+	var decoded marshalConfig
+	assert.NoError(t, decoder.Unmarshal(data, &decoded))
+	assert.Equal(t, config, decoded)
+
+	_, err = decoder.Marshal(map[string]string{})
+	assert.ErrorContains(t, err, "v must be a struct")
+}
+
+func TestMarshalMerge(t *testing.T) {
+	var config struct {
+		Name    string
+		Port    int
+		Comment string
+		DB      struct {
+			Host string
+		}
+		Extra string
+	}
+	config.Name = "new"
+	config.Port = 8080
+	config.Comment = "two words"
+	config.DB.Host = "db2"
+	config.Extra = "added"
+
+	existing := `# App settings
+export NAME=old # the app name
+OTHER=keep
+
+port: 80
+comment="multi
+line"
+  db_host = 'db1'
+UNRELATED="x" # note`
+
+	data, err := xconfigdotenv.New().MarshalMerge([]byte(existing), &config)
+	assert.NoError(t, err)
+	assert.Equal(t, `# App settings
+export NAME=new # the app name
+OTHER=keep
+
+port: 8080
+comment="two words"
+  db_host = db2
+UNRELATED="x" # note
+EXTRA=added
+`, string(data))
+
+	// case-sensitive keys do not match keys in another case
+	data, err = xconfigdotenv.New(xconfigdotenv.WithCaseSensitive()).MarshalMerge([]byte("name=old\n"), &config)
+	assert.NoError(t, err)
+	assert.Equal(t, "name=old\nName=new\nPort=8080\nComment=\"two words\"\nDB_Host=db2\nExtra=added\n", string(data))
+}
+
+func TestMarshalMergeShape(t *testing.T) {
+	type host struct {
+		Port int
+	}
+	type config struct {
+		Tags  []string
+		Hosts []host
+		Name  string
+	}
+
+	v := config{Tags: []string{"x"}, Hosts: []host{{Port: 80}}, Name: "app"}
+	existing := "TAGS_0=a\n# hosts\nTAGS_1=\"b\nc\" # old\nHOSTS=\nOTHER=keep\nNAME=old\n"
+
+	decoder := xconfigdotenv.New()
+	data, err := decoder.MarshalMerge([]byte(existing), &v)
+	assert.NoError(t, err)
+	assert.Equal(t, "# hosts\nOTHER=keep\nNAME=app\nTAGS=x\nHOSTS_0_PORT=80\n", string(data))
+
+	// the merged content decodes back to v
+	var decoded config
+	assert.NoError(t, decoder.Unmarshal(data, &decoded))
+	assert.Equal(t, v, decoded)
+}
+
+func TestMarshalQuoting(t *testing.T) {
+	values := map[string]string{
+		"a=b":              "V=a=b\n",
+		"=":                "V==\n",
+		"x#y":              `V="x#y"` + "\n",
+		"a # comment-like": `V="a # comment-like"` + "\n",
+		"  lead":           `V="  lead"` + "\n",
+		"trail  ":          `V="trail  "` + "\n",
+		"":                 `V=""` + "\n",
+		`it's`:             `V="it's"` + "\n",
+		`a "quoted" word`:  `V="a \"quoted\" word"` + "\n",
+		`say "hi"`:         `V='say "hi"'` + "\n",
+		`"`:                `V='"'` + "\n",
+		`C:\dir\`:          `V=C:\dir\` + "\n",
+		`back\slash`:       `V="back\\slash"` + "\n",
+		"$HOME":            `V="\$HOME"` + "\n",
+		"tab\there":        "V=\"tab\there\"\n",
+		"two\nlines":       `V="two\nlines"` + "\n",
+	}
+
+	decoder := xconfigdotenv.New()
+	for value, want := range values {
+		var config struct{ V string }
+		config.V = value
+		data, err := decoder.Marshal(&config)
+		if !assert.NoError(t, err, value) {
+			continue
+		}
+		assert.Equal(t, want, string(data), value)
+
+		var decoded struct{ V string }
+		assert.NoError(t, decoder.Unmarshal(data, &decoded), value)
+		assert.Equal(t, value, decoded.V, value)
+	}
+
+	// no quoting reads back a value ending with a backslash after a space
+	var config struct{ V string }
+	config.V = `a b\`
+	_, err := decoder.Marshal(&config)
+	assert.EqualError(t, err, `xconfigdotenv: Marshal: key "V": value "a b\\" cannot be written so that it reads back as is`)
+
+	data, err := decoder.MarshalMerge([]byte("V=old # kept\n"), &struct{ V string }{`say "hi"`})
+	assert.NoError(t, err)
+	assert.Equal(t, `V='say "hi"' # kept`+"\n", string(data))
+}
@@ -0,0 +1,290 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const envTag = "env"
+
+// fieldName is one of the names a struct field can be matched by.
+type fieldName struct {
+	value string
+	// rank is the priority of the name, lower is higher: the primary name
+	// has rank 0, the aliases have ranks 1, 2, ... in declaration order.
+	rank int
+	// source is where the name comes from.
+	source MatchSource
+	// fallback marks the Go name of a field whose `env` tag gives another
+	// primary name, see fieldNames.
+	fallback bool
+}
+
+// fallbackPriority is added to the source priority of fallback names, so
+// that they lose to the names of any other source.
+const fallbackPriority = 3
+
+// MatchSource is where a name a field can be matched by comes from, see
+// WithStructTagPriority.
+type MatchSource int
+
+const (
+	// MatchTag is a name listed in the `env` tag, primary name or alias.
+	MatchTag MatchSource = iota
+	// MatchFieldName is the Go name of the field, used when the `env` tag has
+	// no primary name.
+	MatchFieldName
+	// MatchTypeName is the name of the field type.
+	MatchTypeName
+)
+
+// MatchMode defines how keys address the fields of a struct, see
+// WithMatchMode.
+type MatchMode int
+
+const (
+	// MatchNames matches fields by their names only. It is the default.
+	MatchNames MatchMode = iota
+	// MatchIndex also matches a numeric segment which matches no name as the
+	// declaration index of a field, counting from 0 and including unexported
+	// fields, for positional configs generated without stable names: _0_1
+	// (or 0_1) addresses the second field of the struct held by the first
+	// field. The key may start with '_' to mark it as positional. An index
+	// past the last field, or of a field tagged `env:"-"`, is an error.
+	MatchIndex
+)
+
+// Options of the `env` tag, listed after the names.
+const (
+	// envOptionInline marks a map field which captures the keys matching no
+	// other field of its struct, see inlineField.
+	envOptionInline = "inline"
+	// envOptionFile marks a string or []byte field whose value is the path
+	// of the file holding it, see readFile.
+	envOptionFile = "file"
+)
+
+// splitEnvTag splits the `env` tag of field into its names and its options.
+// An entry is an option when it is a known option keyword or has a '='.
+func (o *options) splitEnvTag(field reflect.StructField) (names, opts []string) {
+	tag, ok := field.Tag.Lookup(o.tagNames.Env)
+	if !ok {
+		return nil, nil
+	}
+
+	for i, entry := range strings.Split(tag, ",") {
+		entry = strings.TrimSpace(entry)
+		if i > 0 && (entry == envOptionInline || entry == envOptionFile || strings.Contains(entry, "=")) {
+			opts = append(opts, entry)
+			continue
+		}
+		names = append(names, entry)
+	}
+	return names, opts
+}
+
+// hasEnvOption reports whether the `env` tag of field has the option.
+func (o *options) hasEnvOption(field reflect.StructField, option string) bool {
+	_, opts := o.splitEnvTag(field)
+	return slices.Contains(opts, option)
+}
+
+// fieldNames returns the names a struct field can be matched by.
+//
+// The `env` tag lists the names of the field separated by commas: the first
+// one is the primary name, the others are aliases, e.g. `env:"HOST,ADDR"`.
+// An empty primary name (`env:",ADDR"`) stands for the Go name of the field,
+// which is also the primary name when there is no tag. When the tag gives
+// another primary name, the Go name still matches after all the tag names,
+// as it did before the tag was read: a struct shared with the env plugin of
+// xconfig, whose tags hold absolute names such as `env:"DB_HOST"` on the
+// Host field of DB, still gets DB_HOST. The name of the field type always
+// matches with the primary rank. A field tagged `env:"-"`, an
+// inline field and a field with a prefix (see prefixedFields) have no names
+// and are never matched by them.
+func (o *options) fieldNames(field reflect.StructField) []fieldName {
+	tagNames, opts := o.splitEnvTag(field)
+	if slices.Contains(opts, envOptionInline) || (len(tagNames) == 1 && tagNames[0] == "-") {
+		return nil
+	}
+	if _, ok := o.fieldPrefix(field); ok {
+		return nil
+	}
+
+	if len(tagNames) == 0 {
+		tagNames = []string{""}
+	}
+
+	names := make([]fieldName, 0, len(tagNames)+1)
+	for rank, name := range tagNames {
+		switch {
+		case rank == 0 && name == "":
+			names = append(names, fieldName{value: field.Name, source: MatchFieldName})
+		case name != "":
+			names = append(names, fieldName{value: name, rank: rank, source: MatchTag})
+		}
+	}
+	if tagNames[0] != "" && !strings.EqualFold(tagNames[0], field.Name) {
+		names = append(names, fieldName{value: field.Name, rank: len(tagNames), source: MatchFieldName, fallback: true})
+	}
+	if typeName := field.Type.Name(); typeName != "" {
+		names = append(names, fieldName{value: typeName, source: MatchTypeName})
+	}
+	return names
+}
+
+// inlineField returns the index of the field of typ tagged `env:",inline"`,
+// or -1. An inline field is a map with string keys which receives, under
+// their full remaining key, the keys reaching its struct without matching
+// any other field: with an Extra map[string]string field tagged
+// `env:",inline"` in the struct of the DB field, DB_FOO_BAR=1 gives
+// Extra["FOO_BAR"] = "1".
+// Captured keys are matched keys, so they never reach WithUnknownKeyCallback.
+func (o *options) inlineField(typ reflect.Type) int {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Type.Kind() == reflect.Map && o.hasEnvOption(field, envOptionInline) && !o.skipsField(field) {
+			return i
+		}
+	}
+	return -1
+}
+
+// FieldMatcher decides whether a struct field matches the leading segments
+// of a key, the key being split on '_'. It returns the number of segments
+// the field consumes, between 1 and len(segments); the remaining segments
+// are then matched inside the field (struct fields, map keys, slice indices).
+//
+// The decoder calls it for every field of a struct, each time a key reaches
+// that struct. The field consuming the most segments wins, ties going to the
+// field declared first.
+type FieldMatcher func(field reflect.StructField, segments []string) (matchLen int, ok bool)
+
+// match returns the index of the field of typ matching the longest prefix
+// of parts, the length of that prefix and the rank of the matching name.
+// The index is -1 when no field matches. Among the fields matching the
+// longest prefix, the one matched through the source coming first in the
+// WithStructTagPriority order wins; when several remain, the AmbiguityPolicy
+// decides.
+func (s *decodeState) match(typ reflect.Type, parts []string) (index, matchLen, rank int, err error) {
+	index = -1
+	ambiguous := -1
+	priority := 0
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if s.opts.skipsField(field) {
+			continue
+		}
+
+		n, r, p, ok := s.matchLen(field, parts)
+		if !ok {
+			continue
+		}
+		if n < 1 || n > len(parts) {
+			return -1, 0, 0, fmt.Errorf("field matcher returned match length %d for field %q, expecting 1 to %d", n, field.Name, len(parts))
+		}
+		switch {
+		case n > matchLen, n == matchLen && p < priority:
+			index, matchLen, rank, priority = i, n, r, p
+			ambiguous = -1
+		case n == matchLen && p == priority && ambiguous < 0:
+			ambiguous = i
+		}
+	}
+
+	if index < 0 && s.opts.matchMode == MatchIndex && s.opts.fieldMatcher == nil {
+		index, matchLen, err = s.matchIndex(typ, parts)
+		return index, matchLen, 0, err
+	}
+
+	if ambiguous >= 0 && s.opts.ambiguityPolicy != AmbiguityFirst {
+		return -1, 0, 0, fmt.Errorf("%w: %q matches fields %q and %q",
+			ErrAmbiguous, strings.Join(parts[:matchLen], "_"), typ.Field(index).Name, typ.Field(ambiguous).Name)
+	}
+	return index, matchLen, rank, nil
+}
+
+// matchIndex matches the leading segment of parts, after an optional empty
+// one, as the declaration index of a field of typ, see MatchIndex. The index
+// is -1 when the segment is not a number.
+func (s *decodeState) matchIndex(typ reflect.Type, parts []string) (index, matchLen int, err error) {
+	n := 0
+	if len(parts) > 1 && parts[0] == "" {
+		n = 1
+	}
+	if !isIndex(parts[n]) {
+		return -1, 0, nil
+	}
+
+	i, err := strconv.Atoi(parts[n])
+	if err != nil || i >= typ.NumField() {
+		return -1, 0, fmt.Errorf("field index %s out of range: %s has %d fields", parts[n], typ, typ.NumField())
+	}
+	if names, _ := s.opts.splitEnvTag(typ.Field(i)); len(names) == 1 && names[0] == "-" {
+		return -1, 0, fmt.Errorf("field index %d: field %q is excluded from decoding", i, typ.Field(i).Name)
+	}
+	if s.opts.skipsField(typ.Field(i)) {
+		return -1, 0, fmt.Errorf("field index %d: field %q is unexported", i, typ.Field(i).Name)
+	}
+	return i, n + 1, nil
+}
+
+// matchLen returns the number of leading parts matched by field, the rank of
+// the matching name and the priority of its source, using the FieldMatcher
+// when one is set.
+func (s *decodeState) matchLen(field reflect.StructField, parts []string) (int, int, int, bool) {
+	if s.opts.fieldMatcher != nil {
+		n, ok := s.opts.fieldMatcher(field, parts)
+		return n, 0, 0, ok
+	}
+
+	names := s.opts.fieldNames(field)
+	if len(names) == 0 {
+		return 0, 0, 0, false
+	}
+
+	// We sort out all the prefixes from complete to the minimum
+	for prefixLen := len(parts); prefixLen >= 1; prefixLen-- {
+		if rank, priority, ok := s.matchNames(names, strings.Join(parts[:prefixLen], "_")); ok {
+			return prefixLen, rank, priority, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// matchNames reports whether one of the names of a field matches the key
+// prefix, and returns the best source priority and then the best (lowest)
+// rank among the matching names.
+//
+// Names are compared after normalization (see normalize). Matching is
+// case-insensitive unless WithCaseSensitive is set, in which case aliases
+// are still compared case-insensitively under WithAliasCaseFold. Names from
+// a source missing from WithStructTagPriority never match, and fallback
+// names come after all the others.
+func (s *decodeState) matchNames(names []fieldName, prefix string) (rank, priority int, ok bool) {
+	for _, name := range names {
+		p, enabled := s.opts.sourcePriority(name.source)
+		if name.fallback {
+			p += fallbackPriority
+		}
+		if !enabled || ok && (p > priority || p == priority && name.rank >= rank) {
+			continue
+		}
+		fold := !s.opts.caseSensitive || (name.rank > 0 && s.opts.aliasCaseFold)
+		if normalize(name.value, fold) == normalize(prefix, fold) {
+			rank, priority, ok = name.rank, p, true
+		}
+	}
+	return rank, priority, ok
+}
+
+// Normalize delete everything '_' and, when fold is set, translates the line to the lower register
+func normalize(s string, fold bool) string {
+	if fold {
+		s = strings.ToLower(s)
+	}
+	return strings.ReplaceAll(s, "_", "")
+}
@@ -0,0 +1,266 @@
+package xconfigdotenv_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type aliasDatabase struct {
+	Host string `env:"HOST,ADDR,ADDRESS"`
+	Port int    `env:",PORT_NUMBER"`
+}
+
+type aliasConfig struct {
+	Database aliasDatabase `env:"DATABASE,DB"`
+	Ignored  string        `env:"-"`
+	AppName  string        `env:"APP_NAME"`
+}
+
+func TestAliases(t *testing.T) {
+	var config aliasConfig
+	err := xconfigdotenv.New().Unmarshal([]byte("DB_ADDR=db.local\nDB_PORT_NUMBER=5432\nIGNORED=x"), &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "db.local", config.Database.Host)
+	assert.Equal(t, 5432, config.Database.Port)
+	assert.Empty(t, config.Ignored)
+}
+
+func TestAliasResolutionOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"primary wins over aliases", "DATABASE_ADDRESS=c\nDATABASE_ADDR=b\nDATABASE_HOST=a", "a"},
+		{"earlier alias wins", "DATABASE_ADDRESS=c\nDATABASE_ADDR=b", "b"},
+		{"primary struct name wins", "DB_HOST=b\nDATABASE_HOST=a", "a"},
+		{"outer rank decides first", "DATABASE_ADDRESS=a\nDB_HOST=b", "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config aliasConfig
+			err := xconfigdotenv.New().Unmarshal([]byte(tt.data), &config)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, config.Database.Host)
+		})
+	}
+}
+
+func TestEnvPluginTags(t *testing.T) {
+	// the tags hold the absolute names read by the env plugin of xconfig
+	type config struct {
+		DB struct {
+			Host string `env:"DB_HOST"`
+		}
+		Port int `env:"HTTP_PORT"`
+	}
+
+	var c config
+	err := xconfigdotenv.New().Unmarshal([]byte("DB_HOST=db\nHTTP_PORT=80\nPORT=81"), &c)
+	assert.NoError(t, err)
+	assert.Equal(t, "db", c.DB.Host)
+	// the tag name wins over the Go name of the field
+	assert.Equal(t, 80, c.Port)
+
+	c = config{}
+	assert.NoError(t, xconfigdotenv.New().Unmarshal([]byte("PORT=81"), &c))
+	assert.Equal(t, 81, c.Port)
+	assert.NoError(t, xconfigdotenv.New().Prepare(&c))
+}
+
+func TestCaseSensitive(t *testing.T) {
+	data := []byte("APP_NAME=a\nDATABASE_addr=b\nDATABASE_port=1")
+
+	t.Run("case-insensitive by default", func(t *testing.T) {
+		var config aliasConfig
+		err := xconfigdotenv.New().Unmarshal(data, &config)
+		assert.NoError(t, err)
+		assert.Equal(t, "a", config.AppName)
+		assert.Equal(t, "b", config.Database.Host)
+		assert.Equal(t, 1, config.Database.Port)
+	})
+
+	t.Run("case-sensitive", func(t *testing.T) {
+		var config aliasConfig
+		err := xconfigdotenv.New(xconfigdotenv.WithCaseSensitive()).Unmarshal(data, &config)
+		assert.NoError(t, err)
+		assert.Equal(t, "a", config.AppName)
+		assert.Empty(t, config.Database.Host)
+		assert.Zero(t, config.Database.Port)
+	})
+
+	t.Run("case-sensitive with alias case fold", func(t *testing.T) {
+		var config aliasConfig
+		decoder := xconfigdotenv.New(xconfigdotenv.WithCaseSensitive(), xconfigdotenv.WithAliasCaseFold())
+		err := decoder.Unmarshal(data, &config)
+		assert.NoError(t, err)
+		assert.Equal(t, "a", config.AppName)
+		assert.Equal(t, "b", config.Database.Host)
+		// Port is matched by its primary name, which stays case-sensitive
+		assert.Zero(t, config.Database.Port)
+	})
+}
+
+func TestFieldMatcher(t *testing.T) {
+	// match fields by their `key` tag, consuming as many segments as the tag has
+	matcher := func(field reflect.StructField, segments []string) (int, bool) {
+		key := strings.Split(field.Tag.Get("key"), "_")
+		if len(key) > len(segments) {
+			return 0, false
+		}
+		for i := range key {
+			if key[i] != segments[i] {
+				return 0, false
+			}
+		}
+		return len(key), true
+	}
+
+	var config struct {
+		Server struct {
+			Port int `key:"p"`
+		} `key:"srv_main"`
+		Name string `key:"n"`
+		Host string
+	}
+	err := xconfigdotenv.New(xconfigdotenv.WithFieldMatcher(matcher)).Unmarshal([]byte("srv_main_p=8080\nn=app\nHOST=ignored"), &config)
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, config.Server.Port)
+	assert.Equal(t, "app", config.Name)
+	assert.Empty(t, config.Host)
+}
+
+func TestFieldMatcherInvalidLength(t *testing.T) {
+	matcher := func(reflect.StructField, []string) (int, bool) {
+		return 5, true
+	}
+
+	var config struct {
+		Name string
+	}
+	err := xconfigdotenv.New(xconfigdotenv.WithFieldMatcher(matcher)).Unmarshal([]byte("NAME=app"), &config)
+	assert.ErrorContains(t, err, "match length 5")
+}
+
+func TestInlineMap(t *testing.T) {
+	type database struct {
+		Host  string
+		Extra map[string]string `env:",inline"`
+	}
+
+	var config struct {
+		Name  string
+		DB    database
+		Other map[string]int `env:",inline"`
+	}
+
+	unknown := []string{}
+	decoder := xconfigdotenv.New(xconfigdotenv.WithUnknownKeyCallback(func(key, _ string) error {
+		unknown = append(unknown, key)
+		return nil
+	}))
+	err := decoder.Unmarshal([]byte("NAME=app\nDB_HOST=db\nDB_SSL_MODE=disable\nDB_TIMEOUT=5\nRETRIES=3\nOTHER_X=1"), &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, "db", config.DB.Host)
+	assert.Equal(t, map[string]string{"SSL_MODE": "disable", "TIMEOUT": "5"}, config.DB.Extra)
+	// the inline field is never matched by its own name
+	assert.Equal(t, map[string]int{"RETRIES": 3, "OTHER_X": 1}, config.Other)
+	assert.Empty(t, unknown)
+}
+
+func TestStructTagPriority(t *testing.T) {
+	type Cache struct {
+		Size int
+	}
+
+	type config struct {
+		Local Cache
+		Cache struct {
+			Size int
+		} `env:"CACHE"`
+	}
+
+	data := []byte("CACHE_SIZE=10\nLOCAL_SIZE=1")
+
+	// by default the tag and the type name are equal
+	var ambiguous config
+	err := xconfigdotenv.New().Unmarshal(data, &ambiguous)
+	assert.ErrorIs(t, err, xconfigdotenv.ErrAmbiguous)
+
+	var tagFirst config
+	decoder := xconfigdotenv.New(xconfigdotenv.WithStructTagPriority(
+		xconfigdotenv.MatchTag, xconfigdotenv.MatchFieldName, xconfigdotenv.MatchTypeName))
+	assert.NoError(t, decoder.Unmarshal(data, &tagFirst))
+	assert.Equal(t, 10, tagFirst.Cache.Size)
+	assert.Equal(t, 1, tagFirst.Local.Size)
+
+	var typeFirst config
+	decoder = xconfigdotenv.New(xconfigdotenv.WithStructTagPriority(
+		xconfigdotenv.MatchTypeName, xconfigdotenv.MatchTag, xconfigdotenv.MatchFieldName))
+	assert.NoError(t, decoder.Unmarshal(data, &typeFirst))
+	assert.Equal(t, 1, typeFirst.Local.Size)
+	assert.Equal(t, 0, typeFirst.Cache.Size)
+
+	// the source without priority is disabled
+	var noType config
+	decoder = xconfigdotenv.New(xconfigdotenv.WithStructTagPriority(xconfigdotenv.MatchTag, xconfigdotenv.MatchFieldName))
+	assert.NoError(t, decoder.Unmarshal(data, &noType))
+	assert.Equal(t, 10, noType.Cache.Size)
+	assert.Equal(t, 1, noType.Local.Size)
+}
+
+func TestStructTagPriorityLongestPrefix(t *testing.T) {
+	var config struct {
+		DB       struct{ Port int } `env:"DB"`
+		DBPort   int
+		Replicas int
+	}
+
+	// the field name DBPort matches two segments, more than the tag DB
+	decoder := xconfigdotenv.New(xconfigdotenv.WithStructTagPriority(xconfigdotenv.MatchTag, xconfigdotenv.MatchFieldName))
+	assert.NoError(t, decoder.Unmarshal([]byte("DB_PORT=5432"), &config))
+	assert.Equal(t, 5432, config.DBPort)
+	assert.Equal(t, 0, config.DB.Port)
+}
+
+func TestMatchIndex(t *testing.T) {
+	type config struct {
+		Name string
+		DB   struct {
+			Host string
+			Port int
+		}
+		Hosts []struct{ Addr string }
+		Skip  string `env:"-"`
+	}
+
+	data := []byte("_0=app\n_1_0=db\nDB_PORT=5432\n_2_1_0=h1")
+	decoder := xconfigdotenv.New(xconfigdotenv.WithMatchMode(xconfigdotenv.MatchIndex))
+	var c config
+	assert.NoError(t, decoder.Unmarshal(data, &c))
+	assert.Equal(t, "app", c.Name)
+	assert.Equal(t, "db", c.DB.Host)
+	// names still match
+	assert.Equal(t, 5432, c.DB.Port)
+	assert.Len(t, c.Hosts, 2)
+	assert.Equal(t, "h1", c.Hosts[1].Addr)
+
+	err := decoder.Unmarshal([]byte("_1_2=x"), &c)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "_1_2": field index 2 out of range: struct { Host string; Port int } has 2 fields`)
+
+	err = decoder.Unmarshal([]byte("_3=x"), &c)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "_3": field index 3: field "Skip" is excluded from decoding`)
+
+	// numeric segments are not indices by default
+	c = config{}
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &c))
+	assert.Empty(t, c.Name)
+}
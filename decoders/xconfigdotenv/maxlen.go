@@ -0,0 +1,95 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// maxLenTag is the tag giving the maximum number of elements of a slice or
+// map field, see checkMaxLens.
+const maxLenTag = "maxlen"
+
+// maxLen returns the maximum number of elements the `maxlen` tag of field
+// gives, if it has one.
+func (o *options) maxLen(field reflect.StructField) (int, bool, error) {
+	tag, ok := field.Tag.Lookup(o.tagNames.MaxLen)
+	if !ok {
+		return 0, false, nil
+	}
+	n, err := strconv.Atoi(tag)
+	if err != nil || n < 0 {
+		return 0, false, fmt.Errorf("maxlen of field %s: expecting a number of elements, got %q", field.Name, tag)
+	}
+	return n, true, nil
+}
+
+// checkIndex fails if the slice element at index ix, which a key is about
+// to create, is beyond the maximum length of field, so a key such as
+// HOSTS_1000000 does not grow the slice before checkMaxLens rejects it.
+func (o *options) checkIndex(field reflect.StructField, ix int) error {
+	n, ok, err := o.maxLen(field)
+	if err != nil || !ok || ix < n {
+		return err
+	}
+	return fmt.Errorf("index %d of field %s is beyond its maxlen of %d", ix, field.Name, n)
+}
+
+// checkMaxLens fails if a slice or map field of the struct v, at path, has
+// more elements than its `maxlen` tag allows, e.g. `maxlen:"16"` on a Hosts
+// []string field given 17 hosts, whether as a single value or by index. It
+// runs once every key is decoded, so a map filled by many keys is counted
+// as a whole. The fields are searched as sortSlices searches them.
+func (s *decodeState) checkMaxLens(v reflect.Value, path string) error {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if s.opts.skipsField(field) {
+			continue
+		}
+		n, tagged, err := s.opts.maxLen(field)
+		if err != nil {
+			return err
+		}
+		nested := s.opts.hasTag(field.Type, s.opts.tagNames.MaxLen, map[reflect.Type]bool{})
+		if !tagged && !nested {
+			continue
+		}
+		fieldVal := getFieldValue(v, i)
+		fieldPath := joinPath(path, field.Name)
+
+		if tagged {
+			if kind := fieldVal.Kind(); kind != reflect.Slice && kind != reflect.Map {
+				return fmt.Errorf("maxlen of field %s: expecting a slice or a map, got %s", fieldPath, fieldVal.Type())
+			}
+			if fieldVal.Len() > n {
+				return fmt.Errorf("field %s has %d elements, more than its maxlen of %d", fieldPath, fieldVal.Len(), n)
+			}
+		}
+		if nested {
+			if err := s.checkMaxLensIn(fieldVal, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkMaxLensIn checks the tagged fields of the structs held by v, at path.
+func (s *decodeState) checkMaxLensIn(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return s.checkMaxLensIn(v.Elem(), path)
+		}
+	case reflect.Struct:
+		return s.checkMaxLens(v, path)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := s.checkMaxLensIn(v.Index(i), path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
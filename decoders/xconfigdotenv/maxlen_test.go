@@ -0,0 +1,49 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxLen(t *testing.T) {
+	type upstream struct {
+		Hosts []string `maxlen:"2"`
+	}
+	type config struct {
+		Tags      []string          `maxlen:"3"`
+		Labels    map[string]string `maxlen:"1"`
+		Upstreams []upstream
+		Roots     []string
+	}
+
+	decoder := xconfigdotenv.New()
+	var c config
+	assert.NoError(t, decoder.Unmarshal([]byte("TAGS=a,b,c\nLABELS_team=core\nUPSTREAMS_0_HOSTS=h1,h2\nROOTS=1,2,3,4,5"), &c))
+	assert.Equal(t, []string{"a", "b", "c"}, c.Tags)
+
+	err := decoder.Unmarshal([]byte("TAGS=a,b,c,d"), &config{})
+	assert.EqualError(t, err, "xconfigdotenv: Unmarshal: field Tags has 4 elements, more than its maxlen of 3")
+
+	err = decoder.Unmarshal([]byte("LABELS_team=core\nLABELS_env=prod"), &config{})
+	assert.EqualError(t, err, "xconfigdotenv: Unmarshal: field Labels has 2 elements, more than its maxlen of 1")
+
+	err = decoder.Unmarshal([]byte("UPSTREAMS_1_HOSTS=h1,h2,h3"), &config{})
+	assert.EqualError(t, err, "xconfigdotenv: Unmarshal: field Upstreams[1].Hosts has 3 elements, more than its maxlen of 2")
+
+	// indexed keys are rejected before the slice grows
+	err = decoder.Unmarshal([]byte("TAGS_1000000000=a"), &config{})
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "TAGS_1000000000": index 1000000000 of field Tags is beyond its maxlen of 3`)
+	assert.NoError(t, decoder.Unmarshal([]byte("TAGS_2=c"), &config{}))
+
+	err = decoder.Unmarshal([]byte("PORT=80"), &struct {
+		Port int `maxlen:"1"`
+	}{})
+	assert.EqualError(t, err, "xconfigdotenv: Unmarshal: maxlen of field Port: expecting a slice or a map, got int")
+
+	err = decoder.Unmarshal([]byte("HOSTS=a"), &struct {
+		Hosts []string `maxlen:"many"`
+	}{})
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: maxlen of field Hosts: expecting a number of elements, got "many"`)
+}
@@ -0,0 +1,110 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Metadata describes an UnmarshalWithMetadata run.
+type Metadata struct {
+	// Metrics holds the counters of the run.
+	Metrics Metrics
+	// Warnings lists, in key order, the values which were decoded but
+	// deserve attention, such as coerced values.
+	Warnings []Warning
+	// Flat holds every key of the input with its value as parsed, after
+	// the variable expansion of the parser and the hook of
+	// WithBeforeEachKey, and before secrets are resolved or preprocessors
+	// run. Values of keys matching a field tagged
+	// `secret` or `redact:"true"`, or holding credentials such as
+	// url.Userinfo, or inside a struct field tagged so, are masked.
+	Flat map[string]string
+}
+
+// secretTag is the default key of the tag marking secret fields, shared
+// with the secret plugin of xconfig.
+const secretTag = "secret"
+
+// maskedValue replaces the values of secret keys in Metadata.Flat,
+// and the values of redacted fields in messages.
+const maskedValue = "***"
+
+// Warning describes a value of the input accepted with a remark.
+type Warning struct {
+	// Key is the key of the value.
+	Key string
+	// Message describes what happened.
+	Message string
+}
+
+// String returns the key and the message of the warning.
+func (w Warning) String() string {
+	return w.Key + ": " + w.Message
+}
+
+// Metrics holds the counters of a decode run, e.g. to track the health of
+// a config across reloads.
+type Metrics struct {
+	// KeysProcessed is the number of keys read from the input.
+	KeysProcessed int
+	// KeysMatched is the number of keys which matched a field.
+	KeysMatched int
+	// KeysIgnored is the number of keys which matched no field.
+	KeysIgnored int
+	// Errors is the number of errors the run failed with.
+	Errors int
+	// Duration is the time the run took.
+	Duration time.Duration
+}
+
+// warn records a warning for the current key, if warnings are collected.
+func (s *decodeState) warn(format string, args ...any) {
+	if s.meta == nil {
+		return
+	}
+	s.meta.Warnings = append(s.meta.Warnings, Warning{Key: s.key, Message: s.redactText(fmt.Sprintf(format, args...))})
+}
+
+// recordFlat copies the keys of the input in the metadata, if it is collected.
+func (s *decodeState) recordFlat(flatMap map[string]string) {
+	if s.meta == nil {
+		return
+	}
+	s.meta.Flat = make(map[string]string, len(flatMap))
+	for k, v := range flatMap {
+		s.meta.Flat[k] = v
+	}
+}
+
+// markField notes that the current key matched field: a redacted field
+// redacts the messages of the key, the value of the key is masked in the
+// metadata when the field is secret or redacted, and a deprecated field
+// gets a warning.
+func (s *decodeState) markField(field reflect.StructField) {
+	s.timeLayout = s.opts.timeLayout(field)
+	if s.opts.isRedacted(field) {
+		s.redacted = true
+	}
+	if s.meta == nil || s.key == "" {
+		return
+	}
+	s.warnDeprecatedField(field)
+	if _, ok := field.Tag.Lookup(s.opts.tagNames.Secret); ok || s.redacted {
+		s.meta.Flat[s.key] = maskedValue
+	}
+}
+
+// countKey counts a processed key in the metrics, if they are collected.
+func (s *decodeState) countKey(matched bool) {
+	if s.meta == nil {
+		return
+	}
+
+	s.meta.Metrics.KeysProcessed++
+	if matched {
+		s.meta.Metrics.KeysMatched++
+	} else {
+		s.meta.Metrics.KeysIgnored++
+	}
+}
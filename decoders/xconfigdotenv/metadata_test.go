@@ -0,0 +1,63 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type metricsConfig struct {
+	Name string
+	Port int
+	DB   struct {
+		Host string
+	}
+}
+
+func TestUnmarshalWithMetadataMetrics(t *testing.T) {
+	var config metricsConfig
+	meta, err := xconfigdotenv.New().UnmarshalWithMetadata([]byte("NAME=app\nPORT=80\nDB_HOST=db\nDB_USER=u\nOTHER=1"), &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, 5, meta.Metrics.KeysProcessed)
+	assert.Equal(t, 3, meta.Metrics.KeysMatched)
+	assert.Equal(t, 2, meta.Metrics.KeysIgnored)
+	assert.Zero(t, meta.Metrics.Errors)
+	assert.Positive(t, meta.Metrics.Duration)
+}
+
+func TestUnmarshalWithMetadataError(t *testing.T) {
+	var config metricsConfig
+	meta, err := xconfigdotenv.New().UnmarshalWithMetadata([]byte("PORT=eighty"), &config)
+	assert.Error(t, err)
+
+	if assert.NotNil(t, meta) {
+		assert.Equal(t, 1, meta.Metrics.KeysProcessed)
+		assert.Equal(t, 1, meta.Metrics.KeysMatched)
+		assert.Equal(t, 1, meta.Metrics.Errors)
+	}
+}
+
+func TestUnmarshalWithMetadataFlat(t *testing.T) {
+	var config struct {
+		Name     string
+		Password string `secret:""`
+		Vault    struct {
+			Token string
+		} `secret:""`
+	}
+
+	data := []byte("NAME=app\nGREETING=\"hi ${NAME}\"\nPASSWORD=hunter2\nVAULT_TOKEN=t0k\nOTHER=1")
+	meta, err := xconfigdotenv.New().UnmarshalWithMetadata(data, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", config.Password)
+	assert.Equal(t, map[string]string{
+		"NAME":        "app",
+		"GREETING":    "hi app",
+		"PASSWORD":    "***",
+		"VAULT_TOKEN": "***",
+		"OTHER":       "1",
+	}, meta.Flat)
+}
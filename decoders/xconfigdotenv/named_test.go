@@ -0,0 +1,114 @@
+package xconfigdotenv_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	namedHost   string
+	namedPort   uint16
+	namedHosts  []string
+	namedPorts  []namedPort
+	namedNames  []namedHost
+	namedLabels map[string]string
+	namedLimits map[namedHost]namedPort
+	namedGroups map[string]namedHosts
+	namedSet    map[namedHost]struct{}
+	namedAddrs  []struct{ Host namedHost }
+	namedBytes  []byte
+	namedByte   uint8
+	namedRaw    []namedByte
+	namedValue  any
+	namedAny    map[namedHost]namedValue
+)
+
+type namedConfig struct {
+	Hosts   namedHosts
+	Ports   namedPorts
+	Names   namedNames
+	Indexed namedNames
+	Labels  namedLabels
+	Limits  namedLimits
+	Groups  namedGroups
+	Tags    namedSet
+	Addrs   namedAddrs
+	Key     namedBytes
+	Raw     namedRaw
+	Value   namedRaw `format:"raw"`
+	Meta    namedAny
+	Ptr     *namedHosts
+}
+
+func TestNamedContainers(t *testing.T) {
+	data := []byte(`HOSTS=a,b
+PORTS=80,443
+NAMES=x,y
+INDEXED_0=i
+INDEXED_1=j
+LABELS_team=core
+LIMITS_api=10
+GROUPS_web=w1,w2
+TAGS=t1,t2
+ADDRS_0_HOST=h
+KEY=raw
+RAW=xy
+VALUE=z
+META_build_id=7
+PTR=p,q`)
+
+	var config namedConfig
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+	ptr := namedHosts{"p", "q"}
+	expect := namedConfig{
+		Hosts:   namedHosts{"a", "b"},
+		Ports:   namedPorts{80, 443},
+		Names:   namedNames{"x", "y"},
+		Indexed: namedNames{"i", "j"},
+		Labels:  namedLabels{"team": "core"},
+		Limits:  namedLimits{"api": 10},
+		Groups:  namedGroups{"web": {"w1", "w2"}},
+		Tags:    namedSet{"t1": {}, "t2": {}},
+		Addrs:   namedAddrs{{Host: "h"}},
+		Key:     namedBytes("raw"),
+		Raw:     namedRaw("xy"),
+		Value:   namedRaw("z"),
+		Meta:    namedAny{"build_id": "7"},
+		Ptr:     &ptr,
+	}
+	assert.Equal(t, expect, config)
+
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	var decoded namedConfig
+	assert.NoError(t, decoder.Unmarshal(out, &decoded))
+	assert.Equal(t, expect, decoded, string(out))
+}
+
+func TestJSONNumber(t *testing.T) {
+	var config struct {
+		Amount json.Number
+		Limit  *json.Number
+		Steps  []json.Number
+		Codes  map[string]json.Number
+	}
+
+	// the text is kept as is, beyond the range and precision of float64
+	data := []byte("AMOUNT=12345678901234567890.50\nLIMIT=1e400\nSTEPS=1, 2.50,3\nCODES_A=007")
+	decoder := xconfigdotenv.New(xconfigdotenv.WithNumberLocale(xconfigdotenv.NumberLocaleComma))
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+	assert.Equal(t, json.Number("12345678901234567890.50"), config.Amount)
+	if assert.NotNil(t, config.Limit) {
+		assert.Equal(t, json.Number("1e400"), *config.Limit)
+	}
+	assert.Equal(t, []json.Number{"1", "2.50", "3"}, config.Steps)
+	assert.Equal(t, map[string]json.Number{"A": "007"}, config.Codes)
+
+	out, err := xconfigdotenv.New().Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "AMOUNT=12345678901234567890.50\nLIMIT=1e400\nSTEPS=1,2.50,3\nCODES_A=007\n", string(out))
+}
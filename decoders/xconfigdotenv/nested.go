@@ -0,0 +1,142 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// assignMapValue puts rawVal in the map field mapVal under the key segments.
+// It reports whether the key matched.
+//
+// The segments are consumed according to the map value type:
+//
+//   - a scalar (or any) value takes all the segments as its key, joined
+//     with '_': META_build_id=7 sets META["build_id"].
+//   - a map value takes exactly one segment as its key, the next segments
+//     going to the inner map: with map[string]map[string]string,
+//     ROUTES_api_v1_path=/x sets ROUTES["api"]["v1_path"]. The keys of the
+//     outer maps thus never hold underscores.
+//   - a struct (or pointer to struct) value takes the fewest segments after
+//     which the remaining ones match a field of the struct: with
+//     map[string]Endpoint, ROUTES_user_api_PATH=/x sets the Path field of
+//     ROUTES["user_api"] when Endpoint has no field matching API_PATH. A key
+//     matching no field after any split matches nothing.
+//
+// So with map[string]map[string]Endpoint, ROUTES_api_v1_PATH=/x sets the
+// Path field of ROUTES["api"]["v1"].
+func (s *decodeState) assignMapValue(mapVal reflect.Value, field reflect.StructField, segments []string, rawVal, path string) (bool, error) {
+	if len(segments) == 0 {
+		return true, fmt.Errorf("map field %q but no key given (leftover is empty)", field.Name)
+	}
+	if mapVal.IsNil() { // initialize map if it needed
+		if err := setWithReflect(mapVal, reflect.MakeMap(mapVal.Type())); err != nil {
+			return true, err
+		}
+	}
+
+	elemType := mapVal.Type().Elem()
+	n := 0
+	switch {
+	case isNestedMap(elemType):
+		if len(segments) < 2 {
+			return true, fmt.Errorf("map field %q: no key given for the nested map under %q", field.Name, segments[0])
+		}
+		n = 1
+	case isStructValue(elemType):
+		n = s.structKeyLen(derefType(elemType), segments)
+		if n == 0 {
+			return false, nil
+		}
+	default:
+		mapKey := strings.Join(segments, "_")
+		if !s.claim(path + "[" + mapKey + "]") {
+			return true, nil
+		}
+		if isAnyType(elemType) {
+			rawVal = s.opts.formatValue(field, rawVal)
+			return true, s.reportSet(setAnyMapValue(mapVal, segments, rawVal), path+"["+mapKey+"]", reflect.ValueOf(rawVal))
+		}
+		return true, s.reportEntry(s.setMapValue(mapVal, mapKey, s.opts.formatValue(field, rawVal)), mapVal, path, mapKey)
+	}
+
+	// Map values are not addressable: work on a copy, then store it back
+	mapKey := strings.Join(segments[:n], "_")
+	elem := reflect.New(elemType).Elem()
+	key, err := mapKeyValue(mapVal.Type().Key(), mapKey)
+	if err != nil {
+		return true, err
+	}
+	if existing := mapVal.MapIndex(key); existing.IsValid() {
+		elem.Set(existing)
+	} else if elemType.Kind() != reflect.Map {
+		newElem, err := s.newValue(derefType(elemType))
+		if err != nil {
+			return true, err
+		}
+		if elemType.Kind() == reflect.Ptr {
+			elem.Set(newElem)
+		} else {
+			elem.Set(newElem.Elem())
+		}
+	}
+
+	elemPath := path + "[" + mapKey + "]"
+	var matched bool
+	switch {
+	case elemType.Kind() == reflect.Map:
+		matched, err = s.assignMapValue(elem, field, segments[n:], rawVal, elemPath)
+	case elemType.Kind() == reflect.Ptr:
+		if elem.IsNil() {
+			newElem, err := s.newValue(elemType.Elem())
+			if err != nil {
+				return true, err
+			}
+			elem.Set(newElem)
+		}
+		matched, err = s.assignValue(elem.Elem(), segments[n:], rawVal, elemPath)
+	default:
+		matched, err = s.assignValue(elem, segments[n:], rawVal, elemPath)
+	}
+	if !matched && err == nil {
+		return false, nil
+	}
+	if storeErr := storeMapValue(mapVal, mapKey, elem); storeErr != nil {
+		return true, storeErr
+	}
+	return true, err
+}
+
+// structKeyLen returns the fewest leading segments to take as a map key so
+// that the remaining segments match a field of the struct type st, or 0.
+func (s *decodeState) structKeyLen(st reflect.Type, segments []string) int {
+	for n := 1; n < len(segments); n++ {
+		if i, _, _, err := s.match(st, segments[n:]); i >= 0 || err != nil {
+			// An ambiguous match is reported when descending
+			return n
+		}
+	}
+	return 0
+}
+
+// isNestedMap reports whether the map value type t is itself a map whose
+// entries are keyed by the next segments, rather than a set.
+func isNestedMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && !isSetType(t) && converter(t) == nil
+}
+
+// isStructValue reports whether the map value type t is a struct, or a
+// pointer to one, decoded field by field.
+func isStructValue(t reflect.Type) bool {
+	st := derefType(t)
+	return st.Kind() == reflect.Struct && st.NumField() > 0 && converter(t) == nil && converter(st) == nil && !isBinaryType(st) && !isPflagType(st) && st != syncMapType &&
+		!reflect.PointerTo(st).Implements(mapSetterType)
+}
+
+// derefType returns the type t points to, or t when it is not a pointer.
+func derefType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
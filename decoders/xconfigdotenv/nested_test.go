@@ -0,0 +1,132 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type endpoint struct {
+	Path    string
+	Timeout int `default:"30"`
+	TLS     struct {
+		Cert string
+	}
+}
+
+func TestNestedMaps(t *testing.T) {
+	var config struct {
+		Routes    map[string]map[string]endpoint
+		Services  map[string]endpoint
+		Backends  map[string]*endpoint
+		Labels    map[string]map[string]string
+		Unmatched map[string]endpoint
+	}
+
+	var unknown []string
+	decoder := xconfigdotenv.New(xconfigdotenv.WithUnknownKeyCallback(func(key, _ string) error {
+		unknown = append(unknown, key)
+		return nil
+	}))
+
+	data := []byte(`ROUTES_api_v1_PATH=/x
+ROUTES_api_v1_TIMEOUT=5
+ROUTES_api_v2_PATH=/y
+ROUTES_web_home_TLS_CERT=web.pem
+SERVICES_user_api_PATH=/users
+SERVICES_user_api_TLS_CERT=users.pem
+BACKENDS_db_PATH=/db
+LABELS_team_core_owner=alice
+LABELS_team_ops=bob
+UNMATCHED_a_NOPE=1`)
+	err := decoder.Unmarshal(data, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]map[string]endpoint{
+		"api": {
+			"v1": {Path: "/x", Timeout: 5},
+			"v2": {Path: "/y", Timeout: 30},
+		},
+		"web": {
+			"home": {Timeout: 30, TLS: struct{ Cert string }{Cert: "web.pem"}},
+		},
+	}, config.Routes)
+
+	// the key holds underscores when no field matches sooner
+	assert.Equal(t, map[string]endpoint{
+		"user_api": {Path: "/users", Timeout: 30, TLS: struct{ Cert string }{Cert: "users.pem"}},
+	}, config.Services)
+
+	if assert.Contains(t, config.Backends, "db") {
+		assert.Equal(t, &endpoint{Path: "/db", Timeout: 30}, config.Backends["db"])
+	}
+	assert.Equal(t, map[string]map[string]string{
+		"team": {"core_owner": "alice", "ops": "bob"},
+	}, config.Labels)
+
+	assert.Empty(t, config.Unmatched)
+	assert.Equal(t, []string{"UNMATCHED_a_NOPE"}, unknown)
+
+	out, err := xconfigdotenv.New().Marshal(&config)
+	assert.NoError(t, err)
+	var decoded struct {
+		Routes    map[string]map[string]endpoint
+		Services  map[string]endpoint
+		Backends  map[string]*endpoint
+		Labels    map[string]map[string]string
+		Unmatched map[string]endpoint
+	}
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(out, &decoded))
+	assert.Equal(t, config.Routes, decoded.Routes)
+	assert.Equal(t, config.Services, decoded.Services)
+	assert.Equal(t, config.Backends, decoded.Backends)
+	assert.Equal(t, config.Labels, decoded.Labels)
+}
+
+func TestNestedMapsMissingKey(t *testing.T) {
+	var config struct {
+		Labels map[string]map[string]string
+	}
+
+	err := xconfigdotenv.New().Unmarshal([]byte("LABELS_team=core"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "LABELS_team": map field "Labels": no key given for the nested map under "team"`)
+}
+
+func TestSliceOfMaps(t *testing.T) {
+	var config struct {
+		Routes  []map[string]string
+		Limits  []map[string]int
+		Targets []map[string]endpoint
+	}
+
+	data := []byte(`ROUTES_0_host=a
+ROUTES_0_port=80
+ROUTES_1_host=b
+ROUTES_1_tls_mode=strict
+LIMITS_0_rps=10
+TARGETS_0_api_PATH=/api`)
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+	assert.Equal(t, []map[string]string{
+		{"host": "a", "port": "80"},
+		{"host": "b", "tls_mode": "strict"},
+	}, config.Routes)
+	assert.Equal(t, []map[string]int{{"rps": 10}}, config.Limits)
+	assert.Equal(t, []map[string]endpoint{{"api": {Path: "/api", Timeout: 30}}}, config.Targets)
+
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, `ROUTES_0_host=a
+ROUTES_0_port=80
+ROUTES_1_host=b
+ROUTES_1_tls_mode=strict
+LIMITS_0_rps=10
+TARGETS_0_api_PATH=/api
+TARGETS_0_api_TIMEOUT=30
+TARGETS_0_api_TLS_CERT=""
+`, string(out))
+
+	err = decoder.Unmarshal([]byte("LIMITS_0_rps=fast"), &config)
+	assert.ErrorContains(t, err, `cannot parse "fast" as int`)
+}
@@ -0,0 +1,167 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// numberText returns the text of the number rawVal to parse as kind ("int",
+// "uint" or "float") under the NumberPolicy: trimmed of spaces by default,
+// or checked to hold nothing but the number under NumberStrict. Under
+// WithCoerceBoolNumeric, true and false are 1 and 0 for integers, and under
+// WithSizeSuffixes an integer may end with a size suffix.
+func (s *decodeState) numberText(rawVal, kind string) (string, error) {
+	if s.opts.coerceBoolNumeric && kind != "float" {
+		switch strings.ToLower(rawVal) {
+		case "true":
+			s.warn("coerced %q to 1 for %s", rawVal, kind)
+			return "1", nil
+		case "false":
+			s.warn("coerced %q to 0 for %s", rawVal, kind)
+			return "0", nil
+		}
+	}
+
+	num := rawVal
+	if s.opts.numberPolicy != NumberStrict {
+		num = strings.TrimSpace(num)
+	}
+	multiplier := int64(1)
+	if s.opts.sizeSuffixes && kind != "float" {
+		num, multiplier = splitSizeSuffix(num)
+		if s.opts.numberPolicy != NumberStrict {
+			num = strings.TrimSpace(num)
+		}
+	}
+
+	if s.opts.numberLocale == NumberLocaleComma && kind == "float" {
+		num = commaDecimal(num)
+	}
+
+	if s.opts.numberPolicy == NumberStrict {
+		for i, r := range num {
+			switch {
+			case r >= '0' && r <= '9':
+			case (r == '+' || r == '-') && (i == 0 || kind == "float" && (num[i-1] == 'e' || num[i-1] == 'E')):
+			case kind == "float" && (r == '.' || r == 'e' || r == 'E'):
+			default:
+				return "", fmt.Errorf("cannot parse %q as %s: unexpected %q at offset %d in strict mode", rawVal, kind, r, i)
+			}
+		}
+	}
+
+	if multiplier == 1 {
+		return num, nil
+	}
+	// The range of the field type is checked when parsing the product
+	n, ok := new(big.Int).SetString(num, 10)
+	if !ok {
+		return "", fmt.Errorf("cannot parse %q as %s: invalid number before the size suffix", rawVal, kind)
+	}
+	return n.Mul(n, big.NewInt(multiplier)).String(), nil
+}
+
+// commaDecimal turns the float num, written with a decimal comma and
+// optional thousands points as under NumberLocaleComma, into the Go syntax.
+func commaDecimal(num string) string {
+	mantissa, exponent := num, ""
+	if i := strings.IndexAny(num, "eE"); i >= 0 {
+		mantissa, exponent = num[:i], num[i:]
+	}
+	intPart, frac, hasComma := strings.Cut(mantissa, ",")
+	if thousandsGrouped(intPart) {
+		intPart = strings.ReplaceAll(intPart, ".", "")
+	}
+	if hasComma {
+		return intPart + "." + frac + exponent
+	}
+	return intPart + exponent
+}
+
+// thousandsGrouped reports whether the integer digits, after an optional
+// sign, are split by points in groups of three, as in 1.234.567.
+func thousandsGrouped(digits string) bool {
+	digits = strings.TrimLeft(digits, "+-")
+	groups := strings.Split(digits, ".")
+	if len(groups) < 2 || len(groups[0]) == 0 || len(groups[0]) > 3 {
+		return false
+	}
+	for i, group := range groups {
+		if i > 0 && len(group) != 3 {
+			return false
+		}
+		for _, r := range group {
+			if r < '0' || r > '9' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// checkExact returns an error under WithStrictTypes unless num, the number
+// text of rawVal parsed as kind, is one of the texts the parsed value is
+// formatted as, so that the value reads back as written.
+func (s *decodeState) checkExact(rawVal, num, kind string, texts ...string) error {
+	if !s.opts.strictTypes || slices.Contains(texts, num) {
+		return nil
+	}
+	return fmt.Errorf("cannot parse %q as %s in strict types mode: the value is written %q", rawVal, kind, texts[0])
+}
+
+// parseFloat parses num, the number text of rawVal, as a float of bits bits,
+// rounded under WithFloatPrecision. A float32 which does not hold the
+// parsed value, such as 0.123456789 held as 0.12345679, is reported in the
+// Warnings.
+func (s *decodeState) parseFloat(rawVal, num string, bits int) (float64, error) {
+	// Parsing with the bits of the field checks its range
+	if _, err := strconv.ParseFloat(num, bits); err != nil {
+		return 0, fmt.Errorf("cannot parse %q as float: %w", rawVal, err)
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse %q as float: %w", rawVal, err)
+	}
+	if err := s.checkExact(rawVal, num, "float", strconv.FormatFloat(f, 'g', -1, bits), strconv.FormatFloat(f, 'f', -1, bits)); err != nil {
+		return 0, err
+	}
+	if s.opts.roundFloats {
+		f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'f', s.opts.floatDecimals, 64), 64)
+	}
+
+	if bits == 32 {
+		f32 := float32(f)
+		if strconv.FormatFloat(float64(f32), 'g', -1, 32) != strconv.FormatFloat(f, 'g', -1, 64) {
+			s.warn("%q loses precision as float32, giving %v", rawVal, f32)
+		}
+		return float64(f32), nil
+	}
+	return f, nil
+}
+
+// sizeSuffixes are the size suffixes accepted under WithSizeSuffixes, the
+// longest first, with their multipliers.
+var sizeSuffixes = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40}, {"PiB", 1 << 50}, {"EiB", 1 << 60},
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40}, {"Pi", 1 << 50}, {"Ei", 1 << 60},
+	{"kB", 1e3}, {"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12}, {"PB", 1e15}, {"EB", 1e18},
+	{"k", 1e3}, {"K", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12}, {"P", 1e15}, {"E", 1e18},
+	{"B", 1},
+}
+
+// splitSizeSuffix splits the size suffix off num and returns the number
+// with its multiplier, which is 1 when num has no suffix.
+func splitSizeSuffix(num string) (string, int64) {
+	for _, size := range sizeSuffixes {
+		if strings.HasSuffix(num, size.suffix) && len(num) > len(size.suffix) {
+			return num[:len(num)-len(size.suffix)], size.multiplier
+		}
+	}
+	return num, 1
+}
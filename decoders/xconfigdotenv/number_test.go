@@ -0,0 +1,231 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumberLenient(t *testing.T) {
+	var config struct {
+		Port  int
+		Size  uint
+		Ratio float64
+		Ports []int
+	}
+
+	data := []byte("PORT=\" 42 \"\nSIZE=\"\t7\"\nRATIO=\"1.5 \"\nPORTS=\" 1 , 2 \"")
+	err := xconfigdotenv.New().Unmarshal(data, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 42, config.Port)
+	assert.Equal(t, uint(7), config.Size)
+	assert.Equal(t, 1.5, config.Ratio)
+	assert.Equal(t, []int{1, 2}, config.Ports)
+
+	// lenient only trims spaces
+	err = xconfigdotenv.New().Unmarshal([]byte("PORT='$42'"), &config)
+	assert.ErrorContains(t, err, `cannot parse "$42" as int`)
+}
+
+func TestNumberStrict(t *testing.T) {
+	decoder := xconfigdotenv.New(xconfigdotenv.WithNumberPolicy(xconfigdotenv.NumberStrict))
+
+	var config struct {
+		Port  int
+		Size  uint
+		Ratio float64
+	}
+
+	err := decoder.Unmarshal([]byte("PORT=-42\nSIZE=7\nRATIO=-1.5e-3"), &config)
+	assert.NoError(t, err)
+	assert.Equal(t, -42, config.Port)
+	assert.Equal(t, uint(7), config.Size)
+	assert.Equal(t, -1.5e-3, config.Ratio)
+
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{`PORT=" 42 "`, `cannot parse " 42 " as int: unexpected ' ' at offset 0 in strict mode`},
+		{"PORT='$42'", `cannot parse "$42" as int: unexpected '$' at offset 0 in strict mode`},
+		{"SIZE=1_000", `cannot parse "1_000" as uint: unexpected '_' at offset 1 in strict mode`},
+		{`RATIO="1,5"`, `cannot parse "1,5" as float: unexpected ',' at offset 1 in strict mode`},
+		{"RATIO=NaN", `cannot parse "NaN" as float: unexpected 'N' at offset 0 in strict mode`},
+		{"PORT=4-2", `cannot parse "4-2" as int: unexpected '-' at offset 1 in strict mode`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			err := decoder.Unmarshal([]byte(tt.data), &config)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestCoerceBoolNumeric(t *testing.T) {
+	type config struct {
+		Enabled int
+		Count   uint8
+		Ratio   float64
+	}
+
+	var off config
+	err := xconfigdotenv.New().Unmarshal([]byte("ENABLED=true"), &off)
+	assert.ErrorContains(t, err, `cannot parse "true" as int`)
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithCoerceBoolNumeric())
+
+	var on config
+	meta, err := decoder.UnmarshalWithMetadata([]byte("ENABLED=TRUE\nCOUNT=false"), &on)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, on.Enabled)
+	assert.Equal(t, uint8(0), on.Count)
+	assert.Equal(t, []xconfigdotenv.Warning{
+		{Key: "COUNT", Message: `coerced "false" to 0 for uint`},
+		{Key: "ENABLED", Message: `coerced "TRUE" to 1 for int`},
+	}, meta.Warnings)
+
+	// floats are not coerced
+	err = decoder.Unmarshal([]byte("RATIO=true"), &on)
+	assert.ErrorContains(t, err, `cannot parse "true" as float`)
+}
+
+func TestSizeSuffixes(t *testing.T) {
+	var config struct {
+		Buffer   int
+		Cache    uint64
+		Disk     int64
+		Limit    int
+		Plain    int
+		Negative int
+		Small    uint8
+	}
+
+	var off struct{ Buffer int }
+	err := xconfigdotenv.New().Unmarshal([]byte("BUFFER=64Ki"), &off)
+	assert.ErrorContains(t, err, `cannot parse "64Ki" as int`)
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithSizeSuffixes())
+	err = decoder.Unmarshal([]byte("BUFFER=64Ki\nCACHE=2GiB\nDISK=\"10 MB\"\nLIMIT=3k\nPLAIN=512\nNEGATIVE=-1M"), &config)
+	assert.NoError(t, err)
+	assert.Equal(t, 64*1024, config.Buffer)
+	assert.Equal(t, uint64(2<<30), config.Cache)
+	assert.Equal(t, int64(10_000_000), config.Disk)
+	assert.Equal(t, 3000, config.Limit)
+	assert.Equal(t, 512, config.Plain)
+	assert.Equal(t, -1_000_000, config.Negative)
+
+	err = decoder.Unmarshal([]byte("SMALL=1Ki"), &config)
+	assert.ErrorContains(t, err, `cannot parse "1Ki" as uint: strconv.ParseUint: parsing "1024": value out of range`)
+
+	err = decoder.Unmarshal([]byte("LIMIT=1.5G"), &config)
+	assert.ErrorContains(t, err, `cannot parse "1.5G" as int: invalid number before the size suffix`)
+
+	strict := xconfigdotenv.New(xconfigdotenv.WithSizeSuffixes(), xconfigdotenv.WithNumberPolicy(xconfigdotenv.NumberStrict))
+	assert.NoError(t, strict.Unmarshal([]byte("BUFFER=4Mi"), &config))
+	assert.Equal(t, 4<<20, config.Buffer)
+	err = strict.Unmarshal([]byte(`BUFFER="4 Mi"`), &config)
+	assert.ErrorContains(t, err, "in strict mode")
+}
+
+func TestFloatPrecision(t *testing.T) {
+	type config struct {
+		Price  float64
+		Rate   float32
+		Ratios []float64
+		Scale  float32
+		Small  float32
+	}
+
+	data := []byte("PRICE=9.999\nRATE=0.1234\nRATIOS=0.125,1.005\nSCALE=0.123456789\nSMALL=0.1")
+
+	// floats are not rounded by default, float32 losses are reported
+	var off config
+	meta, err := xconfigdotenv.New().UnmarshalWithMetadata(data, &off)
+	assert.NoError(t, err)
+	assert.Equal(t, config{Price: 9.999, Rate: 0.1234, Ratios: []float64{0.125, 1.005}, Scale: 0.12345679, Small: 0.1}, off)
+	assert.Equal(t, []xconfigdotenv.Warning{
+		{Key: "SCALE", Message: `"0.123456789" loses precision as float32, giving 0.12345679`},
+	}, meta.Warnings)
+
+	var on config
+	meta, err = xconfigdotenv.New(xconfigdotenv.WithFloatPrecision(2)).UnmarshalWithMetadata(data, &on)
+	assert.NoError(t, err)
+	// 1.005 is held as 1.00499999999999989...
+	assert.Equal(t, config{Price: 10, Rate: 0.12, Ratios: []float64{0.12, 1}, Scale: 0.12, Small: 0.1}, on)
+	assert.Empty(t, meta.Warnings)
+
+	// the range of float32 is still checked
+	err = xconfigdotenv.New().Unmarshal([]byte("RATE=1e39"), &on)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "RATE": cannot parse "1e39" as float: strconv.ParseFloat: parsing "1e39": value out of range`)
+}
+
+func TestNumberLocale(t *testing.T) {
+	type config struct {
+		Pi      float64
+		Price   float64
+		Small   float32
+		Exp     float64
+		Dotted  float64
+		Port    int
+		Factors []float64 `sep:";"`
+		Weights []float64
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithNumberLocale(xconfigdotenv.NumberLocaleComma))
+	var c config
+	data := []byte("PI=3,14\nPRICE=1.234.567,5\nSMALL=-0,25\nEXP=1,5e3\nDOTTED=3.14\nPORT=8080\nFACTORS=1,5;2,25\nWEIGHTS_0=0,5\nWEIGHTS_1=1,5")
+	assert.NoError(t, decoder.Unmarshal(data, &c))
+	assert.Equal(t, config{
+		Pi:      3.14,
+		Price:   1234567.5,
+		Small:   -0.25,
+		Exp:     1500,
+		Dotted:  3.14,
+		Port:    8080,
+		Factors: []float64{1.5, 2.25},
+		Weights: []float64{0.5, 1.5},
+	}, c)
+
+	out, err := decoder.Marshal(&c)
+	assert.NoError(t, err)
+	assert.Equal(t, "PI=3,14\nPRICE=1,2345675e+06\nSMALL=-0,25\nEXP=1500\nDOTTED=3,14\nPORT=8080\nFACTORS=\"1,5;2,25\"\nWEIGHTS_0=0,5\nWEIGHTS_1=1,5\n", string(out))
+
+	var decoded config
+	assert.NoError(t, decoder.Unmarshal(out, &decoded))
+	assert.Equal(t, c, decoded)
+
+	// the comma is no decimal separator by default
+	assert.ErrorContains(t, xconfigdotenv.New().Unmarshal([]byte("PI=3,14"), &c), `cannot parse "3,14" as float`)
+}
+
+func TestStrictTypes(t *testing.T) {
+	type config struct {
+		Port  int
+		Size  uint
+		Ratio float64
+		Small float32
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithStrictTypes())
+	var c config
+	assert.NoError(t, decoder.Unmarshal([]byte("PORT=-8080\nSIZE= 10 \nRATIO=1.5e+06\nSMALL=0.25"), &c))
+	assert.Equal(t, config{Port: -8080, Size: 10, Ratio: 1.5e6, Small: 0.25}, c)
+	assert.NoError(t, decoder.Unmarshal([]byte("RATIO=1500000"), &c))
+
+	for data, want := range map[string]string{
+		"PORT=007":          `key "PORT": cannot parse "007" as int in strict types mode: the value is written "7"`,
+		"PORT=+5":           `key "PORT": cannot parse "+5" as int in strict types mode: the value is written "5"`,
+		"SIZE=0x10":         `key "SIZE": cannot parse "0x10" as uint: strconv.ParseUint: parsing "0x10": invalid syntax`,
+		"RATIO=1.0":         `key "RATIO": cannot parse "1.0" as float in strict types mode: the value is written "1"`,
+		"RATIO=1.5e6":       `key "RATIO": cannot parse "1.5e6" as float in strict types mode: the value is written "1.5e+06"`,
+		"SMALL=0.123456789": `key "SMALL": cannot parse "0.123456789" as float in strict types mode: the value is written "0.12345679"`,
+	} {
+		assert.ErrorContains(t, decoder.Unmarshal([]byte(data), &c), want, data)
+	}
+
+	// sloppy values pass by default
+	assert.NoError(t, xconfigdotenv.New().Unmarshal([]byte("PORT=007\nRATIO=1.0"), &c))
+	assert.Equal(t, 7, c.Port)
+}
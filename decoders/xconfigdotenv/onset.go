@@ -0,0 +1,26 @@
+package xconfigdotenv
+
+import "reflect"
+
+// reportSet calls the callback of WithOnSetCallback with the field at path
+// and its value when the assignment giving err succeeded, and returns err.
+func (s *decodeState) reportSet(err error, path string, value reflect.Value) error {
+	if err == nil && s.opts.onSet != nil {
+		s.opts.onSet(path, value)
+	}
+	return err
+}
+
+// reportEntry works like reportSet for the entry mapKey of the map mapVal
+// at path.
+func (s *decodeState) reportEntry(err error, mapVal reflect.Value, path, mapKey string) error {
+	if err != nil || s.opts.onSet == nil {
+		return err
+	}
+	key, err := mapKeyValue(mapVal.Type().Key(), mapKey)
+	if err != nil {
+		return err
+	}
+	s.opts.onSet(path+"["+mapKey+"]", mapVal.MapIndex(key))
+	return nil
+}
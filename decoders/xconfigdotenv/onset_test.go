@@ -0,0 +1,57 @@
+package xconfigdotenv_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnSetCallback(t *testing.T) {
+	var config struct {
+		Name  string
+		Port  int `env:"PORT,WEB_PORT"`
+		Tags  map[string]struct{}
+		Hosts []struct{ Addr string }
+		Meta  map[string]any
+		DB    *struct {
+			Host string
+			Pool int `default:"4"`
+		}
+		Labels map[string]string
+	}
+
+	var calls []string
+	decoder := xconfigdotenv.New(
+		xconfigdotenv.WithOnSetCallback(func(fieldPath string, value reflect.Value) {
+			calls = append(calls, fmt.Sprintf("%s=%v", fieldPath, value.Interface()))
+		}),
+		xconfigdotenv.WithKeyMap(map[string]string{"TEAM": "Labels.team"}),
+	)
+	data := []byte(`NAME=app
+PORT=80
+WEB_PORT=8080
+TAGS=a
+HOSTS_1_ADDR=h1
+META_build_id=7
+DB_HOST=db
+TEAM=core
+BAD=x`)
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+	assert.Equal(t, []string{
+		"DB.Host=db",
+		"Hosts[1].Addr=h1",
+		"Meta[build_id]=7",
+		"Name=app",
+		"Port=80",
+		"Tags=map[a:{}]",
+		"Labels[team]=core",
+	}, calls)
+
+	// failed assignments are not reported
+	calls = nil
+	assert.Error(t, decoder.Unmarshal([]byte("PORT=x"), &config))
+	assert.Empty(t, calls)
+}
@@ -0,0 +1,907 @@
+package xconfigdotenv
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Option configures the Decoder.
+type Option func(*options)
+
+type options struct {
+	// nilStructPolicy controls pointer substructs which no key matched.
+	nilStructPolicy NilStructPolicy
+	// caseSensitive disables the case folding of names.
+	caseSensitive bool
+	// aliasCaseFold keeps the case folding of aliases when caseSensitive is set.
+	aliasCaseFold bool
+	// fieldMatcher replaces the built-in field matching.
+	fieldMatcher FieldMatcher
+	// unknownKey is called for the keys which match no field.
+	unknownKey func(key, value string) error
+	// ambiguityPolicy decides between several fields matching a key.
+	ambiguityPolicy AmbiguityPolicy
+	// matchMode decides whether fields are also matched by index.
+	matchMode MatchMode
+	// duplicateKeyPolicy decides about keys defined more than once.
+	duplicateKeyPolicy DuplicateKeyPolicy
+	// overwritePolicy decides whether keys overwrite the values held beforehand.
+	overwritePolicy OverwritePolicy
+	// parser reads the keys and values of the input, godotenv when nil.
+	parser Parser
+	// clock gives the current time of relative time defaults, time.Now when nil.
+	clock func() time.Time
+	// ignorePatterns are the prefixes and globs of the keys to drop.
+	ignorePatterns []string
+	// concurrentMinKeys is the number of keys from which the top-level
+	// fields are decoded concurrently, 0 when they never are.
+	concurrentMinKeys int
+	// eagerSliceSizing allocates the slices filled by index at their final size.
+	eagerSliceSizing bool
+	// internStrings deduplicates the string values assigned.
+	internStrings bool
+	// lineContinuations joins the lines continued by a trailing backslash.
+	lineContinuations bool
+	// reset zeroes the containers and pointers before filling them.
+	reset bool
+	// denyUnexported leaves the unexported fields alone, see WithAllowUnexported.
+	denyUnexported bool
+	// globAllowEmpty lets UnmarshalGlob match no file.
+	globAllowEmpty bool
+	// assignmentOrder resolves the slices given both as a single value and by index.
+	assignmentOrder AssignmentOrder
+	// trailingTrim is what is trimmed off the files of `env:",file"`.
+	trailingTrim TrailingTrim
+	// beforeEachKey rewrites or drops the parsed keys, see WithBeforeEachKey.
+	beforeEachKey func(key, value string) (string, string, bool)
+	// onSet is called after every assignment, see WithOnSetCallback.
+	onSet func(fieldPath string, value reflect.Value)
+	// postProcessors are run after the assignments of values of their type,
+	// see WithValuePostProcessors.
+	postProcessors map[reflect.Type]PostProcessor
+	// onMissingRequired provides the values of missing required fields, see
+	// WithOnMissingRequired.
+	onMissingRequired func(fieldPath string) (string, bool)
+	// keyMap gives the field paths of keys, see WithKeyMap, and foldedKeyMap
+	// the same by upper-cased key.
+	keyMap       map[string]string
+	foldedKeyMap map[string]string
+	// deprecatedKeys gives the notes of deprecated keys, see
+	// WithDeprecatedKeys, and foldedDeprecatedKeys the same by upper-cased key.
+	deprecatedKeys       map[string]string
+	foldedDeprecatedKeys map[string]string
+	// zeroEmptyStrings sets scalars to zero for empty values.
+	zeroEmptyStrings bool
+	// coerceBoolNumeric accepts true and false as 1 and 0 for integers.
+	coerceBoolNumeric bool
+	// boolTokens are the case-sensitive tokens of WithBoolTokens, and
+	// foldedBoolTokens the case-insensitive ones, lower-cased.
+	boolTokens       map[string]bool
+	foldedBoolTokens map[string]bool
+	// roundFloats rounds floats to floatDecimals decimal places.
+	roundFloats   bool
+	floatDecimals int
+	// sizeSuffixes accepts size suffixes such as Ki and M for integers.
+	sizeSuffixes bool
+	// numberPolicy controls how forgiving the parsing of numbers is.
+	numberPolicy NumberPolicy
+	// resolveAddrs looks up the host names of net.TCPAddr and net.UDPAddr values.
+	resolveAddrs bool
+	// strictTypes rejects the numbers which do not read back as written.
+	strictTypes bool
+	// numberLocale gives the decimal separator of floats.
+	numberLocale NumberLocale
+	// allowPartial keeps decoding the other keys when a key fails.
+	allowPartial bool
+	// maxValueLength is the maximum length of a value in bytes, 0 for no limit.
+	maxValueLength int
+	// preprocessors are the preprocessors added with WithValuePreprocessor, by name.
+	preprocessors map[string]Preprocessor
+	// caseStyles are the key styles split into words besides snake case.
+	caseStyles []CaseStyle
+	// keyCase is the case of the keys written by Marshal.
+	keyCase KeyCase
+	// secretResolvers are the resolvers of secret URIs, by scheme.
+	secretResolvers map[string]SecretResolver
+	// tagNames are the keys of the struct tags read by the decoder.
+	tagNames TagNames
+	// structTagPriority orders the sources of field names, nil when they are equal.
+	structTagPriority []MatchSource
+}
+
+// TagNames are the keys of the struct tags read by the decoder, see WithTagNames.
+type TagNames struct {
+	// Env is the key of the tag listing the names and options of a field, "env" by default.
+	Env string
+	// Default is the key of the tag giving the default value of a field, "default" by default.
+	Default string
+	// Format is the key of the tag giving the format of a value, "format" by default.
+	Format string
+	// Sep is the key of the tag giving the separator of slice elements, "sep" by default.
+	Sep string
+	// Pre is the key of the tag naming the preprocessors of a value, "pre" by default.
+	Pre string
+	// Secret is the key of the tag marking a field as secret, "secret" by default.
+	Secret string
+	// Redact is the key of the tag marking a field as redacted, "redact" by default.
+	Redact string
+	// SortBy is the key of the tag naming the field a slice is sorted by, "sortby" by default.
+	SortBy string
+	// Deprecated is the key of the tag marking a field as deprecated, "deprecated" by default.
+	Deprecated string
+	// MaxLen is the key of the tag giving the maximum length of a slice or map, "maxlen" by default.
+	MaxLen string
+	// Required is the key of the tag marking a field the input must set, "required" by default.
+	Required string
+	// TimeFormat is the key of the tag giving the layout of time values, "timeformat" by default.
+	TimeFormat string
+	// ValueType is the key of the tag giving the type of the values of a sync.Map, "valuetype" by default.
+	ValueType string
+}
+
+var defaultTagNames = TagNames{
+	Env:        envTag,
+	Default:    defaultTag,
+	Format:     formatTag,
+	Sep:        sepTag,
+	Pre:        preTag,
+	Secret:     secretTag,
+	Redact:     redactTag,
+	SortBy:     sortByTag,
+	Deprecated: deprecatedTag,
+	MaxLen:     maxLenTag,
+	Required:   requiredTag,
+	TimeFormat: timeFormatTag,
+	ValueType:  valueTypeTag,
+}
+
+// sourcePriority returns the priority of the names from source, lower is
+// higher, and whether such names are matched at all.
+func (o *options) sourcePriority(source MatchSource) (int, bool) {
+	if o.structTagPriority == nil {
+		return 0, true
+	}
+	i := slices.Index(o.structTagPriority, source)
+	return i, i >= 0
+}
+
+// NilStructPolicy defines what Unmarshal does with a pointer substruct
+// which is still nil because no key matched it.
+type NilStructPolicy int
+
+const (
+	// NilStructKeep leaves unmatched pointer substructs nil. It is the default.
+	NilStructKeep NilStructPolicy = iota
+	// NilStructAllocate allocates unmatched pointer substructs whose type has
+	// at least one field with a `default` tag or a `required` one (directly
+	// or in a nested struct), applies those defaults and checks the required
+	// fields. Other substructs stay nil.
+	NilStructAllocate
+)
+
+// AmbiguityPolicy defines what happens when several fields of a struct match
+// the same key prefix, e.g. a field named like the type of another field.
+type AmbiguityPolicy int
+
+const (
+	// AmbiguityError fails with an ErrAmbiguous error. It is the default.
+	AmbiguityError AmbiguityPolicy = iota
+	// AmbiguityFirst deterministically picks, among the fields matching the
+	// longest prefix of the key, the one with the lowest field index, i.e.
+	// the first one in declaration order.
+	AmbiguityFirst
+	// AmbiguityPanic makes Prepare panic with an ErrAmbiguous error for the
+	// ambiguities it detects up-front. Unmarshal never panics: it fails with
+	// an ErrAmbiguous error, as under AmbiguityError.
+	AmbiguityPanic
+)
+
+// NumberPolicy defines how forgiving the parsing of integer and float
+// values is.
+type NumberPolicy int
+
+const (
+	// NumberLenient trims the spaces around a number before parsing it, so
+	// " 42 " gives 42. It is the default.
+	NumberLenient NumberPolicy = iota
+	// NumberStrict rejects any character other than digits, a leading sign
+	// and, for floats, a decimal point and an exponent: " 42 ", "$42",
+	// "1,000" and "1_000" are errors, as are the Inf and NaN floats.
+	NumberStrict
+)
+
+// NumberLocale defines the decimal separator of float values.
+type NumberLocale int
+
+const (
+	// NumberLocalePoint reads the point as the decimal separator, as in 3.14.
+	// It is the default.
+	NumberLocalePoint NumberLocale = iota
+	// NumberLocaleComma reads the comma as the decimal separator, so 3,14
+	// gives 3.14, and the points separating groups of three digits of the
+	// integer part as thousands separators, so 1.234,5 gives 1234.5. A point
+	// elsewhere is still the decimal separator, so 3.14 gives 3.14 as well.
+	NumberLocaleComma
+)
+
+// WithNilStructPolicy sets the policy for pointer substructs which no key matched.
+//
+// Regardless of the policy, a pointer substruct allocated because a key
+// matched one of its fields always gets its `default` tags applied before
+// the key values are assigned.
+func WithNilStructPolicy(policy NilStructPolicy) Option {
+	return func(o *options) {
+		o.nilStructPolicy = policy
+	}
+}
+
+// WithCaseSensitive makes keys match field names case-sensitively: KEY_NAME
+// matches a field named KeyName only through an `env:"KEY_NAME"` tag.
+// Underscores are still ignored.
+func WithCaseSensitive() Option {
+	return func(o *options) {
+		o.caseSensitive = true
+	}
+}
+
+// WithAliasCaseFold makes the aliases of the `env` tag (every name but the
+// first one) match case-insensitively even under WithCaseSensitive, so only
+// primary names are case-sensitive. Without WithCaseSensitive it is a no-op.
+//
+// When several names of the same field are present in the input, the key
+// matched through the primary name wins, then the aliases in declaration
+// order, whatever the order of the keys.
+func WithAliasCaseFold() Option {
+	return func(o *options) {
+		o.aliasCaseFold = true
+	}
+}
+
+// WithFieldMatcher replaces the built-in matching of fields by names (the
+// `env` tag, the field name and the type name, see WithCaseSensitive) with
+// matcher. See FieldMatcher for the contract.
+func WithFieldMatcher(matcher FieldMatcher) Option {
+	return func(o *options) {
+		o.fieldMatcher = matcher
+	}
+}
+
+// WithUnknownKeyCallback sets a callback called, in key order, for every key
+// which matches no field, e.g. to keep unknown keys in a side map. An error
+// returned by the callback aborts Unmarshal.
+func WithUnknownKeyCallback(callback func(key, value string) error) Option {
+	return func(o *options) {
+		o.unknownKey = callback
+	}
+}
+
+// WithAmbiguityPolicy sets the policy for keys matching several fields.
+func WithAmbiguityPolicy(policy AmbiguityPolicy) Option {
+	return func(o *options) {
+		o.ambiguityPolicy = policy
+	}
+}
+
+// WithOverwritePolicy sets the policy for the keys giving a field which
+// already holds a value when Unmarshal is called, e.g. from an earlier
+// source decoded into the same struct. The policy applies to the fields
+// given a single value; the entries of maps and the elements of indexed
+// slices are always set.
+func WithOverwritePolicy(policy OverwritePolicy) Option {
+	return func(o *options) {
+		o.overwritePolicy = policy
+	}
+}
+
+// WithMatchMode sets how keys address the fields of a struct. The mode has
+// no effect with WithFieldMatcher.
+func WithMatchMode(mode MatchMode) Option {
+	return func(o *options) {
+		o.matchMode = mode
+	}
+}
+
+// WithDuplicateKeyPolicy sets the policy for keys defined more than once in
+// the input, e.g. a key redefined further down a long file by mistake. Keys
+// are compared as they are written, so PORT and port are distinct.
+func WithDuplicateKeyPolicy(policy DuplicateKeyPolicy) Option {
+	return func(o *options) {
+		o.duplicateKeyPolicy = policy
+	}
+}
+
+// WithStructTagPriority orders the sources of the names a field can be
+// matched by: the `env` tag (MatchTag), the Go name of the field (MatchFieldName)
+// and the name of its type (MatchTypeName). Names from a source missing from
+// sources are not matched at all.
+//
+// The priority applies within a prefix length: the field matching the most
+// segments of a key always wins, and only among the fields matching the
+// same number of segments does the one matched through the earliest source
+// win, e.g. with WithStructTagPriority(MatchTag, MatchTypeName) a field
+// tagged `env:"CACHE"` takes CACHE_SIZE from a field of type Cache without
+// an ambiguity error. Fields matched through the same source are left to the
+// AmbiguityPolicy.
+//
+// By default all the sources are enabled and equal. The option has no effect
+// with WithFieldMatcher.
+func WithStructTagPriority(sources ...MatchSource) Option {
+	return func(o *options) {
+		o.structTagPriority = append([]MatchSource{}, sources...)
+	}
+}
+
+// WithNumberPolicy sets the policy for parsing integer and float values,
+// whether from keys or `default` tags.
+func WithNumberPolicy(policy NumberPolicy) Option {
+	return func(o *options) {
+		o.numberPolicy = policy
+	}
+}
+
+// WithStrictTypes rejects the integer and float values which parse but do
+// not read back as written once formatted again, for configs where a
+// sloppy value should not pass: 007, +5 and 1.0 are errors, as they read
+// back as 7, 5 and 1, and so do floats which the field cannot hold
+// exactly, such as 0.123456789 for a float32. Floats may be written in
+// plain or exponent notation as Go formats them, 1500000 or 1.5e+06, but
+// not 1.5e6. The check applies to the
+// number once the spaces trimmed by NumberLenient, the size suffixes of
+// WithSizeSuffixes and the decimal commas of NumberLocaleComma are read,
+// before the rounding of WithFloatPrecision. Durations, complex numbers and
+// the types with a converter are not checked. It is off by default.
+func WithStrictTypes() Option {
+	return func(o *options) {
+		o.strictTypes = true
+	}
+}
+
+// WithResolveAddrs parses net.TCPAddr and net.UDPAddr values, and the
+// pointers and slices of them, with net.ResolveTCPAddr and
+// net.ResolveUDPAddr, which look up host names such as localhost:80.
+// By default these values take an IP address and a port only, as in
+// 1.2.3.4:80 or [::1]:80, so decoding does no network I/O. It takes
+// precedence over a converter registered for either type.
+func WithResolveAddrs() Option {
+	return func(o *options) {
+		o.resolveAddrs = true
+	}
+}
+
+// WithNumberLocale sets the locale of float values, whether from keys or
+// `default` tags. Marshal writes floats with the decimal separator of the
+// locale. Under NumberLocaleComma, a slice of floats given as a single
+// value, FACTORS=1,5;2,25, needs a separator other than the comma, such
+// as `sep:";"`, as splitting on commas comes first; otherwise its elements
+// are given under indexed keys, FACTORS_0=1,5.
+func WithNumberLocale(locale NumberLocale) Option {
+	return func(o *options) {
+		o.numberLocale = locale
+	}
+}
+
+// WithTagNames changes the keys of the struct tags read by the decoder, e.g.
+// to keep the `default` tags of a struct shared with another library for
+// that library:
+//
+//	xconfigdotenv.WithTagNames(xconfigdotenv.TagNames{Default: "envDefault"})
+//
+// Empty names keep their default key.
+func WithTagNames(names TagNames) Option {
+	return func(o *options) {
+		if names.Env != "" {
+			o.tagNames.Env = names.Env
+		}
+		if names.Default != "" {
+			o.tagNames.Default = names.Default
+		}
+		if names.Format != "" {
+			o.tagNames.Format = names.Format
+		}
+		if names.Sep != "" {
+			o.tagNames.Sep = names.Sep
+		}
+		if names.Pre != "" {
+			o.tagNames.Pre = names.Pre
+		}
+		if names.Secret != "" {
+			o.tagNames.Secret = names.Secret
+		}
+		if names.Redact != "" {
+			o.tagNames.Redact = names.Redact
+		}
+		if names.SortBy != "" {
+			o.tagNames.SortBy = names.SortBy
+		}
+		if names.Deprecated != "" {
+			o.tagNames.Deprecated = names.Deprecated
+		}
+		if names.MaxLen != "" {
+			o.tagNames.MaxLen = names.MaxLen
+		}
+		if names.Required != "" {
+			o.tagNames.Required = names.Required
+		}
+		if names.TimeFormat != "" {
+			o.tagNames.TimeFormat = names.TimeFormat
+		}
+		if names.ValueType != "" {
+			o.tagNames.ValueType = names.ValueType
+		}
+	}
+}
+
+// WithCoerceBoolNumeric makes integer fields accept the boolean literals
+// true and false, in any case, as 1 and 0, for sources which stringify
+// booleans inconsistently. Every coercion is reported in the Warnings of
+// UnmarshalWithMetadata. It is off by default, as it can mask errors.
+func WithCoerceBoolNumeric() Option {
+	return func(o *options) {
+		o.coerceBoolNumeric = true
+	}
+}
+
+// WithBoolTokens adds tokens of boolean values, which boolean fields accept
+// before the literals of strconv.ParseBool (1, t, TRUE, true, True, 0, f, ...),
+// e.g. for yes/no switches or localized ones:
+//
+//	xconfigdotenv.WithBoolTokens(xconfigdotenv.BoolTokens{
+//		True:  []string{"yes", "on", "sí"},
+//		False: []string{"no", "off"},
+//	})
+//
+// Tokens are compared ignoring their case, so YES and Sí match too, unless
+// CaseSensitive is set; this is independent of WithCaseSensitive, which is
+// about keys. Many sets of tokens are merged, a token listed again taking
+// its latest meaning.
+func WithBoolTokens(tokens BoolTokens) Option {
+	return func(o *options) {
+		add := func(token string, b bool) {
+			if tokens.CaseSensitive {
+				if o.boolTokens == nil {
+					o.boolTokens = make(map[string]bool)
+				}
+				o.boolTokens[token] = b
+				return
+			}
+			if o.foldedBoolTokens == nil {
+				o.foldedBoolTokens = make(map[string]bool)
+			}
+			o.foldedBoolTokens[strings.ToLower(token)] = b
+		}
+		for _, token := range tokens.True {
+			add(token, true)
+		}
+		for _, token := range tokens.False {
+			add(token, false)
+		}
+	}
+}
+
+// WithFloatPrecision rounds the values of float fields, including slice
+// elements and map values, to the given number of decimal places, e.g. 2
+// for amounts: PRICE=9.999 gives 10 and RATE=0.1234 gives 0.12. The value
+// is rounded as a decimal, before any float32 conversion. A negative
+// number of places is taken as 0. Floats are not rounded by default.
+func WithFloatPrecision(decimals int) Option {
+	return func(o *options) {
+		o.roundFloats = true
+		o.floatDecimals = max(decimals, 0)
+	}
+}
+
+// WithMaxValueLength limits the length of the values to n bytes, to guard
+// against huge values in untrusted input. A longer value, whether its key
+// matches a field or not, fails Unmarshal before any conversion. There is
+// no limit by default or when n is 0 or less.
+func WithMaxValueLength(n int) Option {
+	return func(o *options) {
+		o.maxValueLength = n
+	}
+}
+
+// WithSizeSuffixes makes integer fields accept a size suffix, optionally
+// preceded by spaces unless NumberStrict is set: the SI suffixes k (or K),
+// M, G, T, P and E are powers of 1000, the IEC suffixes Ki, Mi, Gi, Ti, Pi
+// and Ei powers of 1024, and all of them may be followed by B (MB, GiB). A
+// lone B is a multiplier of 1. So "64Ki" gives 65536 and "1.5G" is still an
+// error, as the number before the suffix must be an integer.
+//
+// Detection is safe: a value with a suffix is never a valid plain integer,
+// so no value valid without the option changes meaning with it. The result
+// must fit the field type. It is off by default.
+func WithSizeSuffixes() Option {
+	return func(o *options) {
+		o.sizeSuffixes = true
+	}
+}
+
+// WithAllowPartial makes Unmarshal decode every key it can instead of
+// stopping at the first failing key, for best-effort loading: the fields of
+// the failing keys are left as they were, the others are set, and the
+// errors are returned together as a *PartialError. Errors of the
+// WithUnknownKeyCallback callback are collected too.
+func WithAllowPartial() Option {
+	return func(o *options) {
+		o.allowPartial = true
+	}
+}
+
+// WithParser replaces godotenv for reading the keys and values of the
+// input, for the .env dialects it does not read, such as one keeping
+// comments as values. The keys and values returned then go through the
+// same matching and conversions. WithLineContinuations and
+// WithDuplicateKeyPolicy still process the input before parser, following
+// the godotenv syntax, and Marshal and MarshalMerge still write it. A nil
+// parser keeps godotenv.
+func WithParser(parser Parser) Option {
+	return func(o *options) {
+		o.parser = parser
+	}
+}
+
+// WithValuePreprocessor adds the preprocessor fn under name, for the fields
+// selecting it with the `pre` tag, replacing a built-in preprocessor of the
+// same name. See Preprocessor.
+func WithValuePreprocessor(name string, fn Preprocessor) Option {
+	return func(o *options) {
+		if o.preprocessors == nil {
+			o.preprocessors = make(map[string]Preprocessor)
+		}
+		o.preprocessors[name] = fn
+	}
+}
+
+// WithCaseStyle makes keys match fields whatever the convention they are
+// written in, splitting them into words on the separators and case
+// boundaries of the given styles: with CaseDot and CaseCamel,
+// database.url, databaseUrl and DATABASE_URL all give the words DATABASE
+// and URL, matching a field DatabaseURL or the field URL of a struct field
+// Database. By default keys are only split on '_' (CaseSnake), which is
+// always enabled.
+//
+// The words are matched like the segments of snake case keys, so map keys
+// and inline keys are rebuilt from them joined with '_': LABELS_teamName
+// gives LABELS["team_Name"] under CaseCamel.
+func WithCaseStyle(styles ...CaseStyle) Option {
+	return func(o *options) {
+		o.caseStyles = append([]CaseStyle{}, styles...)
+	}
+}
+
+// WithKeyCaseOutput sets the case of the keys Marshal and MarshalMerge
+// write for the names of fields, KeyCaseScreamingSnake by default. The
+// names given by `env` tags and prefixes, and map keys, are written as
+// they are. Unmarshal reads the keys of KeyCaseSnake back unless
+// WithCaseSensitive is set, and those of KeyCaseKebab with
+// WithCaseStyle(CaseKebab) and a Parser accepting '-' in keys.
+func WithKeyCaseOutput(keyCase KeyCase) Option {
+	return func(o *options) {
+		o.keyCase = keyCase
+	}
+}
+
+// WithSecretResolver makes the values which are URIs of the scheme, such as
+// secret://vault/db/password for the scheme "secret", be replaced by what
+// resolver returns for them. Several schemes can be set, each with its
+// resolver; without any, values are never resolved. See SecretResolver.
+func WithSecretResolver(scheme string, resolver SecretResolver) Option {
+	return func(o *options) {
+		if o.secretResolvers == nil {
+			o.secretResolvers = make(map[string]SecretResolver)
+		}
+		o.secretResolvers[scheme] = resolver
+	}
+}
+
+// WithZeroEmptyStrings makes an empty value set a number, boolean or
+// duration field (including time.Duration and the other named types of
+// those kinds, such as slog.Level) to its zero value instead of failing, for
+// configs where PORT= means "no value". Other fields are unchanged: empty
+// strings stay empty and an empty slice value gives an empty slice. A
+// pointer field gets a pointer to the zero value. It is off by default.
+func WithZeroEmptyStrings() Option {
+	return func(o *options) {
+		o.zeroEmptyStrings = true
+	}
+}
+
+// WithClock sets the clock giving the current time of relative defaults of
+// time.Time fields, such as `default:"now+24h"`, in place of time.Now. It is
+// meant for tests, which get deterministic expiry and retention defaults.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.clock = now
+	}
+}
+
+// WithGlobAllowEmpty lets UnmarshalGlob decode nothing when no file
+// matches its pattern, e.g. for an optional drop-in directory, instead of
+// failing with ErrNoGlobMatch.
+func WithGlobAllowEmpty() Option {
+	return func(o *options) {
+		o.globAllowEmpty = true
+	}
+}
+
+// WithAssignmentOrder sets which keys give a slice field set both as a
+// single value and by index, such as X=a,c and X_0=b: the keys in key
+// order by default, which depends on the case of the keys, the indexed
+// keys with AssignmentPreferIndexed, or the single value with
+// AssignmentPreferScalar, whatever their order. The keys dropped are
+// reported in the Warnings of UnmarshalWithMetadata. Sets and maps, whose
+// single value and subkeys both give entries, merge them by entry.
+func WithAssignmentOrder(order AssignmentOrder) Option {
+	return func(o *options) {
+		o.assignmentOrder = order
+	}
+}
+
+// WithTrailingNewlineTrim sets what is trimmed off the end of the contents
+// of the files read for fields tagged `env:",file"`: a single trailing
+// newline by default, all trailing white space with TrailingTrimSpace,
+// nothing with TrailingTrimNone. Values given in the input itself are never trimmed.
+func WithTrailingNewlineTrim(trim TrailingTrim) Option {
+	return func(o *options) {
+		o.trailingTrim = trim
+	}
+}
+
+// WithBeforeEachKey calls hook for every key of the input, in key order,
+// with its value, before the key is split and matched. The key and value
+// it returns replace them, and the entry is dropped when it returns false,
+// so keys may be renamed or routed on their values:
+//
+//	xconfigdotenv.WithBeforeEachKey(func(key, value string) (string, string, bool) {
+//		if strings.HasPrefix(value, "postgres://") {
+//			return "DB_URL", value, true
+//		}
+//		return key, value, !strings.HasPrefix(key, "TMP_")
+//	})
+//
+// The hook sees the keys once the input is parsed, so after the line
+// continuations, the DuplicateKeyPolicy applied to the input and the
+// variable expansion and merging of the parser, and before WithIgnorePrefix,
+// the key map and the matching; the value it returns then goes through the
+// preprocessors and the secret resolvers as usual. Two keys the hook gives
+// the same name are handled by the DuplicateKeyPolicy, the last one in key
+// order winning.
+func WithBeforeEachKey(hook func(key, value string) (newKey, newValue string, keep bool)) Option {
+	return func(o *options) {
+		o.beforeEachKey = hook
+	}
+}
+
+// WithIgnorePrefix drops the keys matching any of patterns before they are
+// matched, so the variables of the environment unrelated to the config
+// (PATH, HOME, LS_COLORS) neither reach the fields nor the unknown key
+// callback. A pattern with one of the characters *?[ is a glob matched
+// against the whole key, as by path.Match, any other pattern a key prefix;
+// both compare names as the options do. Dropped keys are counted as ignored
+// in the Metrics and left out of Metadata.Flat. It may be given many times.
+func WithIgnorePrefix(patterns ...string) Option {
+	return func(o *options) {
+		o.ignorePatterns = append(o.ignorePatterns, patterns...)
+	}
+}
+
+// defaultConcurrentMinKeys is the threshold of WithConcurrentDecode when it
+// is given no positive one.
+const defaultConcurrentMinKeys = 512
+
+// WithConcurrentDecode decodes the top-level fields of the struct on their own
+// goroutines when the input has at least minKeys keys, 512 when minKeys is
+// not positive. The keys are partitioned by the top-level field they match,
+// so every goroutine writes a distinct field; the keys matching no field, or
+// several, and the keys of the key map are decoded afterwards as usual. The result, errors and metadata
+// are the same as the sequential decoding gives, except that fail-fast
+// decoding, which still reports the error of the first failing key, may
+// have assigned other values by then.
+//
+// The unknown key callback, the field matcher, the preprocessors and the
+// secret resolvers may then be called concurrently. It only pays off for
+// large configs spread across many top-level fields.
+func WithConcurrentDecode(minKeys int) Option {
+	return func(o *options) {
+		if minKeys <= 0 {
+			minKeys = defaultConcurrentMinKeys
+		}
+		o.concurrentMinKeys = minKeys
+	}
+}
+
+// WithEagerSliceSizing pre-scans the keys for the largest index of every
+// slice filled by indexed keys, HOSTS_0 to HOSTS_499, and allocates the
+// slice once at that size when its first key is decoded, instead of growing
+// it key after key. It speeds up the configs with many indexed keys. The
+// slices end up with the same elements either way. It is off by default.
+func WithEagerSliceSizing() Option {
+	return func(o *options) {
+		o.eagerSliceSizing = true
+	}
+}
+
+// WithStringInterner deduplicates the string values assigned to string
+// fields, slice elements and map values: identical values share a single
+// copy, including across the Unmarshal calls of every Decoder, which cuts
+// the memory held by large configs repeating values (LOG_LEVEL=info in many
+// sections) and kept across reloads. Interned strings are still freed once
+// no config refers to them. It is off by default, as interning costs a
+// lookup per value.
+func WithStringInterner() Option {
+	return func(o *options) {
+		o.internStrings = true
+	}
+}
+
+// WithKeyMap gives the field path of keys, for teams keeping a central
+// mapping of keys to fields, e.g. generated from a schema: a listed key goes
+// to its field path, bypassing the name matching entirely, and the other
+// keys are matched as usual. Keys are compared case-insensitively unless
+// WithCaseSensitive is set. Many key maps are merged, later keys winning.
+//
+// A field path is a dotted list of segments resolved from the struct given
+// to Unmarshal: a struct segment is the Go name of a field, pointers are
+// allocated on the way, a slice segment is an index, growing the slice as
+// needed, and a map segment is a key, the last one of a map of scalars
+// giving the key of the value:
+//
+//	xconfigdotenv.WithKeyMap(map[string]string{
+//		"PGHOST":        "DB.Host",
+//		"UPSTREAM_1":    "Proxy.Upstreams.1",
+//		"TEAM_LABEL":    "Labels.team",
+//		"ROUTE_USER_TO": "Routes.user.Target",
+//	})
+func WithKeyMap(keys map[string]string) Option {
+	return func(o *options) {
+		if o.keyMap == nil {
+			o.keyMap = make(map[string]string, len(keys))
+			o.foldedKeyMap = make(map[string]string, len(keys))
+		}
+		for key, path := range keys {
+			o.keyMap[key] = path
+			o.foldedKeyMap[strings.ToUpper(key)] = path
+		}
+	}
+}
+
+// WithDeprecatedKeys reports the keys of the input which are deprecated
+// in the Warnings of UnmarshalWithMetadata, with the note given for the key,
+// typically its replacement:
+//
+//	xconfigdotenv.WithDeprecatedKeys(map[string]string{
+//		"DB_HOST": "use DB_URL instead",
+//	})
+//
+// The keys are matched as a whole, case-insensitively unless WithCaseSensitive
+// is set, and their values are still decoded. A field tagged
+// `deprecated:"use DB_URL instead"` is reported likewise for every key it
+// takes.
+func WithDeprecatedKeys(keys map[string]string) Option {
+	return func(o *options) {
+		if o.deprecatedKeys == nil {
+			o.deprecatedKeys = make(map[string]string, len(keys))
+			o.foldedDeprecatedKeys = make(map[string]string, len(keys))
+		}
+		for key, note := range keys {
+			o.deprecatedKeys[key] = note
+			o.foldedDeprecatedKeys[strings.ToUpper(key)] = note
+		}
+	}
+}
+
+// WithReset zeroes every slice, map and pointer field the input gives a value
+// to before filling it, so that decoding again into a populated struct, on a
+// config reload, leaves no stale elements or entries from the previous
+// input: with HOSTS_0, HOSTS_1 decoded before and only HOSTS_0 now, Hosts
+// holds a single element. A field is reset once per Unmarshal, when the
+// first key reaches it, and only the fields reached by a key are reset; the
+// others, including those a new input no longer mentions at all, keep their
+// values. A reset pointer to a struct is allocated again with its defaults.
+// Unmarshaler fields are left to their UnmarshalEnv method.
+func WithReset() Option {
+	return func(o *options) {
+		o.reset = true
+	}
+}
+
+// WithOnSetCallback calls callback after every assignment of a value from
+// the input, for auditing or for detecting the changes between reloads.
+// FieldPath is the dotted path of Go field names from the struct given to
+// Unmarshal, with the element or key in brackets for slices and maps
+// (Hosts[1].Addr, Labels[team]), and value the field, element or map entry
+// holding the new value. Sets, query maps and Unmarshaler fields are
+// reported once as a whole. A key which does not overwrite a field set
+// through a higher priority name is not reported, and neither are the
+// `default` tags.
+//
+// Callbacks fire as soon as each value is assigned, so before any
+// validation run once Unmarshal returns, such as the validate plugin of
+// xconfig. The value of a map entry is a copy, and under
+// WithConcurrentDecode the callback may be called concurrently.
+func WithOnSetCallback(callback func(fieldPath string, value reflect.Value)) Option {
+	return func(o *options) {
+		o.onSet = callback
+	}
+}
+
+// WithValuePostProcessors runs the post-processor of a type after every
+// value of that type is assigned, for the normalization or validation the
+// type calls for wherever it is used, rather than field by field. It runs
+// on the value the converter, or the built-in conversion, gives: a path
+// type is made absolute, whatever the fields holding it,
+//
+//	type Path string
+//
+//	xconfigdotenv.WithValuePostProcessors(map[reflect.Type]xconfigdotenv.PostProcessor{
+//		reflect.TypeFor[Path](): func(_ reflect.Type, v reflect.Value) error {
+//			abs, err := filepath.Abs(v.String())
+//			if err != nil {
+//				return err
+//			}
+//			v.SetString(abs)
+//			return nil
+//		},
+//	})
+//
+// The values of fields, slice elements and map values are post-processed
+// when decoded from text, and so are the `default` tags of the structs
+// allocated for pointer fields: a *Path field
+// gets its Path post-processed, and a []Path field each element. An error
+// fails the key as a parse error does. Post-processors may run
+// concurrently under WithConcurrentDecode.
+func WithValuePostProcessors(postProcessors map[reflect.Type]PostProcessor) Option {
+	return func(o *options) {
+		o.postProcessors = postProcessors
+	}
+}
+
+// WithLineContinuations joins an unquoted value ending with a backslash with
+// the next line, as shells do, for long values in hand-written files:
+//
+//	JAVA_OPTS=-Xms256m \
+//	    -Xmx1g
+//
+// gives "-Xms256m -Xmx1g": the backslash and the line break are dropped,
+// and so is the indentation of the next line, which may be continued in
+// turn. Quoted values, which may already span lines, and comments are read
+// as they are, and an even number of trailing backslashes does not
+// continue the value. It is off by default, as an unquoted value may end
+// with a backslash, such as the paths C:\dir\ written by Marshal.
+func WithLineContinuations() Option {
+	return func(o *options) {
+		o.lineContinuations = true
+	}
+}
+
+// WithOnMissingRequired registers callback to provide the value of a field
+// tagged `required` which the input does not set, e.g. by prompting for it
+// or fetching it from elsewhere. It is called with the dotted path of the
+// field, DB.Host, and when handled is true the value is decoded into the
+// field as if a key had given it; otherwise the field stays missing.
+//
+// The callback is called for every missing field, in field declaration
+// order, once the keys are decoded and before the missing fields which it
+// does not handle are reported together in a single ErrMissingRequired
+// error. A value failing to decode fails the run at once.
+func WithOnMissingRequired(callback func(fieldPath string) (value string, handled bool)) Option {
+	return func(o *options) {
+		o.onMissingRequired = callback
+	}
+}
+
+// WithAllowUnexported controls whether unexported fields are decoded, which
+// they are by default, writing them through unsafe. With
+// WithAllowUnexported(false), only the fields which reflection may set are
+// touched, as encoding/json does: the keys matching an unexported field only
+// are unknown keys, its `default` tag is ignored, and Marshal leaves it out.
+// The fields of an unexported embedded struct are not reached either, and
+// naming an unexported field by index under MatchIndex or through WithKeyMap
+// is an error.
+func WithAllowUnexported(allow bool) Option {
+	return func(o *options) {
+		o.denyUnexported = !allow
+	}
+}
@@ -0,0 +1,144 @@
+package xconfigdotenv_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type unknownKeysConfig struct {
+	Name string
+	DB   struct {
+		Host string
+	}
+}
+
+func TestWithUnknownKeyCallback(t *testing.T) {
+	unknown := map[string]string{}
+	decoder := xconfigdotenv.New(xconfigdotenv.WithUnknownKeyCallback(func(key, value string) error {
+		unknown[key] = value
+		return nil
+	}))
+
+	var config unknownKeysConfig
+	err := decoder.Unmarshal([]byte("NAME=app\nDB_HOST=db\nDB_USER=u\nVERSION=2"), &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, "db", config.DB.Host)
+	assert.Equal(t, map[string]string{"DB_USER": "u", "VERSION": "2"}, unknown)
+}
+
+func TestWithUnknownKeyCallbackError(t *testing.T) {
+	errUnknown := errors.New("unknown key")
+	decoder := xconfigdotenv.New(xconfigdotenv.WithUnknownKeyCallback(func(string, string) error {
+		return errUnknown
+	}))
+
+	var config unknownKeysConfig
+	err := decoder.Unmarshal([]byte("NAME=app\nVERSION=2"), &config)
+	assert.ErrorIs(t, err, errUnknown)
+	assert.ErrorContains(t, err, `key "VERSION"`)
+}
+
+func TestWithTagNames(t *testing.T) {
+	type database struct {
+		Host string `env:"ADDR" cfg:"HOST"`
+		Port int    `default:"1" envDefault:"8080"`
+	}
+
+	var config struct {
+		DB   *database
+		Key  string `format:"raw" envFormat:"multiline"`
+		Mode string `env:"MODE"`
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithTagNames(xconfigdotenv.TagNames{
+		Env:     "cfg",
+		Default: "envDefault",
+		Format:  "envFormat",
+	}))
+	err := decoder.Unmarshal([]byte("DB_HOST=db\nDB_ADDR=other\nKEY='a\\nb'\nMODE=dev"), &config)
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, config.DB) {
+		assert.Equal(t, "db", config.DB.Host)
+		assert.Equal(t, 8080, config.DB.Port)
+	}
+	assert.Equal(t, "a\nb", config.Key)
+	// without a cfg tag the field name is used
+	assert.Equal(t, "dev", config.Mode)
+
+	// empty names keep their default key
+	var partial struct {
+		DB *database
+	}
+	decoder = xconfigdotenv.New(xconfigdotenv.WithTagNames(xconfigdotenv.TagNames{Default: "envDefault"}))
+	assert.NoError(t, decoder.Unmarshal([]byte("DB_ADDR=db"), &partial))
+	if assert.NotNil(t, partial.DB) {
+		assert.Equal(t, "db", partial.DB.Host)
+		assert.Equal(t, 8080, partial.DB.Port)
+	}
+}
+
+func TestWithMaxValueLength(t *testing.T) {
+	var config struct {
+		Name string
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithMaxValueLength(4))
+	assert.NoError(t, decoder.Unmarshal([]byte("NAME=abcd"), &config))
+	assert.Equal(t, "abcd", config.Name)
+
+	err := decoder.Unmarshal([]byte("NAME=abcde"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "NAME": value of 5 bytes exceeds the maximum length of 4 bytes`)
+
+	// unknown keys are checked too
+	err = decoder.Unmarshal([]byte("OTHER=abcde"), &config)
+	assert.ErrorContains(t, err, "exceeds the maximum length")
+
+	var values map[string]string
+	err = decoder.Unmarshal([]byte("OTHER=abcde"), &values)
+	assert.ErrorContains(t, err, "exceeds the maximum length")
+
+	// no limit by default
+	long := strings.Repeat("x", 1<<16)
+	assert.NoError(t, xconfigdotenv.New().Unmarshal([]byte("NAME="+long), &config))
+	assert.Equal(t, long, config.Name)
+}
+
+func TestWithZeroEmptyStrings(t *testing.T) {
+	type config struct {
+		Port    int
+		Debug   bool
+		Ratio   float64
+		Timeout time.Duration
+		Retries *uint
+		Ports   []int
+		Name    string
+	}
+
+	data := []byte("PORT=\nDEBUG=\nRATIO=\nTIMEOUT=\nRETRIES=\nPORTS=1,,3\nNAME=")
+
+	var strict config
+	err := xconfigdotenv.New().Unmarshal(data, &strict)
+	assert.ErrorContains(t, err, `cannot parse "" as`)
+
+	cfg := config{Port: 80, Debug: true, Ratio: 1, Timeout: time.Second, Name: "app"}
+	err = xconfigdotenv.New(xconfigdotenv.WithZeroEmptyStrings()).Unmarshal(data, &cfg)
+	assert.NoError(t, err)
+
+	assert.Zero(t, cfg.Port)
+	assert.False(t, cfg.Debug)
+	assert.Zero(t, cfg.Ratio)
+	assert.Zero(t, cfg.Timeout)
+	if assert.NotNil(t, cfg.Retries) {
+		assert.Zero(t, *cfg.Retries)
+	}
+	assert.Equal(t, []int{1, 0, 3}, cfg.Ports)
+	assert.Empty(t, cfg.Name)
+}
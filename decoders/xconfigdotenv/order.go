@@ -0,0 +1,68 @@
+package xconfigdotenv
+
+import (
+	"reflect"
+	"strings"
+)
+
+// AssignmentOrder defines which keys give a slice field set both as a single
+// value and by index, such as X=a,c and X_0=b for a field X []string.
+type AssignmentOrder int
+
+const (
+	// AssignmentKeyOrder applies the keys in key order: the single value
+	// sets the slice, and the indexed keys then set their elements, giving
+	// [b c], unless the single value comes last, as x=a,c does, which gives
+	// [a c]. It is the default.
+	AssignmentKeyOrder AssignmentOrder = iota
+	// AssignmentPreferIndexed lets the indexed keys give the whole slice,
+	// the longest structural match: the single value is dropped, whatever
+	// the order of the keys, so the example gives [b].
+	AssignmentPreferIndexed
+	// AssignmentPreferScalar lets the single value give the whole slice:
+	// the indexed keys are dropped, whatever the order of the keys, so the
+	// example gives [a c].
+	AssignmentPreferScalar
+)
+
+// isIndexedSlice reports whether t is a slice type taking both a single
+// value, split into its elements, and indexed keys.
+func isIndexedSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 && converter(t) == nil && !isPflagType(t)
+}
+
+// acceptsSliceValue reports whether the single value of the slice field at
+// path is assigned: under AssignmentPreferIndexed, not once an indexed key
+// set an element.
+func (s *decodeState) acceptsSliceValue(path string) bool {
+	if s.opts.assignmentOrder != AssignmentPreferIndexed {
+		return true
+	}
+	for assigned := range s.assigned {
+		if strings.HasPrefix(assigned, path+"[") {
+			s.warn("value ignored, field %s is given by indexed keys", path)
+			return false
+		}
+	}
+	return true
+}
+
+// acceptsSliceIndex reports whether an indexed key of the slice field
+// fieldVal at path is assigned once a single value set the slice: under
+// AssignmentPreferScalar it is not, and under AssignmentPreferIndexed the
+// slice is emptied first, so the indexed keys give all its elements.
+func (s *decodeState) acceptsSliceIndex(fieldVal reflect.Value, path string) (bool, error) {
+	if _, ok := s.assigned[path]; !ok {
+		return true, nil
+	}
+	switch s.opts.assignmentOrder {
+	case AssignmentPreferScalar:
+		s.warn("indexed key ignored, field %s is given as a single value", path)
+		return false, nil
+	case AssignmentPreferIndexed:
+		s.warn("value of field %s dropped for its indexed keys", path)
+		delete(s.assigned, path)
+		return true, setWithReflect(fieldVal, reflect.Zero(fieldVal.Type()))
+	}
+	return true, nil
+}
@@ -0,0 +1,55 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignmentOrder(t *testing.T) {
+	type config struct {
+		X     []string
+		Hosts []struct{ Addr string }
+	}
+
+	inputs := []string{
+		// the single value comes first in key order
+		"X=a,c\nX_0=b",
+		// and last, its key sorting after X_0
+		"X_0=b\nx=a,c",
+	}
+	tests := []struct {
+		order xconfigdotenv.AssignmentOrder
+		want  [][]string
+	}{
+		{xconfigdotenv.AssignmentKeyOrder, [][]string{{"b", "c"}, {"a", "c"}}},
+		{xconfigdotenv.AssignmentPreferIndexed, [][]string{{"b"}, {"b"}}},
+		{xconfigdotenv.AssignmentPreferScalar, [][]string{{"a", "c"}, {"a", "c"}}},
+	}
+	for _, tt := range tests {
+		decoder := xconfigdotenv.New(xconfigdotenv.WithAssignmentOrder(tt.order))
+		for i, input := range inputs {
+			var c config
+			assert.NoError(t, decoder.Unmarshal([]byte(input), &c), input)
+			assert.Equal(t, tt.want[i], c.X, "%d: %q", tt.order, input)
+		}
+	}
+
+	// the keys dropped are reported
+	decoder := xconfigdotenv.New(xconfigdotenv.WithAssignmentOrder(xconfigdotenv.AssignmentPreferIndexed))
+	var c config
+	meta, err := decoder.UnmarshalWithMetadata([]byte("X_0=b\nx=a,c"), &c)
+	assert.NoError(t, err)
+	assert.Equal(t, []xconfigdotenv.Warning{{Key: "x", Message: "value ignored, field X is given by indexed keys"}}, meta.Warnings)
+
+	decoder = xconfigdotenv.New(xconfigdotenv.WithAssignmentOrder(xconfigdotenv.AssignmentPreferScalar))
+	meta, err = decoder.UnmarshalWithMetadata([]byte("X=a,c\nX_0=b"), &c)
+	assert.NoError(t, err)
+	assert.Equal(t, []xconfigdotenv.Warning{{Key: "X_0", Message: "indexed key ignored, field X is given as a single value"}}, meta.Warnings)
+
+	// slices of structs only take indexed keys, which are left alone
+	c = config{}
+	assert.NoError(t, decoder.Unmarshal([]byte("HOSTS_0_ADDR=a\nHOSTS_1_ADDR=b"), &c))
+	assert.Equal(t, []struct{ Addr string }{{"a"}, {"b"}}, c.Hosts)
+}
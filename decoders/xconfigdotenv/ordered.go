@@ -0,0 +1,72 @@
+package xconfigdotenv
+
+import (
+	"reflect"
+	"slices"
+)
+
+// MapSetter is implemented by map-like types which receive the subkeys of
+// their field, in place of a Go map: with a field Labels of such a type,
+// LABELS_TEAM=core calls Set("TEAM", "core"). Values are passed as is,
+// after the `format` tag is applied.
+//
+// Keys are decoded in sorted order, so Set is called in the sorted order of
+// the full keys, not in the order of the input, which is not kept by the
+// parser.
+type MapSetter interface {
+	Set(key, value string) error
+}
+
+var mapSetterType = reflect.TypeFor[MapSetter]()
+
+// OrderedMap is a MapSetter keeping its keys in the order they were first
+// set. Its zero value is an empty map ready to use.
+type OrderedMap struct {
+	keys   []string
+	values map[string]string
+}
+
+// Set sets the value of key, appending key to the keys when it is new.
+func (m *OrderedMap) Set(key, value string) error {
+	if m.values == nil {
+		m.values = make(map[string]string)
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+	return nil
+}
+
+// Get returns the value of key and whether key is set.
+func (m *OrderedMap) Get(key string) (string, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
+
+// Keys returns the keys in the order they were first set.
+func (m *OrderedMap) Keys() []string {
+	return slices.Clone(m.keys)
+}
+
+// Len returns the number of keys.
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+// mapSetter returns the field value v as a MapSetter, allocating a nil
+// pointer, or false when its type does not implement MapSetter.
+func mapSetter(v reflect.Value) (MapSetter, bool, error) {
+	if v.Kind() == reflect.Ptr && v.Type().Implements(mapSetterType) {
+		if v.IsNil() {
+			if err := setWithReflect(v, reflect.New(v.Type().Elem())); err != nil {
+				return nil, false, err
+			}
+		}
+		return v.Interface().(MapSetter), true, nil
+	}
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(mapSetterType) {
+		return v.Addr().Interface().(MapSetter), true, nil
+	}
+	return nil, false, nil
+}
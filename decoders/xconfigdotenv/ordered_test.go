@@ -0,0 +1,49 @@
+package xconfigdotenv_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type rejectingSetter struct{}
+
+func (rejectingSetter) Set(key, _ string) error {
+	return errors.New("rejected " + key)
+}
+
+func TestOrderedMap(t *testing.T) {
+	var config struct {
+		Steps  xconfigdotenv.OrderedMap
+		Labels *xconfigdotenv.OrderedMap
+		Other  *xconfigdotenv.OrderedMap
+	}
+
+	decoder := xconfigdotenv.New()
+	err := decoder.Unmarshal([]byte("STEPS_2_BUILD=make\nSTEPS_1_FETCH=git\nSTEPS_3_TEST=go\nLABELS_team=core"), &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"1_FETCH", "2_BUILD", "3_TEST"}, config.Steps.Keys())
+	value, ok := config.Steps.Get("2_BUILD")
+	assert.True(t, ok)
+	assert.Equal(t, "make", value)
+	if assert.NotNil(t, config.Labels) {
+		assert.Equal(t, 1, config.Labels.Len())
+	}
+	assert.Nil(t, config.Other)
+
+	data, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "STEPS_1_FETCH=git\nSTEPS_2_BUILD=make\nSTEPS_3_TEST=go\nLABELS_team=core\n", string(data))
+}
+
+func TestMapSetterError(t *testing.T) {
+	var config struct {
+		Labels rejectingSetter
+	}
+
+	err := xconfigdotenv.New().Unmarshal([]byte("LABELS_team=core"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "LABELS_team": rejected team`)
+}
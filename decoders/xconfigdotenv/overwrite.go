@@ -0,0 +1,61 @@
+package xconfigdotenv
+
+import "reflect"
+
+// OverwritePolicy defines whether a key overwrites the value a field holds
+// before Unmarshal, e.g. when several sources are decoded in turn into the
+// same struct, a later source overriding an earlier one.
+type OverwritePolicy int
+
+const (
+	// OverwriteAlways sets every field a key gives, so the last source
+	// wins: with PORT=8080 decoded first, PORT=0 then gives 0. It is the
+	// default.
+	OverwriteAlways OverwritePolicy = iota
+	// OverwriteSkipZeroValues keeps the value of a field when the key gives
+	// it the zero value: with PORT=8080 decoded first, PORT=0 keeps 8080,
+	// while PORT=9090 gives 9090, and a field still holding 0 gets 0.
+	OverwriteSkipZeroValues
+	// OverwriteOnlyIfUnset only sets the fields still holding their zero
+	// value, so the first source wins: with PORT=8080 decoded first,
+	// PORT=9090 keeps 8080, while a field still holding 0 gets 9090.
+	OverwriteOnlyIfUnset
+)
+
+// assignField sets the field fieldVal at path from rawVal, unless a key
+// matched through higher priority names set it (see claim) or the
+// OverwritePolicy keeps its value. Fields set by an earlier key of the same
+// run are overwritten as usual, the policy only protecting the values the
+// struct held beforehand.
+func (s *decodeState) assignField(fieldVal reflect.Value, field reflect.StructField, rawVal, path string) error {
+	policy := s.opts.overwritePolicy
+	if policy == OverwriteAlways {
+		if !s.claim(path) {
+			return nil
+		}
+		return s.reportSet(s.setFieldValue(fieldVal, field, rawVal), path, fieldVal)
+	}
+
+	_, setByKey := s.assigned[path]
+	preset := !setByKey && !isZeroValue(fieldVal)
+	// The value is decoded even when it is not kept, so it fails as usual
+	value := reflect.New(fieldVal.Type()).Elem()
+	if err := s.setFieldValue(value, field, rawVal); err != nil {
+		return err
+	}
+	if preset && (policy == OverwriteOnlyIfUnset || isZeroValue(value)) {
+		return nil
+	}
+	if !s.claim(path) {
+		return nil
+	}
+	return s.reportSet(setWithReflect(fieldVal, value), path, fieldVal)
+}
+
+// isZeroValue reports whether v is the zero value, or a pointer to it.
+func isZeroValue(v reflect.Value) bool {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,61 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type overwriteConfig struct {
+	Host    string
+	Port    int
+	Debug   bool
+	Timeout *int
+	Tags    []string
+	Labels  map[string]string
+}
+
+func TestOverwritePolicy(t *testing.T) {
+	first := []byte("HOST=a\nPORT=8080\nDEBUG=true\nTIMEOUT=5\nTAGS=x\nLABELS_team=core")
+	second := []byte("HOST=b\nPORT=0\nDEBUG=false\nTIMEOUT=0\nTAGS=y,z\nLABELS_team=infra\nLABELS_env=prod")
+	timeout := func(n int) *int { return &n }
+
+	tests := []struct {
+		policy xconfigdotenv.OverwritePolicy
+		want   overwriteConfig
+	}{
+		{xconfigdotenv.OverwriteAlways, overwriteConfig{
+			Host: "b", Port: 0, Debug: false, Timeout: timeout(0), Tags: []string{"y", "z"},
+			Labels: map[string]string{"team": "infra", "env": "prod"},
+		}},
+		{xconfigdotenv.OverwriteSkipZeroValues, overwriteConfig{
+			Host: "b", Port: 8080, Debug: true, Timeout: timeout(5), Tags: []string{"y", "z"},
+			Labels: map[string]string{"team": "infra", "env": "prod"},
+		}},
+		{xconfigdotenv.OverwriteOnlyIfUnset, overwriteConfig{
+			Host: "a", Port: 8080, Debug: true, Timeout: timeout(5), Tags: []string{"x"},
+			Labels: map[string]string{"team": "infra", "env": "prod"},
+		}},
+	}
+	for _, tt := range tests {
+		decoder := xconfigdotenv.New(xconfigdotenv.WithOverwritePolicy(tt.policy))
+		var config overwriteConfig
+		assert.NoError(t, decoder.Unmarshal(first, &config))
+		assert.NoError(t, decoder.Unmarshal(second, &config))
+		assert.Equal(t, tt.want, config, tt.policy)
+	}
+
+	// an unset field takes the value, even the zero one, and a key of the
+	// same run may overwrite another
+	decoder := xconfigdotenv.New(xconfigdotenv.WithOverwritePolicy(xconfigdotenv.OverwriteOnlyIfUnset))
+	config := overwriteConfig{Host: "kept"}
+	assert.NoError(t, decoder.Unmarshal([]byte("HOST=new\nTIMEOUT=0\nPORT=1\nPort=2"), &config))
+	assert.Equal(t, "kept", config.Host)
+	assert.Equal(t, timeout(0), config.Timeout)
+	assert.Equal(t, 2, config.Port)
+
+	// a value failing to decode fails as usual, even when it is not kept
+	err := decoder.Unmarshal([]byte("PORT=x"), &config)
+	assert.ErrorContains(t, err, `cannot parse "x" as int`)
+}
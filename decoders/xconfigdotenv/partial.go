@@ -0,0 +1,92 @@
+package xconfigdotenv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeyError is the error of a key which could not be decoded.
+//
+// Both KeyError and PartialError marshal to JSON, for tools annotating
+// config failures: a KeyError gives {"key", "field", "message"} and a
+// PartialError the array of its errors.
+type KeyError struct {
+	// Key is the key of the input, empty for errors of a field given the
+	// keys collected for it (see Unmarshaler).
+	Key string
+	// Field is the path of the deepest field the key matched, such as
+	// DB.Hosts, empty when the error came before a field matched.
+	Field string
+	Err   error
+}
+
+// Error returns the error with the key, or the field when there is no key.
+func (e *KeyError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("xconfigdotenv: Unmarshal: field %q: %v", e.Field, e.Err)
+	}
+	return fmt.Sprintf("xconfigdotenv: Unmarshal: key %q: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// keyErrorJSON is the JSON form of a KeyError.
+type keyErrorJSON struct {
+	Key     string `json:"key"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// MarshalJSON implements json.Marshaler, the message being the text of Err.
+func (e *KeyError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(keyErrorJSON{Key: e.Key, Field: e.Field, Message: e.Err.Error()})
+}
+
+// PartialError is the error returned under WithAllowPartial when some keys
+// failed: the destination holds every value which could be decoded, and
+// Errors lists, in key order, the errors of the others.
+type PartialError struct {
+	Errors []error
+}
+
+// Error returns the errors, one per line.
+func (e *PartialError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the errors, for errors.Is and errors.As.
+func (e *PartialError) Unwrap() []error {
+	return e.Errors
+}
+
+// MarshalJSON implements json.Marshaler, giving the array of the errors.
+// Errors which are not a KeyError only have a message.
+func (e *PartialError) MarshalJSON() ([]byte, error) {
+	entries := make([]keyErrorJSON, len(e.Errors))
+	for i, err := range e.Errors {
+		var keyErr *KeyError
+		if errors.As(err, &keyErr) {
+			entries[i] = keyErrorJSON{Key: keyErr.Key, Field: keyErr.Field, Message: keyErr.Err.Error()}
+		} else {
+			entries[i] = keyErrorJSON{Message: err.Error()}
+		}
+	}
+	return json.Marshal(entries)
+}
+
+// orNil returns e as an error, or nil when it holds no error.
+func (e *PartialError) orNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,90 @@
+package xconfigdotenv_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowPartial(t *testing.T) {
+	type config struct {
+		Name  string
+		Port  int
+		Debug bool
+		Hosts []string
+	}
+
+	data := []byte("NAME=app\nPORT=eighty\nDEBUG=maybe\nHOSTS=a,b")
+
+	var failFast config
+	err := xconfigdotenv.New().Unmarshal(data, &failFast)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "DEBUG": cannot parse "maybe" as bool: strconv.ParseBool: parsing "maybe": invalid syntax`)
+
+	var cfg config
+	decoder := xconfigdotenv.New(xconfigdotenv.WithAllowPartial())
+	meta, err := decoder.UnmarshalWithMetadata(data, &cfg)
+
+	var partial *xconfigdotenv.PartialError
+	if assert.ErrorAs(t, err, &partial) {
+		assert.Len(t, partial.Errors, 2)
+	}
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+	assert.Equal(t, `xconfigdotenv: Unmarshal: key "DEBUG": cannot parse "maybe" as bool: strconv.ParseBool: parsing "maybe": invalid syntax
+xconfigdotenv: Unmarshal: key "PORT": cannot parse "eighty" as int: strconv.ParseInt: parsing "eighty": invalid syntax`, err.Error())
+	assert.Equal(t, 2, meta.Metrics.Errors)
+
+	assert.Equal(t, "app", cfg.Name)
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	assert.Zero(t, cfg.Port)
+
+	// no error at all gives nil
+	assert.NoError(t, decoder.Unmarshal([]byte("NAME=app"), &cfg))
+}
+
+func TestAllowPartialUnknownKeyCallback(t *testing.T) {
+	var config struct {
+		Name string
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithAllowPartial(), xconfigdotenv.WithUnknownKeyCallback(func(key, _ string) error {
+		return errors.New("unknown")
+	}))
+	err := decoder.Unmarshal([]byte("A=1\nB=2\nNAME=app"), &config)
+	assert.EqualError(t, err, "xconfigdotenv: Unmarshal: key \"A\": unknown\nxconfigdotenv: Unmarshal: key \"B\": unknown")
+	assert.Equal(t, "app", config.Name)
+}
+
+func TestKeyErrorJSON(t *testing.T) {
+	var config struct {
+		Name string
+		DB   struct {
+			Port int
+		}
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithAllowPartial(), xconfigdotenv.WithUnknownKeyCallback(func(key, _ string) error {
+		return errors.New("unknown")
+	}))
+	err := decoder.Unmarshal([]byte("DB_PORT=x\nEXTRA=1\nNAME=app"), &config)
+
+	var keyErr *xconfigdotenv.KeyError
+	if assert.ErrorAs(t, err, &keyErr) {
+		assert.Equal(t, "DB_PORT", keyErr.Key)
+		assert.Equal(t, "DB.Port", keyErr.Field)
+	}
+
+	data, jsonErr := json.Marshal(err)
+	assert.NoError(t, jsonErr)
+	assert.JSONEq(t, `[
+		{"key": "DB_PORT", "field": "DB.Port", "message": "cannot parse \"x\" as int: strconv.ParseInt: parsing \"x\": invalid syntax"},
+		{"key": "EXTRA", "field": "", "message": "unknown"}
+	]`, string(data))
+
+	data, jsonErr = json.Marshal(keyErr)
+	assert.NoError(t, jsonErr)
+	assert.JSONEq(t, `{"key": "DB_PORT", "field": "DB.Port", "message": "cannot parse \"x\" as int: strconv.ParseInt: parsing \"x\": invalid syntax"}`, string(data))
+}
@@ -0,0 +1,59 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// pflagValue is the interface of the values of github.com/spf13/pflag,
+// pflag.Value, which this package does not depend on.
+type pflagValue interface {
+	Set(string) error
+	String() string
+	Type() string
+}
+
+var pflagValueType = reflect.TypeFor[pflagValue]()
+
+// isPflagType reports whether the values of t are decoded by their Set
+// method: whether *t implements pflag.Value, as the flag types of CLIs built
+// on pflag do, with no converter registered for t and no UnmarshalBinary
+// method, which come first.
+func isPflagType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr || converter(t) != nil || isBinaryType(t) {
+		return false
+	}
+	return reflect.PointerTo(t).Implements(pflagValueType)
+}
+
+// setPflagValue sets fieldVal, of a type for which isPflagType is true,
+// with its Set method, as pflag does for a flag given on the command line:
+// a value built by a pflag constructor keeps working, and a slice value may
+// append to the elements it holds, as it does for a repeated flag.
+func setPflagValue(fieldVal reflect.Value, rawVal string) error {
+	target := fieldVal
+	if !fieldVal.CanAddr() {
+		target = reflect.New(fieldVal.Type()).Elem()
+		target.Set(fieldVal)
+	}
+	value := target.Addr().Interface().(pflagValue)
+	if err := value.Set(rawVal); err != nil {
+		return fmt.Errorf("cannot parse %q as %s: %w", rawVal, value.Type(), err)
+	}
+	if target != fieldVal {
+		return setWithReflect(fieldVal, target)
+	}
+	return nil
+}
+
+// pflagText returns the text String gives for v, when its type is decoded
+// by setPflagValue, so Marshal writes what Unmarshal reads.
+func pflagText(v reflect.Value) (string, bool) {
+	if !isPflagType(v.Type()) {
+		return "", false
+	}
+	// Copy v, whose method has a pointer receiver
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	return ptr.Interface().(pflagValue).String(), true
+}
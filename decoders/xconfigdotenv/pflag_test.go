@@ -0,0 +1,82 @@
+package xconfigdotenv_test
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+// ipList is a pflag.Value holding IPs, like the IPSlice flags of pflag.
+type ipList []net.IP
+
+func (l *ipList) Set(s string) error {
+	var ips ipList
+	for _, part := range strings.Split(s, ",") {
+		ip := net.ParseIP(strings.TrimSpace(part))
+		if ip == nil {
+			return fmt.Errorf("invalid IP %q", part)
+		}
+		ips = append(ips, ip)
+	}
+	*l = ips
+	return nil
+}
+
+func (l *ipList) String() string {
+	parts := make([]string, len(*l))
+	for i, ip := range *l {
+		parts[i] = ip.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *ipList) Type() string { return "ipSlice" }
+
+// mode is a pflag.Value of a struct kind, holding one of a set of names.
+type mode struct {
+	name string
+}
+
+func (m *mode) Set(s string) error {
+	switch s {
+	case "fast", "safe":
+		m.name = s
+		return nil
+	}
+	return fmt.Errorf("must be fast or safe")
+}
+
+func (m *mode) String() string { return m.name }
+
+func (m *mode) Type() string { return "mode" }
+
+func TestPflagValue(t *testing.T) {
+	var config struct {
+		Trusted ipList
+		Mode    mode
+		Backup  *mode
+		Modes   map[string]mode
+	}
+
+	data := []byte(`TRUSTED=10.0.0.1, 10.0.0.2
+MODE=fast
+BACKUP=safe
+MODES_a=safe`)
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+	assert.Equal(t, ipList{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}, config.Trusted)
+	assert.Equal(t, mode{"fast"}, config.Mode)
+	assert.Equal(t, &mode{"safe"}, config.Backup)
+	assert.Equal(t, map[string]mode{"a": {"safe"}}, config.Modes)
+
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "TRUSTED=10.0.0.1,10.0.0.2\nMODE=fast\nBACKUP=safe\nMODES_a=safe\n", string(out))
+
+	err = decoder.Unmarshal([]byte("MODE=slow"), &config)
+	assert.ErrorContains(t, err, `cannot parse "slow" as mode: must be fast or safe`)
+}
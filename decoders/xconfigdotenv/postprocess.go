@@ -0,0 +1,24 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// PostProcessor normalizes or validates a value of type typ just assigned
+// to v, see WithValuePostProcessors. V is addressable, so the post-processor
+// may change it.
+type PostProcessor func(typ reflect.Type, v reflect.Value) error
+
+// postProcess runs the post-processor registered for the type of v, if any,
+// on the value just assigned to v.
+func (s *decodeState) postProcess(v reflect.Value) error {
+	post, ok := s.opts.postProcessors[v.Type()]
+	if !ok {
+		return nil
+	}
+	if err := post(v.Type(), v); err != nil {
+		return fmt.Errorf("post-processing %s: %w", v.Type(), err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package xconfigdotenv_test
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+// configPath is a file path made absolute by a post-processor.
+type configPath string
+
+func absPath(_ reflect.Type, v reflect.Value) error {
+	abs, err := filepath.Abs(v.String())
+	if err != nil {
+		return err
+	}
+	v.SetString(abs)
+	return nil
+}
+
+func TestValuePostProcessors(t *testing.T) {
+	var config struct {
+		Data    configPath
+		Log     *configPath
+		Plugins []configPath
+		Mounts  map[string]configPath
+		Cache   *struct {
+			Dir  configPath `default:"cache"`
+			Size int
+		}
+		Name string
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithValuePostProcessors(map[reflect.Type]xconfigdotenv.PostProcessor{
+		reflect.TypeFor[configPath](): absPath,
+	}))
+	data := []byte("DATA=data\nLOG=/var/log/app\nPLUGINS=a,b\nMOUNTS_tmp=tmp\nCACHE_SIZE=1\nNAME=name")
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+
+	abs := func(path string) configPath {
+		p, err := filepath.Abs(path)
+		assert.NoError(t, err)
+		return configPath(p)
+	}
+	assert.Equal(t, abs("data"), config.Data)
+	if assert.NotNil(t, config.Log) {
+		assert.Equal(t, configPath("/var/log/app"), *config.Log)
+	}
+	assert.Equal(t, []configPath{abs("a"), abs("b")}, config.Plugins)
+	assert.Equal(t, map[string]configPath{"tmp": abs("tmp")}, config.Mounts)
+	if assert.NotNil(t, config.Cache) {
+		assert.Equal(t, abs("cache"), config.Cache.Dir)
+	}
+	// other types are left alone
+	assert.Equal(t, "name", config.Name)
+
+	// the post-processor runs after the converter, and its errors fail the key
+	var ports struct{ Port int }
+	decoder = xconfigdotenv.New(xconfigdotenv.WithValuePostProcessors(map[reflect.Type]xconfigdotenv.PostProcessor{
+		reflect.TypeFor[int](): func(_ reflect.Type, v reflect.Value) error {
+			if v.Int() < 1024 {
+				return errors.New("privileged port")
+			}
+			return nil
+		},
+	}))
+	assert.NoError(t, decoder.Unmarshal([]byte("PORT=8080"), &ports))
+	assert.Equal(t, 8080, ports.Port)
+	err := decoder.Unmarshal([]byte("PORT=80"), &ports)
+	assert.ErrorContains(t, err, "post-processing int: privileged port")
+}
@@ -0,0 +1,135 @@
+package xconfigdotenv
+
+import (
+	"reflect"
+	"strings"
+)
+
+// envOptionPrefix is the `env` tag option giving the key prefix of a struct
+// field, see prefixedFields.
+const envOptionPrefix = "prefix="
+
+// prefixedField is a struct field whose keys start with its own prefix.
+type prefixedField struct {
+	prefix string
+	// index is the path of indices of the field from the top-level struct,
+	// and path the same as field names.
+	index []int
+	path  string
+}
+
+// fieldPrefix returns the prefix option of the `env` tag of field, if field
+// is a struct or a pointer to one.
+func (o *options) fieldPrefix(field reflect.StructField) (string, bool) {
+	if !isPrefixable(field.Type) {
+		return "", false
+	}
+	_, opts := o.splitEnvTag(field)
+	for _, opt := range opts {
+		if prefix, ok := strings.CutPrefix(opt, envOptionPrefix); ok && prefix != "" {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// isPrefixable reports whether a field of type t may be given a prefix.
+func isPrefixable(t reflect.Type) bool {
+	st := derefType(t)
+	return st.Kind() == reflect.Struct && converter(t) == nil && converter(st) == nil && !isAtomicType(st) && !isBinaryType(st) && !isPflagType(st) && st != syncMapType
+}
+
+// prefixedFields returns the fields of typ, and of the structs it holds
+// through struct and pointer fields, tagged with a prefix, e.g.
+// `env:",prefix=PG"`.
+//
+// The keys of such a field start with its prefix instead of the names of
+// the field and of the structs holding it: a Primary field tagged
+// `env:",prefix=PG"` in the struct of a Storage field gets PG_HOST rather
+// than STORAGE_PRIMARY_HOST, which no longer matches. The prefix is matched
+// at the start of the key like a field name, so its case and underscores
+// are free, and a prefix inside a prefixed struct starts the key again.
+// Structs reached through slices and maps are not searched, so their
+// fields are matched by their names.
+func (o *options) prefixedFields(typ reflect.Type) []prefixedField {
+	var fields []prefixedField
+	o.collectPrefixed(typ, nil, "", map[reflect.Type]bool{typ: true}, &fields)
+	return fields
+}
+
+// collectPrefixed does the work of prefixedFields. Types holds the struct
+// types on the current path, so recursive types are searched once.
+func (o *options) collectPrefixed(typ reflect.Type, index []int, path string, types map[reflect.Type]bool, fields *[]prefixedField) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !isPrefixable(field.Type) || o.skipsField(field) {
+			continue
+		}
+		fieldIndex := append(index[:len(index):len(index)], i)
+		fieldPath := joinPath(path, field.Name)
+		if prefix, ok := o.fieldPrefix(field); ok {
+			*fields = append(*fields, prefixedField{prefix: prefix, index: fieldIndex, path: fieldPath})
+		}
+
+		st := derefType(field.Type)
+		if types[st] {
+			continue
+		}
+		types[st] = true
+		o.collectPrefixed(st, fieldIndex, fieldPath, types, fields)
+		delete(types, st)
+	}
+}
+
+// matchPrefix returns the prefixed field whose prefix matches the longest
+// prefix of parts, leaving segments for its fields, and the length of that
+// prefix. The field is nil when no prefix matches.
+func (s *decodeState) matchPrefix(parts []string) (*prefixedField, int) {
+	var best *prefixedField
+	bestLen := 0
+	for i := range s.prefixes {
+		names := []fieldName{{value: s.prefixes[i].prefix, source: MatchTag}}
+		for n := len(parts) - 1; n > bestLen; n-- {
+			if _, _, ok := s.matchNames(names, strings.Join(parts[:n], "_")); ok {
+				best, bestLen = &s.prefixes[i], n
+				break
+			}
+		}
+	}
+	return best, bestLen
+}
+
+// assignPrefixed puts rawVal in the prefixed field matched by parts, if
+// any. It reports whether a prefix matched the key.
+func (s *decodeState) assignPrefixed(elem reflect.Value, parts []string, rawVal string) (bool, error) {
+	pf, n := s.matchPrefix(parts)
+	if pf == nil {
+		return false, nil
+	}
+	return s.descendPrefixed(elem, pf, pf.index, "", parts[n:], rawVal)
+}
+
+// descendPrefixed walks the fields of v along index down to the prefixed
+// field pf, at path, and puts rawVal in it. The pointers on the way are
+// allocated as by descendPtr, so they stay nil unless a field takes the key.
+func (s *decodeState) descendPrefixed(v reflect.Value, pf *prefixedField, index []int, path string, parts []string, rawVal string) (bool, error) {
+	if len(index) == 0 {
+		s.ranks = append(s.ranks, 0)
+		s.field = pf.path
+		return s.assignValue(v, parts, rawVal, pf.path)
+	}
+
+	field := v.Type().Field(index[0])
+	path = joinPath(path, field.Name)
+	s.markField(field)
+	fieldVal := getFieldValue(v, index[0])
+	if err := s.resetField(fieldVal, path); err != nil {
+		return true, err
+	}
+	if fieldVal.Kind() == reflect.Ptr {
+		return s.descendPtr(fieldVal, func(elem reflect.Value) (bool, error) {
+			return s.descendPrefixed(elem, pf, index[1:], path, parts, rawVal)
+		})
+	}
+	return s.descendPrefixed(fieldVal, pf, index[1:], path, parts, rawVal)
+}
@@ -0,0 +1,66 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type prefixDB struct {
+	Host string
+	Port int
+}
+
+type prefixConfig struct {
+	Name    string
+	Storage struct {
+		Primary prefixDB  `env:",prefix=PG"`
+		Replica *prefixDB `env:",prefix=PG_REPLICA"`
+		Cache   struct {
+			Size  int
+			Redis prefixDB `env:",prefix=REDIS"`
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	data := []byte(`NAME=app
+PG_HOST=db
+PG_PORT=5432
+PG_REPLICA_HOST=replica
+pg_replica_port=5433
+REDIS_HOST=cache
+STORAGE_CACHE_SIZE=10
+STORAGE_PRIMARY_HOST=ignored
+STORAGE_CACHE_REDIS_HOST=ignored`)
+
+	var config prefixConfig
+	meta, err := xconfigdotenv.New().UnmarshalWithMetadata(data, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, prefixDB{Host: "db", Port: 5432}, config.Storage.Primary)
+	assert.Equal(t, &prefixDB{Host: "replica", Port: 5433}, config.Storage.Replica)
+	assert.Equal(t, "cache", config.Storage.Cache.Redis.Host)
+	assert.Equal(t, 10, config.Storage.Cache.Size)
+	// the keys using the field names do not match the prefixed fields
+	assert.Equal(t, 2, meta.Metrics.KeysIgnored)
+
+	// prefixed fields are written under their prefix
+	data, err = xconfigdotenv.New().Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, `NAME=app
+PG_HOST=db
+PG_PORT=5432
+PG_REPLICA_HOST=replica
+PG_REPLICA_PORT=5433
+STORAGE_CACHE_SIZE=10
+REDIS_HOST=cache
+REDIS_PORT=0
+`, string(data))
+
+	// concurrent decoding gives the same result
+	var concurrent prefixConfig
+	assert.NoError(t, xconfigdotenv.New(xconfigdotenv.WithConcurrentDecode(1)).Unmarshal(data, &concurrent))
+	assert.Equal(t, config, concurrent)
+}
@@ -0,0 +1,98 @@
+package xconfigdotenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrAmbiguous is returned when several fields match the same key.
+var ErrAmbiguous = errors.New("ambiguous key")
+
+// Prepare checks up-front the struct type v points to, and the struct types
+// reachable from it, for fields sharing a name (their `env` names or their
+// Go name, after normalization), which would make the keys using that name
+// ambiguous. Names of field types are not checked: two fields of the same
+// type are only ambiguous for keys using the type name, which Unmarshal
+// reports. Prepare applies the AmbiguityPolicy: it returns an ErrAmbiguous
+// error, panics with it, or returns nil under AmbiguityFirst. It does
+// nothing under WithFieldMatcher.
+func (d *Decoder) Prepare(v any) error {
+	if d.opts.fieldMatcher != nil || d.opts.ambiguityPolicy == AmbiguityFirst {
+		return nil
+	}
+
+	t := reflect.TypeOf(v)
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Interface) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("xconfigdotenv: Prepare: v must be a pointer to a struct, got %T", v)
+	}
+
+	if err := d.checkAmbiguity(t, map[reflect.Type]bool{}); err != nil {
+		err = fmt.Errorf("xconfigdotenv: Prepare: %w", err)
+		if d.opts.ambiguityPolicy == AmbiguityPanic {
+			panic(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// checkAmbiguity checks the struct type t and the struct types reachable from it.
+func (d *Decoder) checkAmbiguity(t reflect.Type, visited map[reflect.Type]bool) error {
+	if visited[t] {
+		return nil
+	}
+	visited[t] = true
+
+	type owned struct {
+		norm     string
+		priority int
+	}
+	owners := make(map[owned]string)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if d.opts.skipsField(field) {
+			continue
+		}
+
+		for _, name := range d.opts.fieldNames(field) {
+			priority, enabled := d.opts.sourcePriority(name.source)
+			if name.source == MatchTypeName || name.fallback || !enabled {
+				continue
+			}
+			key := owned{
+				norm:     normalize(name.value, !d.opts.caseSensitive || (name.rank > 0 && d.opts.aliasCaseFold)),
+				priority: priority,
+			}
+			if owner, ok := owners[key]; ok && owner != field.Name {
+				return fmt.Errorf("%w: %s: name %q is shared by fields %q and %q", ErrAmbiguous, t, name.value, owner, field.Name)
+			}
+			owners[key] = field.Name
+		}
+
+		if ft := structType(field.Type); ft != nil {
+			if err := d.checkAmbiguity(ft, visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// structType returns the struct type held by t, through pointers, slices,
+// arrays and maps, or nil.
+func structType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			return t
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type dbConf struct {
+	Host string
+}
+
+type sameTypeConfig struct {
+	Primary dbConf
+	Replica dbConf
+}
+
+type sharedNameConfig struct {
+	Name  string
+	Title string `env:"TITLE,NAME"`
+}
+
+func TestAmbiguityPolicy(t *testing.T) {
+	data := []byte("DBCONF_HOST=db\nREPLICA_HOST=replica")
+
+	t.Run("error", func(t *testing.T) {
+		var config sameTypeConfig
+		err := xconfigdotenv.New().Unmarshal(data, &config)
+		assert.ErrorIs(t, err, xconfigdotenv.ErrAmbiguous)
+		assert.ErrorContains(t, err, `matches fields "Primary" and "Replica"`)
+	})
+
+	t.Run("first", func(t *testing.T) {
+		var config sameTypeConfig
+		decoder := xconfigdotenv.New(xconfigdotenv.WithAmbiguityPolicy(xconfigdotenv.AmbiguityFirst))
+		err := decoder.Unmarshal(data, &config)
+		assert.NoError(t, err)
+		assert.Equal(t, "db", config.Primary.Host)
+		assert.Equal(t, "replica", config.Replica.Host)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		// only Prepare panics, Unmarshal returns the error
+		var config sameTypeConfig
+		decoder := xconfigdotenv.New(xconfigdotenv.WithAmbiguityPolicy(xconfigdotenv.AmbiguityPanic))
+		var err error
+		assert.NotPanics(t, func() {
+			err = decoder.Unmarshal(data, &config)
+		})
+		assert.ErrorIs(t, err, xconfigdotenv.ErrAmbiguous)
+	})
+}
+
+func TestPrepare(t *testing.T) {
+	t.Run("type names are not checked", func(t *testing.T) {
+		assert.NoError(t, xconfigdotenv.New().Prepare(&sameTypeConfig{}))
+	})
+
+	t.Run("shared name", func(t *testing.T) {
+		err := xconfigdotenv.New().Prepare(&sharedNameConfig{})
+		assert.ErrorIs(t, err, xconfigdotenv.ErrAmbiguous)
+		assert.ErrorContains(t, err, `name "NAME" is shared by fields "Name" and "Title"`)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		var config struct {
+			Sections []*sharedNameConfig
+		}
+		assert.ErrorIs(t, xconfigdotenv.New().Prepare(&config), xconfigdotenv.ErrAmbiguous)
+	})
+
+	t.Run("first", func(t *testing.T) {
+		decoder := xconfigdotenv.New(xconfigdotenv.WithAmbiguityPolicy(xconfigdotenv.AmbiguityFirst))
+		assert.NoError(t, decoder.Prepare(&sharedNameConfig{}))
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		decoder := xconfigdotenv.New(xconfigdotenv.WithAmbiguityPolicy(xconfigdotenv.AmbiguityPanic))
+		assert.Panics(t, func() {
+			_ = decoder.Prepare(&sharedNameConfig{})
+		})
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		n := 1
+		assert.Error(t, xconfigdotenv.New().Prepare(&n))
+	})
+}
@@ -0,0 +1,80 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// preTag is the tag naming the preprocessors of a value, see Preprocessor.
+const preTag = "pre"
+
+// Preprocessor transforms a raw value before its conversion.
+//
+// Fields select preprocessors by name with the `pre` tag, several names
+// being applied in order, e.g. `pre:"trim,expanduser"`. The preprocessors of
+// a struct, map or slice field apply to all the values below it, before
+// those of the inner fields. Preprocessors run before the `format` tag is
+// applied. The built-in preprocessors are:
+//
+//   - trim: removes the leading and trailing spaces.
+//   - expandenv: replaces ${VAR} and $VAR with the environment variables,
+//     see os.ExpandEnv.
+//   - expanduser: replaces a leading ~ with the home directory of the user.
+//   - abspath: makes a path absolute, see filepath.Abs.
+//
+// Others are added with WithValuePreprocessor.
+type Preprocessor func(rawVal string) (string, error)
+
+var builtinPreprocessors = map[string]Preprocessor{
+	"trim": func(rawVal string) (string, error) {
+		return strings.TrimSpace(rawVal), nil
+	},
+	"expandenv": func(rawVal string) (string, error) {
+		return os.ExpandEnv(rawVal), nil
+	},
+	"expanduser": expandUser,
+	"abspath":    filepath.Abs,
+}
+
+// expandUser replaces a leading ~ of the path with the home directory.
+func expandUser(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return home + path[1:], nil
+}
+
+// preprocess applies to rawVal the preprocessors named by the `pre` tag of field.
+func (s *decodeState) preprocess(field reflect.StructField, rawVal string) (string, error) {
+	tag, ok := field.Tag.Lookup(s.opts.tagNames.Pre)
+	if !ok {
+		return rawVal, nil
+	}
+
+	for _, name := range strings.Split(tag, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		fn, ok := s.opts.preprocessors[name]
+		if !ok {
+			fn, ok = builtinPreprocessors[name]
+		}
+		if !ok {
+			return "", fmt.Errorf("unknown preprocessor %q for field %q", name, field.Name)
+		}
+
+		var err error
+		if rawVal, err = fn(rawVal); err != nil {
+			return "", fmt.Errorf("preprocessor %q: %w", name, err)
+		}
+	}
+	return rawVal, nil
+}
@@ -0,0 +1,66 @@
+package xconfigdotenv_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreprocessors(t *testing.T) {
+	t.Setenv("APP_ROOT", "/srv/app")
+	home, err := os.UserHomeDir()
+	assert.NoError(t, err)
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	var config struct {
+		Name   string `pre:"trim"`
+		Data   string `pre:"expandenv"`
+		Cache  string `pre:"expanduser"`
+		Logs   string `pre:"abspath"`
+		Shout  string `pre:"trim,upper"`
+		Keep   string
+		Paths  []string `pre:"expanduser"`
+		Owners struct {
+			Team string
+		} `pre:"upper"`
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithValuePreprocessor("upper", func(rawVal string) (string, error) {
+		return strings.ToUpper(rawVal), nil
+	}))
+	data := []byte("NAME=\" app \"\nDATA='${APP_ROOT}/data'\nCACHE=~/cache\nLOGS=logs\nSHOUT=\" hi \"\nKEEP=\" ~ \"\nPATHS=~/a,/b\nOWNERS_TEAM=core")
+	err = decoder.Unmarshal(data, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, "/srv/app/data", config.Data)
+	assert.Equal(t, home+"/cache", config.Cache)
+	assert.Equal(t, filepath.Join(wd, "logs"), config.Logs)
+	assert.Equal(t, "HI", config.Shout)
+	assert.Equal(t, " ~ ", config.Keep)
+	assert.Equal(t, []string{home + "/a", "/b"}, config.Paths)
+	assert.Equal(t, "CORE", config.Owners.Team)
+}
+
+func TestPreprocessorErrors(t *testing.T) {
+	var unknown struct {
+		Name string `pre:"nope"`
+	}
+	err := xconfigdotenv.New().Unmarshal([]byte("NAME=app"), &unknown)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "NAME": unknown preprocessor "nope" for field "Name"`)
+
+	var failing struct {
+		Name string `pre:"fail"`
+	}
+	decoder := xconfigdotenv.New(xconfigdotenv.WithValuePreprocessor("fail", func(string) (string, error) {
+		return "", errors.New("boom")
+	}))
+	err = decoder.Unmarshal([]byte("NAME=app"), &failing)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "NAME": preprocessor "fail": boom`)
+}
@@ -0,0 +1,81 @@
+package xconfigdotenv
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// redactTag is the default key of the tag marking fields whose values never
+// appear in errors or metadata: `redact:"true"`.
+const redactTag = "redact"
+
+// isRedacted reports whether the field is tagged `redact:"true"`, or holds
+// credentials (see isCredentialType) and is not tagged `redact:"false"`.
+func (o *options) isRedacted(field reflect.StructField) bool {
+	redact, err := strconv.ParseBool(field.Tag.Get(o.tagNames.Redact))
+	if err != nil {
+		return isCredentialType(field.Type)
+	}
+	return redact
+}
+
+// redactValue records the forms of rawVal, the value of a redacted field,
+// to remove from the messages of the current key: the value, trimmed, and
+// its elements split on sep.
+func (s *decodeState) redactValue(rawVal, sep string) {
+	s.redactions = append(s.redactions, rawVal, strings.TrimSpace(rawVal))
+	if sep != "" && strings.Contains(rawVal, sep) {
+		for _, elem := range strings.Split(rawVal, sep) {
+			s.redactions = append(s.redactions, strings.TrimSpace(elem))
+		}
+	}
+}
+
+// redactText replaces in msg the values recorded for the current key, as
+// they are and quoted, with maskedValue.
+func (s *decodeState) redactText(msg string) string {
+	if !s.redacted {
+		return msg
+	}
+	forms := make([]string, 0, 2*len(s.redactions))
+	for _, v := range s.redactions {
+		if v == "" {
+			continue
+		}
+		forms = append(forms, v, strings.Trim(strconv.Quote(v), `"`))
+	}
+	// Longest first, so an element does not break the mask of the value
+	sort.Slice(forms, func(i, j int) bool { return len(forms[i]) > len(forms[j]) })
+	for _, v := range forms {
+		msg = strings.ReplaceAll(msg, v, maskedValue)
+	}
+	return msg
+}
+
+// redactError returns err with the values of the current key removed from
+// its message, when the key matched a redacted field.
+func (s *decodeState) redactError(err error) error {
+	if !s.redacted {
+		return err
+	}
+	return &redactedError{msg: s.redactText(err.Error()), err: err}
+}
+
+// redactedError is an error whose message has the redacted values removed.
+// It does not unwrap, so the original message cannot be reached, but
+// errors.Is still looks into the original error.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Is(target error) bool {
+	return errors.Is(e.err, target)
+}
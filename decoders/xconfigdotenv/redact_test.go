@@ -0,0 +1,53 @@
+package xconfigdotenv_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	type config struct {
+		Name string
+		Pin  int   `redact:"true"`
+		Keys []int `redact:"true"`
+		DB   struct {
+			Port int
+		} `redact:"true"`
+		Shown int `redact:"false"`
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithAllowPartial())
+	var cfg config
+	meta, err := decoder.UnmarshalWithMetadata([]byte("NAME=app\nPIN=s3cr3t\nKEYS=10,t0ken,30\nDB_PORT=' hidden '\nSHOWN=visible"), &cfg)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "DB_PORT": cannot parse "***" as int: strconv.ParseInt: parsing "***": invalid syntax
+xconfigdotenv: Unmarshal: key "KEYS": element 1: cannot parse "***" as int: strconv.ParseInt: parsing "***": invalid syntax
+xconfigdotenv: Unmarshal: key "PIN": cannot parse "***" as int: strconv.ParseInt: parsing "***": invalid syntax
+xconfigdotenv: Unmarshal: key "SHOWN": cannot parse "visible" as int: strconv.ParseInt: parsing "visible": invalid syntax`)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+	assert.NotContains(t, err.Error(), "s3cr3t")
+
+	assert.Equal(t, map[string]string{
+		"NAME":    "app",
+		"PIN":     "***",
+		"KEYS":    "***",
+		"DB_PORT": "***",
+		"SHOWN":   "visible",
+	}, meta.Flat)
+}
+
+func TestRedactWarnings(t *testing.T) {
+	var config struct {
+		Flag int `redact:"true"`
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithCoerceBoolNumeric())
+	meta, err := decoder.UnmarshalWithMetadata([]byte("FLAG=true"), &config)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, config.Flag)
+	if assert.Len(t, meta.Warnings, 1) {
+		assert.Equal(t, `coerced "***" to 1 for int`, meta.Warnings[0].Message)
+	}
+}
@@ -0,0 +1,192 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// typeKey is the subkey naming the concrete type of an interface field.
+const typeKey = "TYPE"
+
+// registeredType is a concrete type registered with RegisterType.
+type registeredType struct {
+	name     string
+	typ      reflect.Type
+	newValue func() reflect.Value
+}
+
+// registry holds the types registered with RegisterType, by interface type.
+// Like converters, the map and its slices are never modified once stored.
+var registry struct {
+	// mu serializes the registrations.
+	mu sync.Mutex
+	m  atomic.Pointer[map[reflect.Type][]registeredType]
+}
+
+// RegisterType registers newValue as the constructor of the concrete type
+// named name for the interface I, for every Decoder. newValue returns a
+// pointer to a struct, which gets its `default` tags applied; registering
+// a name again replaces its constructor.
+//
+// A field of type I, or an element of a field of type []I, then takes its
+// concrete type from its TYPE subkey, and its other subkeys go to the
+// fields of the struct:
+//
+//	xconfigdotenv.RegisterType[Handler]("http", func() Handler { return &HTTPHandler{} })
+//
+//	HANDLERS_0_TYPE=http
+//	HANDLERS_0_PORT=80
+//
+// The TYPE key is looked up in the whole input when the first key of the
+// field is decoded, so keys may come in any order. A slice grows to the
+// largest index given, keeping the elements already decoded: an index
+// without keys leaves a nil element. Marshal writes the TYPE key back.
+//
+// As with RegisterConverter, registering is safe while other goroutines
+// decode, but the types are best registered before their first use.
+//
+// RegisterType panics when I is not an interface type.
+func RegisterType[I any](name string, newValue func() I) {
+	iface := reflect.TypeFor[I]()
+	if iface.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("xconfigdotenv: RegisterType: %s is not an interface type", iface))
+	}
+
+	rt := registeredType{
+		name: name,
+		typ:  reflect.TypeOf(newValue()),
+		newValue: func() reflect.Value {
+			return reflect.ValueOf(newValue())
+		},
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	m := make(map[reflect.Type][]registeredType)
+	if old := registry.m.Load(); old != nil {
+		m = maps.Clone(*old)
+	}
+	types := slices.Clone(m[iface])
+	i := slices.IndexFunc(types, func(t registeredType) bool { return t.name == name })
+	if i >= 0 {
+		types[i] = rt
+	} else {
+		types = append(types, rt)
+	}
+	m[iface] = types
+	registry.m.Store(&m)
+}
+
+// registeredTypes returns the types registered for the interface type t.
+func registeredTypes(t reflect.Type) []registeredType {
+	m := registry.m.Load()
+	if m == nil {
+		return nil
+	}
+	return (*m)[t]
+}
+
+// isRegisteredInterface reports whether t is an interface type with
+// registered concrete types.
+func isRegisteredInterface(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && len(registeredTypes(t)) > 0
+}
+
+// registeredName returns the name the concrete type t is registered under
+// for the interface type iface.
+func registeredName(iface, t reflect.Type) (string, bool) {
+	for _, rt := range registeredTypes(iface) {
+		if rt.typ == t {
+			return rt.name, true
+		}
+	}
+	return "", false
+}
+
+// assignRegistered puts rawVal in the interface v, whose concrete type is
+// given by the TYPE subkey, leftover holding the segments after the field.
+// A value already in v that is not a non-nil pointer, e.g. a struct value,
+// cannot be decoded into and is replaced by a new value of the TYPE type.
+func (s *decodeState) assignRegistered(v reflect.Value, field reflect.StructField, leftover []string, rawVal, path string) (bool, error) {
+	if v.IsNil() || v.Elem().Kind() != reflect.Ptr || v.Elem().IsNil() {
+		prefix := s.parts[:len(s.parts)-len(leftover)]
+		name, ok := s.lookupKey(append(slices.Clone(prefix), typeKey))
+		if !ok {
+			return true, fmt.Errorf("no %s_%s key gives the type of field %q", strings.Join(prefix, "_"), typeKey, field.Name)
+		}
+		value, err := s.newRegistered(v.Type(), name, field)
+		if err != nil {
+			return true, err
+		}
+		v.Set(value)
+	}
+
+	// The TYPE key itself only chose the type
+	if len(leftover) == 1 && s.sameSegment(leftover[0], typeKey) {
+		return true, nil
+	}
+	return s.assignValue(v.Elem().Elem(), leftover, rawVal, path)
+}
+
+// newRegistered returns a new value of the concrete type named name for the
+// interface type iface.
+func (s *decodeState) newRegistered(iface reflect.Type, name string, field reflect.StructField) (reflect.Value, error) {
+	for _, rt := range registeredTypes(iface) {
+		if rt.name != name {
+			continue
+		}
+		value := rt.newValue()
+		if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("type %q of %s must be a non-nil pointer to a struct, got %s", name, iface, rt.typ)
+		}
+		if err := s.applyDefaults(value.Elem()); err != nil {
+			return reflect.Value{}, err
+		}
+		return value, nil
+	}
+	return reflect.Value{}, fmt.Errorf("unknown type %q for %s field %q", name, iface, field.Name)
+}
+
+// lookupKey returns the value of the key of the input whose segments are
+// parts, compared as the options compare names. When several keys match,
+// e.g. H_TYPE and h_type, the first one in sorted order wins. The keys are
+// indexed on the first lookup of the decoding.
+func (s *decodeState) lookupKey(parts []string) (string, bool) {
+	if s.flatIndex == nil {
+		s.flatIndex = make(map[string]string, len(s.flat))
+		for _, rawKey := range slices.Sorted(maps.Keys(s.flat)) {
+			index := s.indexKey(s.opts.splitKey(rawKey))
+			if _, ok := s.flatIndex[index]; !ok {
+				s.flatIndex[index] = s.flat[rawKey]
+			}
+		}
+	}
+
+	rawVal, ok := s.flatIndex[s.indexKey(parts)]
+	return rawVal, ok
+}
+
+// indexKey returns the key of the segments parts in flatIndex: equal for
+// segments sameSegment reports the same.
+func (s *decodeState) indexKey(parts []string) string {
+	key := strings.Join(parts, "\x00")
+	if s.opts.caseSensitive {
+		return key
+	}
+	return strings.ToLower(key)
+}
+
+// sameSegment reports whether the key segments a and b are the same, as
+// the options compare names.
+func (s *decodeState) sameSegment(a, b string) bool {
+	if s.opts.caseSensitive {
+		return a == b
+	}
+	return strings.EqualFold(a, b)
+}
@@ -0,0 +1,107 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type handler interface {
+	Kind() string
+}
+
+type httpHandler struct {
+	Port int
+	Path string `default:"/"`
+}
+
+func (*httpHandler) Kind() string { return "http" }
+
+type grpcHandler struct {
+	Addr string
+}
+
+func (*grpcHandler) Kind() string { return "grpc" }
+
+func init() {
+	xconfigdotenv.RegisterType("http", func() handler { return &httpHandler{} })
+	xconfigdotenv.RegisterType("grpc", func() handler { return &grpcHandler{} })
+}
+
+func TestRegisterType(t *testing.T) {
+	type config struct {
+		Main     handler
+		Handlers []handler
+	}
+
+	data := []byte(`MAIN_TYPE=grpc
+MAIN_ADDR=:9000
+HANDLERS_0_PORT=80
+HANDLERS_0_TYPE=http
+handlers_2_type=grpc
+HANDLERS_2_ADDR=:9001`)
+
+	var cfg config
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(data, &cfg))
+	assert.Equal(t, &grpcHandler{Addr: ":9000"}, cfg.Main)
+	assert.Equal(t, []handler{&httpHandler{Port: 80, Path: "/"}, nil, &grpcHandler{Addr: ":9001"}}, cfg.Handlers)
+
+	out, err := decoder.Marshal(&cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "MAIN_TYPE=grpc\nMAIN_ADDR=:9000\nHANDLERS_0_TYPE=http\nHANDLERS_0_PORT=80\nHANDLERS_0_PATH=/\nHANDLERS_2_TYPE=grpc\nHANDLERS_2_ADDR=:9001\n", string(out))
+
+	err = decoder.Unmarshal([]byte("HANDLERS_0_PORT=80"), &config{})
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "HANDLERS_0_PORT": no HANDLERS_0_TYPE key gives the type of field "Handlers"`)
+
+	err = decoder.Unmarshal([]byte("MAIN_TYPE=ftp"), &config{})
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "MAIN_TYPE": unknown type "ftp" for xconfigdotenv_test.handler field "Main"`)
+}
+
+type namer interface {
+	Name() string
+}
+
+type plainNamer struct {
+	Port int
+}
+
+func (plainNamer) Name() string { return "plain" }
+
+func init() {
+	xconfigdotenv.RegisterType("plain", func() namer { return &plainNamer{} })
+}
+
+func TestRegisterTypePreset(t *testing.T) {
+	type config struct {
+		H namer
+	}
+
+	data := []byte("H_TYPE=plain\nH_PORT=80")
+
+	cfg := config{H: plainNamer{Port: 1}}
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &cfg))
+	assert.Equal(t, &plainNamer{Port: 80}, cfg.H)
+
+	cfg = config{H: (*plainNamer)(nil)}
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &cfg))
+	assert.Equal(t, &plainNamer{Port: 80}, cfg.H)
+
+	cfg = config{H: &plainNamer{Port: 1}}
+	assert.NoError(t, xconfigdotenv.New().Unmarshal([]byte("H_PORT=80"), &cfg))
+	assert.Equal(t, &plainNamer{Port: 80}, cfg.H)
+}
+
+func TestRegisterTypeCaseConflict(t *testing.T) {
+	type config struct {
+		H handler
+	}
+
+	data := []byte("h_type=grpc\nH_TYPE=http\nH_PORT=80")
+	for range 20 {
+		var cfg config
+		assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &cfg))
+		assert.Equal(t, &httpHandler{Port: 80, Path: "/"}, cfg.H)
+	}
+}
@@ -0,0 +1,114 @@
+package xconfigdotenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// requiredTag is the tag marking a field the input must set, shared with
+// the markdown documentation of xconfig: `required:""` or `required:"true"`.
+const requiredTag = "required"
+
+// ErrMissingRequired is returned, wrapped, when required fields are missing
+// from the input.
+var ErrMissingRequired = errors.New("missing required fields")
+
+// isRequired reports whether the field is tagged as required, with an empty
+// value or one parsing as true.
+func (o *options) isRequired(field reflect.StructField) bool {
+	tag, ok := field.Tag.Lookup(o.tagNames.Required)
+	if !ok {
+		return false
+	}
+	required, err := strconv.ParseBool(tag)
+	return err != nil || required
+}
+
+// checkRequired fails with ErrMissingRequired for the required fields of
+// the struct v which no key set and which still hold their zero value, so
+// the values given in the struct beforehand count as set. The fields are
+// searched through structs and non-nil pointers to structs, so the required
+// fields of an optional *Sub section left nil are not missing.
+//
+// The callback of WithOnMissingRequired is called for each missing field,
+// in field declaration order, before the error is built: the fields it
+// handles are set from the value it returns, and the error lists the others
+// at once, in the same order.
+func (s *decodeState) checkRequired(v reflect.Value) error {
+	if !s.opts.hasTag(v.Type(), s.opts.tagNames.Required, map[reflect.Type]bool{}) {
+		return nil
+	}
+	var missing []string
+	if err := s.collectMissing(v, "", &missing); err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrMissingRequired, strings.Join(missing, ", "))
+}
+
+// collectMissing does the work of checkRequired for the struct v at path.
+func (s *decodeState) collectMissing(v reflect.Value, path string, missing *[]string) error {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if s.opts.skipsField(field) {
+			continue
+		}
+		fieldVal := getFieldValue(v, i)
+		fieldPath := joinPath(path, field.Name)
+
+		if s.opts.isRequired(field) && fieldVal.IsZero() && !s.isAssigned(fieldPath) {
+			handled, err := s.provideRequired(fieldVal, field, fieldPath)
+			if err != nil {
+				return err
+			}
+			if !handled {
+				*missing = append(*missing, fieldPath)
+				continue
+			}
+		}
+
+		if fieldVal.Kind() == reflect.Ptr && !fieldVal.IsNil() {
+			fieldVal = fieldVal.Elem()
+		}
+		if fieldVal.Kind() == reflect.Struct && isStructValue(fieldVal.Type()) {
+			if err := s.collectMissing(fieldVal, fieldPath, missing); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isAssigned reports whether a key set the field at path, or a field,
+// element or entry inside it.
+func (s *decodeState) isAssigned(path string) bool {
+	for assigned := range s.assigned {
+		if assigned == path || strings.HasPrefix(assigned, path+".") || strings.HasPrefix(assigned, path+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// provideRequired sets the missing required field at path from the value
+// the callback of WithOnMissingRequired returns for it, if it handles it.
+func (s *decodeState) provideRequired(fieldVal reflect.Value, field reflect.StructField, path string) (bool, error) {
+	if s.opts.onMissingRequired == nil {
+		return false, nil
+	}
+	rawVal, handled := s.opts.onMissingRequired(path)
+	if !handled {
+		return false, nil
+	}
+	s.timeLayout = s.opts.timeLayout(field)
+	if err := s.reportSet(s.setFieldValue(fieldVal, field, rawVal), path, fieldVal); err != nil {
+		return true, fmt.Errorf("required field %s: %w", path, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,79 @@
+package xconfigdotenv_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type requiredConfig struct {
+	Name string `required:""`
+	Port int    `required:"true"`
+	Mode string `required:"false"`
+	DB   struct {
+		Host string `required:""`
+		User string `required:""`
+	}
+	Cache *struct {
+		Addr string `required:""`
+	}
+	Labels map[string]string `required:""`
+}
+
+func TestRequired(t *testing.T) {
+	decoder := xconfigdotenv.New()
+
+	var config requiredConfig
+	assert.NoError(t, decoder.Unmarshal([]byte("NAME=app\nPORT=0\nDB_HOST=db\nDB_USER=u\nLABELS_team=core"), &config))
+	assert.Equal(t, 0, config.Port)
+	assert.Nil(t, config.Cache)
+
+	// every missing field is reported at once, a section left nil is not
+	err := decoder.Unmarshal([]byte("NAME=app\nDB_HOST=db"), &requiredConfig{})
+	assert.EqualError(t, err, "xconfigdotenv: Unmarshal: missing required fields: Port, DB.User, Labels")
+	assert.True(t, errors.Is(err, xconfigdotenv.ErrMissingRequired))
+
+	err = decoder.Unmarshal([]byte("CACHE_OTHER=1"), &requiredConfig{})
+	assert.EqualError(t, err, "xconfigdotenv: Unmarshal: missing required fields: Name, Port, DB.Host, DB.User, Labels")
+
+	// values set beforehand count
+	config = requiredConfig{Name: "app", Port: 80}
+	config.DB.Host, config.DB.User = "db", "u"
+	config.Labels = map[string]string{"team": "core"}
+	assert.NoError(t, decoder.Unmarshal([]byte("CACHE_ADDR=c:1"), &config))
+
+	// a key failing to decode is reported as such rather than as missing
+	err = xconfigdotenv.New(xconfigdotenv.WithAllowPartial()).Unmarshal([]byte("PORT=x\nNAME=app\nDB_USER=u\nLABELS_a=b"), &requiredConfig{})
+	assert.EqualError(t, err, "xconfigdotenv: Unmarshal: key \"PORT\": cannot parse \"x\" as int: strconv.ParseInt: parsing \"x\": invalid syntax\n"+
+		"xconfigdotenv: Unmarshal: missing required fields: DB.Host")
+}
+
+func TestOnMissingRequired(t *testing.T) {
+	var asked []string
+	decoder := xconfigdotenv.New(xconfigdotenv.WithOnMissingRequired(func(fieldPath string) (string, bool) {
+		asked = append(asked, fieldPath)
+		switch fieldPath {
+		case "Port":
+			return "8080", true
+		case "DB.User":
+			return "admin", true
+		}
+		return "", false
+	}))
+
+	var config requiredConfig
+	err := decoder.Unmarshal([]byte("NAME=app\nDB_HOST=db"), &config)
+	assert.EqualError(t, err, "xconfigdotenv: Unmarshal: missing required fields: Labels")
+	assert.Equal(t, []string{"Port", "DB.User", "Labels"}, asked)
+	assert.Equal(t, 8080, config.Port)
+	assert.Equal(t, "admin", config.DB.User)
+
+	// a provided value is decoded like the value of a key
+	decoder = xconfigdotenv.New(xconfigdotenv.WithOnMissingRequired(func(fieldPath string) (string, bool) {
+		return "many", true
+	}))
+	err = decoder.Unmarshal([]byte("NAME=app"), &requiredConfig{})
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: required field Port: cannot parse "many" as int: strconv.ParseInt: parsing "many": invalid syntax`)
+}
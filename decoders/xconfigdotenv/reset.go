@@ -0,0 +1,23 @@
+package xconfigdotenv
+
+import "reflect"
+
+// resetField zeroes the slice, map or pointer fieldVal, at path, the first
+// time the current run reaches it under WithReset, so it only holds what the
+// input gives it.
+func (s *decodeState) resetField(fieldVal reflect.Value, path string) error {
+	if !s.opts.reset || s.resetPaths[path] {
+		return nil
+	}
+	switch fieldVal.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Ptr:
+	default:
+		return nil
+	}
+
+	if s.resetPaths == nil {
+		s.resetPaths = make(map[string]bool)
+	}
+	s.resetPaths[path] = true
+	return setWithReflect(fieldVal, reflect.Zero(fieldVal.Type()))
+}
@@ -0,0 +1,63 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type resetConfig struct {
+	Hosts  []string
+	Ports  []int
+	Labels map[string]string
+	DB     *struct {
+		Host string
+		Pool int `default:"4"`
+	}
+	Extra map[string]string `env:",inline"`
+	Keep  []string
+}
+
+func TestReset(t *testing.T) {
+	first := []byte(`HOSTS_0=a
+HOSTS_1=b
+PORTS=80,443
+LABELS_team=core
+LABELS_env=prod
+DB_HOST=db1
+DB_POOL=8
+FOO=1
+KEEP=x,y`)
+	second := []byte(`HOSTS_0=c
+PORTS=8080
+LABELS_env=dev
+DB_HOST=db2
+BAR=2`)
+
+	// without the option the previous elements and entries remain
+	var config resetConfig
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(first, &config))
+	assert.NoError(t, decoder.Unmarshal(second, &config))
+	assert.Equal(t, []string{"c", "b"}, config.Hosts)
+	assert.Equal(t, map[string]string{"team": "core", "env": "dev"}, config.Labels)
+	assert.Equal(t, 8, config.DB.Pool)
+
+	config = resetConfig{}
+	decoder = xconfigdotenv.New(xconfigdotenv.WithReset())
+	assert.NoError(t, decoder.Unmarshal(first, &config))
+	db := config.DB
+	assert.NoError(t, decoder.Unmarshal(second, &config))
+	assert.Equal(t, []string{"c"}, config.Hosts)
+	assert.Equal(t, []int{8080}, config.Ports)
+	assert.Equal(t, map[string]string{"env": "dev"}, config.Labels)
+	assert.Equal(t, map[string]string{"BAR": "2"}, config.Extra)
+	// the pointer is allocated again, with its defaults
+	assert.Equal(t, "db2", config.DB.Host)
+	assert.Equal(t, 4, config.DB.Pool)
+	assert.NotSame(t, db, config.DB)
+	assert.Equal(t, "db1", db.Host)
+	// fields no key reaches are left alone
+	assert.Equal(t, []string{"x", "y"}, config.Keep)
+}
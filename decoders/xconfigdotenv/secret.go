@@ -0,0 +1,57 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SecretResolver fetches secrets from a secret store, so the input only
+// holds references to them, e.g. DB_PASSWORD=secret://vault/db/password.
+//
+// The decoder resolves a value when it starts with the scheme of a resolver
+// set with WithSecretResolver followed by "://", once the key matched a
+// field: the values of unknown keys are never resolved. The resolved value
+// then goes through the `pre` and `format` tags and the conversion as the
+// raw value would. Resolve is passed the whole URI.
+type SecretResolver interface {
+	Resolve(uri string) (string, error)
+}
+
+// SecretResolverFunc is a function used as a SecretResolver.
+type SecretResolverFunc func(uri string) (string, error)
+
+// Resolve calls f.
+func (f SecretResolverFunc) Resolve(uri string) (string, error) {
+	return f(uri)
+}
+
+// resolveOnce resolves rawVal unless the current key already had it resolved.
+func (s *decodeState) resolveOnce(rawVal string) (string, error) {
+	if s.resolved {
+		return rawVal, nil
+	}
+	s.resolved = true
+	return s.resolveSecret(rawVal)
+}
+
+// resolveSecret returns the secret rawVal refers to when it is a URI of a
+// scheme with a resolver, and rawVal otherwise.
+func (s *decodeState) resolveSecret(rawVal string) (string, error) {
+	if len(s.opts.secretResolvers) == 0 {
+		return rawVal, nil
+	}
+	scheme, _, ok := strings.Cut(rawVal, "://")
+	if !ok {
+		return rawVal, nil
+	}
+	resolver, ok := s.opts.secretResolvers[scheme]
+	if !ok {
+		return rawVal, nil
+	}
+
+	secret, err := resolver.Resolve(rawVal)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve secret %q: %w", rawVal, err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,57 @@
+package xconfigdotenv_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretResolver(t *testing.T) {
+	secrets := map[string]string{
+		"secret://vault/db/password": "s3cr3t",
+		"secret://vault/db/port":     "5432",
+		"aws-sm://api-key":           "key",
+	}
+	var calls []string
+	resolver := xconfigdotenv.SecretResolverFunc(func(uri string) (string, error) {
+		calls = append(calls, uri)
+		if secret, ok := secrets[uri]; ok {
+			return secret, nil
+		}
+		return "", errors.New("not found")
+	})
+
+	var config struct {
+		DB struct {
+			Password string
+			Port     int
+		}
+		APIKey string `env:"API_KEY"`
+		URL    string
+		Extra  map[string]string `env:",inline"`
+	}
+
+	decoder := xconfigdotenv.New(
+		xconfigdotenv.WithSecretResolver("secret", resolver),
+		xconfigdotenv.WithSecretResolver("aws-sm", resolver),
+	)
+	data := []byte("DB_PASSWORD=secret://vault/db/password\nDB_PORT=secret://vault/db/port\nAPI_KEY=aws-sm://api-key\nURL=https://example.com\nTOKEN=secret://vault/db/password")
+	err := decoder.Unmarshal(data, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "s3cr3t", config.DB.Password)
+	assert.Equal(t, 5432, config.DB.Port)
+	assert.Equal(t, "key", config.APIKey)
+	assert.Equal(t, "https://example.com", config.URL)
+	assert.Equal(t, map[string]string{"TOKEN": "s3cr3t"}, config.Extra)
+	assert.Len(t, calls, 4)
+
+	err = decoder.Unmarshal([]byte("DB_PASSWORD=secret://vault/missing"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "DB_PASSWORD": cannot resolve secret "secret://vault/missing": not found`)
+
+	// without resolver the values are kept
+	assert.NoError(t, xconfigdotenv.New().Unmarshal([]byte("API_KEY=aws-sm://api-key"), &config))
+	assert.Equal(t, "aws-sm://api-key", config.APIKey)
+}
@@ -0,0 +1,52 @@
+package xconfigdotenv
+
+import (
+	"reflect"
+	"strings"
+)
+
+// isSetType reports whether t is a map with empty struct values, such as
+// map[string]struct{}, which is decoded as a set of its keys.
+func isSetType(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Struct && t.Elem().NumField() == 0
+}
+
+// isFlagMapType reports whether t is a map with boolean values, such as
+// map[string]bool, whose keys are also given as a list of the keys set to
+// true, like the members of a set: FLAGS=a,b gives {a: true, b: true}.
+func isFlagMapType(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Bool
+}
+
+// acceptsMembers reports whether the map type t takes a list of members as
+// its value, see assignSet.
+func acceptsMembers(t reflect.Type) bool {
+	return isSetType(t) || isFlagMapType(t)
+}
+
+// assignSet adds to the set fieldVal the members of rawVal separated by sep,
+// so FLAGS=a,b gives the same set {a, b} as FLAGS_a= and FLAGS_b=. Spaces
+// around the members and empty members are ignored. The members of a map
+// with boolean values are set to true, as by FLAGS_a=true.
+func (s *decodeState) assignSet(fieldVal reflect.Value, rawVal, sep, fieldPath string) error {
+	if fieldVal.IsNil() {
+		if err := setWithReflect(fieldVal, reflect.MakeMap(fieldVal.Type())); err != nil {
+			return err
+		}
+	}
+
+	member := reflect.Zero(fieldVal.Type().Elem())
+	if isFlagMapType(fieldVal.Type()) {
+		member = reflect.ValueOf(true).Convert(fieldVal.Type().Elem())
+	}
+	for _, key := range splitElems(rawVal, sep) {
+		key = strings.TrimSpace(key)
+		if key == "" || !s.claim(fieldPath+"["+key+"]") {
+			continue
+		}
+		if err := storeMapValue(fieldVal, key, member); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSet(t *testing.T) {
+	type empty struct{}
+
+	var config struct {
+		Flags    map[string]struct{}
+		Features map[string]empty
+		Modules  map[string]struct{}
+	}
+
+	data := []byte("FLAGS_a=\nFLAGS_b=1\nFEATURES=beta, dark-mode,,\nMODULES=x\nMODULES_y=")
+	err := xconfigdotenv.New().Unmarshal(data, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]struct{}{"a": {}, "b": {}}, config.Flags)
+	assert.Equal(t, map[string]empty{"beta": {}, "dark-mode": {}}, config.Features)
+	assert.Equal(t, map[string]struct{}{"x": {}, "y": {}}, config.Modules)
+}
+
+func TestFlagMap(t *testing.T) {
+	var config struct {
+		Flags    map[string]bool
+		Features map[string]bool `sep:";"`
+	}
+
+	data := []byte(`FLAGS=a, b
+FLAGS_c=
+FLAGS_d=false
+FLAGS_e=TRUE
+FEATURES=x;y`)
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+	assert.Equal(t, map[string]bool{"a": true, "b": true, "c": true, "d": false, "e": true}, config.Flags)
+	assert.Equal(t, map[string]bool{"x": true, "y": true}, config.Features)
+
+	// the entries are written one by one, keeping the false ones
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "FLAGS_a=true\nFLAGS_b=true\nFLAGS_c=true\nFLAGS_d=false\nFLAGS_e=true\nFEATURES_x=true\nFEATURES_y=true\n", string(out))
+
+	// an explicit entry wins over the list, whatever the order of the keys
+	config.Flags = nil
+	assert.NoError(t, decoder.Unmarshal([]byte("FLAGS_a=false\nFLAGS=a,b"), &config))
+	assert.Equal(t, map[string]bool{"a": false, "b": true}, config.Flags)
+
+	err = decoder.Unmarshal([]byte("FLAGS_a=maybe"), &config)
+	assert.ErrorContains(t, err, `cannot parse "maybe" as bool`)
+}
@@ -0,0 +1,53 @@
+package xconfigdotenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrShapeMismatch is returned when a key gives a single value to a field
+// expecting subkeys, such as DATABASE=foo for a struct field Database, or
+// subkeys to a field expecting a single value, such as PORT_X=1 for an int
+// field Port.
+var ErrShapeMismatch = errors.New("shape mismatch")
+
+// acceptsScalar reports whether a field of type t can be set from a single
+// value, rather than only through subkeys.
+func acceptsScalar(t reflect.Type) bool {
+	if converter(t) != nil || isAtomicType(t) || isBinaryType(t) || isPflagType(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return acceptsScalar(t.Elem())
+	case reflect.Struct, reflect.Map:
+		return false
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8 || acceptsScalar(t.Elem())
+	}
+	return true
+}
+
+// scalarToContainer returns the error for the current key giving a single
+// value to field, of type t, which expects subkeys.
+func (s *decodeState) scalarToContainer(field reflect.StructField, t reflect.Type) error {
+	sub := "<field>"
+	switch {
+	case derefType(t).Kind() == reflect.Map, reflect.PointerTo(derefType(t)).Implements(mapSetterType):
+		sub = "<key>"
+	case derefType(t).Kind() == reflect.Slice:
+		sub = "0_<field>"
+	}
+	return fmt.Errorf("%w: cannot assign a scalar to %s field %q; did you mean %s_%s?",
+		ErrShapeMismatch, derefType(t).Kind(), field.Name, s.key, sub)
+}
+
+// containerToScalar returns the error for the current key giving subkeys,
+// the leftover segments, to field, of type t, which expects a single value.
+func (s *decodeState) containerToScalar(field reflect.StructField, t reflect.Type, leftover []string) error {
+	key := strings.TrimSuffix(s.key, "_"+strings.Join(leftover, "_"))
+	return fmt.Errorf("%w: cannot assign subkey %q to %s field %q; did you mean %s?",
+		ErrShapeMismatch, strings.Join(leftover, "_"), t.Kind(), field.Name, key)
+}
@@ -0,0 +1,43 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShapeMismatch(t *testing.T) {
+	var config struct {
+		Database struct {
+			Host string
+		}
+		Cache  *struct{ Size int }
+		Labels map[string]string
+		Steps  xconfigdotenv.OrderedMap
+		Hosts  []struct{ Addr string }
+		Port   int
+		Name   *string
+	}
+
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{"DATABASE=foo", `key "DATABASE": shape mismatch: cannot assign a scalar to struct field "Database"; did you mean DATABASE_<field>?`},
+		{"CACHE=big", `key "CACHE": shape mismatch: cannot assign a scalar to struct field "Cache"; did you mean CACHE_<field>?`},
+		{"LABELS=a", `key "LABELS": shape mismatch: cannot assign a scalar to map field "Labels"; did you mean LABELS_<key>?`},
+		{"STEPS=a", `key "STEPS": shape mismatch: cannot assign a scalar to struct field "Steps"; did you mean STEPS_<key>?`},
+		{"HOSTS=a,b", `key "HOSTS": shape mismatch: cannot assign a scalar to slice field "Hosts"; did you mean HOSTS_0_<field>?`},
+		{"PORT_NUMBER=80", `key "PORT_NUMBER": shape mismatch: cannot assign subkey "NUMBER" to int field "Port"; did you mean PORT?`},
+		{"NAME_FIRST=a", `key "NAME_FIRST": shape mismatch: cannot assign subkey "FIRST" to string field "Name"; did you mean NAME?`},
+		{"DATABASE_HOST_NAME=db", `key "DATABASE_HOST_NAME": shape mismatch: cannot assign subkey "NAME" to string field "Host"; did you mean DATABASE_HOST?`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			err := xconfigdotenv.New().Unmarshal([]byte(tt.data), &config)
+			assert.ErrorIs(t, err, xconfigdotenv.ErrShapeMismatch)
+			assert.EqualError(t, err, "xconfigdotenv: Unmarshal: "+tt.err)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package xconfigdotenv
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// sortByTag is the tag naming the field the elements of a slice field are
+// sorted by, see sortSlices.
+const sortByTag = "sortby"
+
+// sortSlices sorts the slice fields of the struct v, at path, tagged with
+// the field of their elements to sort them by, e.g. `sortby:"Priority"` on
+// a Routes []Route field, so the order of the elements follows their
+// priorities rather than the indices of their keys. It runs once every key
+// is decoded, the Unmarshaler fields included, and the sort is stable, so
+// the elements with equal fields keep the order of their indices. The field
+// must be a number, a string or a boolean, and nil elements come last. The
+// slices are searched through struct and pointer fields and the elements of
+// slices, not through maps, whose values receive copies.
+func (s *decodeState) sortSlices(v reflect.Value, path string) error {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if s.opts.skipsField(field) {
+			continue
+		}
+		name, tagged := field.Tag.Lookup(s.opts.tagNames.SortBy)
+		nested := s.opts.hasTag(field.Type, s.opts.tagNames.SortBy, map[reflect.Type]bool{})
+		if !tagged && !nested {
+			continue
+		}
+		fieldVal := getFieldValue(v, i)
+		fieldPath := joinPath(path, field.Name)
+
+		if tagged && fieldVal.Kind() == reflect.Slice {
+			if err := sortSlice(fieldVal, name); err != nil {
+				return fmt.Errorf("sortby of field %s: %w", fieldPath, err)
+			}
+		}
+		if nested {
+			if err := s.sortSlicesIn(fieldVal, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortSlicesIn sorts the tagged slices of the structs held by v, at path.
+func (s *decodeState) sortSlicesIn(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return s.sortSlicesIn(v.Elem(), path)
+		}
+	case reflect.Struct:
+		return s.sortSlices(v, path)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := s.sortSlicesIn(v.Index(i), path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hasTag reports whether a field with the tag key, such as `sortby`, is
+// reachable from the type t through the types sortSlices searches.
+func (o *options) hasTag(t reflect.Type, key string, visited map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return false
+	}
+	visited[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if o.skipsField(field) {
+			continue
+		}
+		if _, ok := field.Tag.Lookup(key); ok || o.hasTag(field.Type, key, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortSlice sorts the elements of the slice v, structs or pointers to
+// structs, by their field name.
+func sortSlice(v reflect.Value, name string) error {
+	st := derefType(v.Type().Elem())
+	if st.Kind() != reflect.Struct {
+		return fmt.Errorf("expecting a slice of structs, got %s", v.Type())
+	}
+	sf, ok := st.FieldByName(name)
+	if !ok {
+		return fmt.Errorf("no field %q in %s", name, st)
+	}
+	compare, ok := sortCompare(sf.Type.Kind())
+	if !ok {
+		return fmt.Errorf("cannot sort by field %q of type %s, expecting a number, a string or a boolean", name, sf.Type)
+	}
+
+	keys := make([]reflect.Value, v.Len())
+	for i := range keys {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				continue
+			}
+			elem = elem.Elem()
+		}
+		if key, err := elem.FieldByIndexErr(sf.Index); err == nil {
+			keys[i] = key
+		}
+	}
+
+	// Sort the indices, then move the elements accordingly
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := keys[order[i]], keys[order[j]]
+		if !a.IsValid() || !b.IsValid() {
+			return a.IsValid()
+		}
+		return compare(a, b) < 0
+	})
+	sorted := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+	for i, from := range order {
+		sorted.Index(i).Set(v.Index(from))
+	}
+	reflect.Copy(v, sorted)
+	return nil
+}
+
+// sortCompare returns the comparison of the values of kind, if they can be
+// sorted.
+func sortCompare(kind reflect.Kind) (func(a, b reflect.Value) int, bool) {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(a, b reflect.Value) int { return cmp.Compare(a.Int(), b.Int()) }, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(a, b reflect.Value) int { return cmp.Compare(a.Uint(), b.Uint()) }, true
+	case reflect.Float32, reflect.Float64:
+		return func(a, b reflect.Value) int { return cmp.Compare(a.Float(), b.Float()) }, true
+	case reflect.String:
+		return func(a, b reflect.Value) int { return cmp.Compare(a.String(), b.String()) }, true
+	case reflect.Bool:
+		return func(a, b reflect.Value) int {
+			switch {
+			case a.Bool() == b.Bool():
+				return 0
+			case b.Bool():
+				return -1
+			}
+			return 1
+		}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,51 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type sortRoute struct {
+	Path     string
+	Priority int
+}
+
+func TestSortBy(t *testing.T) {
+	var config struct {
+		Routes []sortRoute `sortby:"Priority"`
+		Groups []struct {
+			Name  string
+			Hosts []*struct{ Addr string } `sortby:"Addr"`
+		}
+	}
+
+	data := []byte(`ROUTES_0_PATH=/
+ROUTES_0_PRIORITY=30
+ROUTES_1_PATH=/api
+ROUTES_1_PRIORITY=10
+ROUTES_2_PATH=/admin
+ROUTES_2_PRIORITY=20
+ROUTES_3_PATH=/health
+ROUTES_3_PRIORITY=10
+GROUPS_0_NAME=db
+GROUPS_0_HOSTS_0_ADDR=db2
+GROUPS_0_HOSTS_2_ADDR=db1`)
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &config))
+
+	// equal priorities keep the order of their indices
+	assert.Equal(t, []sortRoute{{"/api", 10}, {"/health", 10}, {"/admin", 20}, {"/", 30}}, config.Routes)
+	// the element missing at index 1 stays nil and comes last
+	if assert.Len(t, config.Groups, 1) && assert.Len(t, config.Groups[0].Hosts, 3) {
+		assert.Equal(t, "db1", config.Groups[0].Hosts[0].Addr)
+		assert.Equal(t, "db2", config.Groups[0].Hosts[1].Addr)
+		assert.Nil(t, config.Groups[0].Hosts[2])
+	}
+
+	var wrong struct {
+		Routes []sortRoute `sortby:"Weight"`
+	}
+	err := xconfigdotenv.New().Unmarshal([]byte("ROUTES_0_PATH=/"), &wrong)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: sortby of field Routes: no field "Weight" in xconfigdotenv_test.sortRoute`)
+}
@@ -0,0 +1,117 @@
+package xconfigdotenv
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// CaseStyle is a convention for writing keys, see WithCaseStyle.
+type CaseStyle int
+
+const (
+	// CaseSnake splits keys on '_': DATABASE_URL. It is always enabled.
+	CaseSnake CaseStyle = iota
+	// CaseDot splits keys on '.': database.url.
+	CaseDot
+	// CaseCamel splits keys on case boundaries: databaseUrl, DatabaseURL,
+	// HTTPServer (HTTP and Server).
+	CaseCamel
+	// CaseKebab splits keys on '-': database-url. The .env syntax does not
+	// allow '-' in keys, so such keys come from a Parser given with
+	// WithParser.
+	CaseKebab
+)
+
+// KeyCase is the case of the keys written by Marshal, see WithKeyCaseOutput.
+type KeyCase int
+
+const (
+	// KeyCaseScreamingSnake writes upper snake case keys: MAX_CONNS,
+	// DB_HOST. It is the default. Under WithCaseSensitive, the field
+	// names keep their case instead, Max_Conns, so they read back.
+	KeyCaseScreamingSnake KeyCase = iota
+	// KeyCaseSnake writes lower snake case keys: max_conns, db_host.
+	KeyCaseSnake
+	// KeyCaseKebab writes lower kebab case keys, joining every segment
+	// with '-': max-conns, db-host, hosts-0-addr. They read back with
+	// CaseKebab only.
+	KeyCaseKebab
+)
+
+// splitKey splits the key into its segments, the words of the key styles.
+func (o *options) splitKey(key string) []string {
+	dot := slices.Contains(o.caseStyles, CaseDot)
+	camel := slices.Contains(o.caseStyles, CaseCamel)
+	kebab := slices.Contains(o.caseStyles, CaseKebab)
+	if !dot && !camel && !kebab {
+		return strings.Split(key, "_")
+	}
+
+	var parts []string
+	for _, part := range strings.Split(key, "_") {
+		words := []string{part}
+		if dot {
+			words = strings.Split(part, ".")
+		}
+		if kebab {
+			words = splitEach(words, "-")
+		}
+		for _, word := range words {
+			if camel {
+				parts = append(parts, splitCamel(word)...)
+			} else {
+				parts = append(parts, word)
+			}
+		}
+	}
+	return parts
+}
+
+// splitEach splits each of the words on sep.
+func splitEach(words []string, sep string) []string {
+	var split []string
+	for _, word := range words {
+		split = append(split, strings.Split(word, sep)...)
+	}
+	return split
+}
+
+// nameKey returns the key segment of the Go name of a field, or of another
+// name derived from the code rather than given by a tag, in the KeyCase.
+func (o *options) nameKey(name string) string {
+	switch o.keyCase {
+	case KeyCaseSnake:
+		return strings.ToLower(snakeCase(name, false))
+	case KeyCaseKebab:
+		return strings.ToLower(strings.ReplaceAll(snakeCase(name, false), "_", "-"))
+	}
+	return snakeCase(name, !o.caseSensitive)
+}
+
+// joinKey returns key followed by segment, joined with the separator of
+// the KeyCase.
+func (o *options) joinKey(key, segment string) string {
+	if o.keyCase == KeyCaseKebab {
+		return key + "-" + segment
+	}
+	return key + "_" + segment
+}
+
+// splitCamel splits the word on its case boundaries: before an upper case
+// letter following a lower case letter or a digit, and before the last
+// letter of a run of upper case letters followed by a lower case letter.
+func splitCamel(word string) []string {
+	runes := []rune(word)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, r := runes[i-1], runes[i]
+		next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && next) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
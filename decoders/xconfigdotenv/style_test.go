@@ -0,0 +1,113 @@
+package xconfigdotenv_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type styleConfig struct {
+	DatabaseURL string
+	HTTPServer  struct {
+		ReadTimeout int
+	}
+	Cache struct {
+		MaxSize int
+	}
+	Labels map[string]string
+}
+
+func TestCaseStyle(t *testing.T) {
+	decoder := xconfigdotenv.New(xconfigdotenv.WithCaseStyle(xconfigdotenv.CaseDot, xconfigdotenv.CaseCamel))
+
+	tests := map[string]string{
+		"snake": "DATABASE_URL=pg\nHTTP_SERVER_READ_TIMEOUT=5\nCACHE_MAX_SIZE=10\nLABELS_team=core",
+		"dot":   "database.url=pg\nhttp.server.read.timeout=5\ncache.max.size=10\nlabels.team=core",
+		"camel": "databaseUrl=pg\nhttpServerReadTimeout=5\ncacheMaxSize=10\nlabelsTeam=core",
+		"mixed": "DatabaseURL=pg\nHTTPServer_readTimeout=5\ncache.maxSize=10\nLABELS.team=core",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var config styleConfig
+			err := decoder.Unmarshal([]byte(data), &config)
+			assert.NoError(t, err)
+
+			assert.Equal(t, "pg", config.DatabaseURL)
+			assert.Equal(t, 5, config.HTTPServer.ReadTimeout)
+			assert.Equal(t, 10, config.Cache.MaxSize)
+			assert.Len(t, config.Labels, 1)
+		})
+	}
+
+	// map keys are rebuilt from the words
+	var config styleConfig
+	assert.NoError(t, decoder.Unmarshal([]byte("labelsTeamName=core"), &config))
+	assert.Equal(t, map[string]string{"Team_Name": "core"}, config.Labels)
+}
+
+func TestCaseStyleDefault(t *testing.T) {
+	var config styleConfig
+	err := xconfigdotenv.New().Unmarshal([]byte("cacheMaxSize=10\ncache.max.size=20\nDATABASEURL=pg"), &config)
+	assert.NoError(t, err)
+
+	// without the option only '_' splits keys
+	assert.Zero(t, config.Cache.MaxSize)
+	assert.Equal(t, "pg", config.DatabaseURL)
+}
+
+func TestKeyCaseOutput(t *testing.T) {
+	type config struct {
+		DatabaseURL string
+		Banner      string `env:"BANNER"`
+		HTTPServer  struct {
+			ReadTimeout int
+		}
+		Hosts []struct {
+			Addr string
+		}
+		Labels map[string]string
+	}
+	var c config
+	c.DatabaseURL = "pg"
+	c.Banner = "hi"
+	c.HTTPServer.ReadTimeout = 5
+	c.Hosts = append(c.Hosts, struct{ Addr string }{"h1"})
+	c.Labels = map[string]string{"team": "core"}
+
+	// kebab keys are read by a parser accepting '-' in keys
+	kebabParser := func(data []byte) (map[string]string, error) {
+		values := make(map[string]string)
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			key, value, _ := strings.Cut(line, "=")
+			values[key] = value
+		}
+		return values, nil
+	}
+
+	tests := []struct {
+		name string
+		opts []xconfigdotenv.Option
+		want string
+	}{
+		{"screaming snake", nil, "DATABASE_URL=pg\nBANNER=hi\nHTTP_SERVER_READ_TIMEOUT=5\nHOSTS_0_ADDR=h1\nLABELS_team=core\n"},
+		{"snake", []xconfigdotenv.Option{xconfigdotenv.WithKeyCaseOutput(xconfigdotenv.KeyCaseSnake)},
+			"database_url=pg\nBANNER=hi\nhttp_server_read_timeout=5\nhosts_0_addr=h1\nlabels_team=core\n"},
+		{"kebab", []xconfigdotenv.Option{
+			xconfigdotenv.WithKeyCaseOutput(xconfigdotenv.KeyCaseKebab),
+			xconfigdotenv.WithCaseStyle(xconfigdotenv.CaseKebab),
+			xconfigdotenv.WithParser(kebabParser),
+		}, "database-url=pg\nBANNER=hi\nhttp-server-read-timeout=5\nhosts-0-addr=h1\nlabels-team=core\n"},
+	}
+	for _, tt := range tests {
+		decoder := xconfigdotenv.New(tt.opts...)
+		data, err := decoder.Marshal(&c)
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, string(data), tt.name)
+
+		var decoded config
+		assert.NoError(t, decoder.Unmarshal(data, &decoded), tt.name)
+		assert.Equal(t, c, decoded, tt.name)
+	}
+}
@@ -0,0 +1,102 @@
+package xconfigdotenv
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const valueTypeTag = "valuetype"
+
+var syncMapType = reflect.TypeFor[sync.Map]()
+
+// syncMapValueTypes are the types the `valuetype` tag of a sync.Map field
+// may name for its values.
+var syncMapValueTypes = map[string]reflect.Type{
+	"string":   reflect.TypeFor[string](),
+	"bool":     reflect.TypeFor[bool](),
+	"int":      reflect.TypeFor[int](),
+	"int64":    reflect.TypeFor[int64](),
+	"uint":     reflect.TypeFor[uint](),
+	"uint64":   reflect.TypeFor[uint64](),
+	"float64":  reflect.TypeFor[float64](),
+	"duration": reflect.TypeFor[time.Duration](),
+	"time":     timeType,
+}
+
+// syncMapValueType returns the type of the values of the sync.Map field,
+// given by its `valuetype` tag, string by default.
+func (o *options) syncMapValueType(field reflect.StructField) (reflect.Type, error) {
+	name, ok := field.Tag.Lookup(o.tagNames.ValueType)
+	if !ok || name == "" {
+		return syncMapValueTypes["string"], nil
+	}
+	t, ok := syncMapValueTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("valuetype of field %s: unknown type %q", field.Name, name)
+	}
+	return t, nil
+}
+
+// assignSyncMap stores rawVal in the sync.Map fieldVal, or the one it
+// points to, at fieldPath, under the key the leftover segments give joined
+// with '_', as for a map field: with a field Flags, FLAGS_BETA=on calls
+// Store("BETA", "on"). A nil pointer is allocated. The value is stored as a
+// string, or parsed into the type of the `valuetype` tag of field, such as
+// `valuetype:"int"`; the types are string, bool, int, int64, uint, uint64,
+// float64, duration for time.Duration and time for time.Time.
+func (s *decodeState) assignSyncMap(fieldVal reflect.Value, field reflect.StructField, leftover []string, rawVal, fieldPath string) error {
+	mapKey := strings.Join(leftover, "_")
+	if !s.claim(fieldPath + "[" + mapKey + "]") {
+		return nil
+	}
+	valueType, err := s.opts.syncMapValueType(field)
+	if err != nil {
+		return err
+	}
+	value := reflect.New(valueType).Elem()
+	if err := s.setBasicValue(value, s.opts.formatValue(field, rawVal)); err != nil {
+		return err
+	}
+
+	if fieldVal.Kind() == reflect.Ptr {
+		if fieldVal.IsNil() {
+			if err := setWithReflect(fieldVal, reflect.New(syncMapType)); err != nil {
+				return err
+			}
+		}
+		fieldVal = fieldVal.Elem()
+	}
+	ptr, err := atomicPointer(fieldVal)
+	if err != nil {
+		return err
+	}
+	ptr.Interface().(*sync.Map).Store(mapKey, value.Interface())
+	return s.reportSet(nil, fieldPath+"["+mapKey+"]", value)
+}
+
+// syncMapEntries returns the entries of the sync.Map v with string keys,
+// sorted by key, for Marshal.
+func syncMapEntries(v reflect.Value) ([]string, map[string]any, error) {
+	ptr, err := atomicPointer(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	var keys []string
+	entries := make(map[string]any)
+	ptr.Interface().(*sync.Map).Range(func(key, value any) bool {
+		k, ok := key.(string)
+		if !ok {
+			err = fmt.Errorf("unsupported sync.Map key type %T; only string keys allowed", key)
+			return false
+		}
+		keys = append(keys, k)
+		entries[k] = value
+		return true
+	})
+	sort.Strings(keys)
+	return keys, entries, err
+}
@@ -0,0 +1,73 @@
+package xconfigdotenv_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+// syncMapEntries returns the entries of m, for comparisons.
+func syncMapEntries(m *sync.Map) map[string]any {
+	entries := make(map[string]any)
+	m.Range(func(key, value any) bool {
+		entries[key.(string)] = value
+		return true
+	})
+	return entries
+}
+
+func TestSyncMap(t *testing.T) {
+	type config struct {
+		Flags    sync.Map
+		Limits   *sync.Map `valuetype:"int"`
+		Timeouts sync.Map  `valuetype:"duration"`
+	}
+
+	data := []byte(`FLAGS_beta=on
+FLAGS_NEW_UI=off
+LIMITS_api=100
+LIMITS_db=20
+TIMEOUTS_read=5s`)
+	var c config
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(data, &c))
+	assert.Equal(t, map[string]any{"beta": "on", "NEW_UI": "off"}, syncMapEntries(&c.Flags))
+	if assert.NotNil(t, c.Limits) {
+		assert.Equal(t, map[string]any{"api": 100, "db": 20}, syncMapEntries(c.Limits))
+	}
+	assert.Equal(t, map[string]any{"read": 5 * time.Second}, syncMapEntries(&c.Timeouts))
+
+	// the map stays usable concurrently, and Marshal writes it back
+	c.Flags.Store("dark", "on")
+	out, err := decoder.Marshal(&c)
+	assert.NoError(t, err)
+	assert.Equal(t, `FLAGS_NEW_UI=off
+FLAGS_beta=on
+FLAGS_dark=on
+LIMITS_api=100
+LIMITS_db=20
+TIMEOUTS_read=5s
+`, string(out))
+
+	err = decoder.Unmarshal([]byte("LIMITS_api=many"), &c)
+	assert.ErrorContains(t, err, `cannot parse "many" as int`)
+
+	var unknown struct {
+		Flags sync.Map `valuetype:"color"`
+	}
+	err = decoder.Unmarshal([]byte("FLAGS_beta=on"), &unknown)
+	assert.ErrorContains(t, err, `valuetype of field Flags: unknown type "color"`)
+}
+
+func TestSyncMapValueTypeTagName(t *testing.T) {
+	var config struct {
+		Limits sync.Map `kind:"int"`
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithTagNames(xconfigdotenv.TagNames{ValueType: "kind"}))
+	assert.NoError(t, decoder.Unmarshal([]byte("LIMITS_api=100"), &config))
+	assert.Equal(t, map[string]any{"api": 100}, syncMapEntries(&config.Limits))
+}
@@ -0,0 +1,76 @@
+package xconfigdotenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const timeFormatTag = "timeformat"
+
+// timeLayouts are the layouts of package time the `timeformat` tag may name.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+// timeLayout returns the layout of the time.Time values of field, and of its
+// elements and map values, given by its `timeformat` tag: a layout of
+// package time, such as `timeformat:"2006-01-02 15:04"`, or the name of one
+// of its constants, such as `timeformat:"DateOnly"`. It is empty without
+// the tag, the values being in the RFC 3339 format (see parseTime).
+func (o *options) timeLayout(field reflect.StructField) string {
+	layout := field.Tag.Get(o.tagNames.TimeFormat)
+	if named, ok := timeLayouts[layout]; ok {
+		return named
+	}
+	return layout
+}
+
+// parseTime parses a time.Time in the RFC 3339 format, with optional
+// fractional seconds (2023-01-01T00:00:00Z, 2023-01-01T09:30:00.5+02:00).
+// The commas splitting slices given as a single value never occur in it.
+func parseTime(rawVal string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(rawVal))
+	if err != nil {
+		return time.Time{}, errors.New("expecting an RFC 3339 time, such as 2006-01-02T15:04:05Z")
+	}
+	return t, nil
+}
+
+// timeValue returns the time.Time v holds, directly or through a non-nil
+// pointer.
+func timeValue(v reflect.Value) (time.Time, bool) {
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Type() != timeType {
+		return time.Time{}, false
+	}
+	return v.Interface().(time.Time), true
+}
+
+// setTimeValue sets the time.Time fieldVal from rawVal in the layout of the
+// `timeformat` tag of the field being decoded.
+func (s *decodeState) setTimeValue(fieldVal reflect.Value, rawVal string) error {
+	t, err := time.Parse(s.timeLayout, strings.TrimSpace(rawVal))
+	if err != nil {
+		return fmt.Errorf("cannot parse %q as time: expecting the layout %q", rawVal, s.timeLayout)
+	}
+	return setWithReflect(fieldVal, reflect.ValueOf(t))
+}
@@ -0,0 +1,120 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTime(t *testing.T) {
+	var config struct {
+		Start    time.Time
+		Stop     *time.Time
+		Events   []time.Time
+		Windows  []time.Time
+		Schedule map[string]time.Time
+		Jobs     []struct{ At time.Time }
+	}
+
+	data := []byte(`START=2023-01-01T00:00:00Z
+STOP=2023-01-01T09:30:00.5+02:00
+EVENTS_0=2023-01-01T00:00:00Z
+EVENTS_1=2023-06-01T12:00:00Z
+WINDOWS=2023-01-01T00:00:00Z, 2023-01-02T00:00:00Z
+SCHEDULE_morning=2023-01-01T08:00:00Z
+JOBS_0_AT=2023-01-01T03:00:00Z`)
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+
+	day := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, day, config.Start)
+	if assert.NotNil(t, config.Stop) {
+		assert.True(t, time.Date(2023, 1, 1, 7, 30, 0, 5e8, time.UTC).Equal(*config.Stop))
+	}
+	assert.Equal(t, []time.Time{day, time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)}, config.Events)
+	assert.Equal(t, []time.Time{day, day.AddDate(0, 0, 1)}, config.Windows)
+	assert.Equal(t, map[string]time.Time{"morning": day.Add(8 * time.Hour)}, config.Schedule)
+	if assert.Len(t, config.Jobs, 1) {
+		assert.Equal(t, day.Add(3*time.Hour), config.Jobs[0].At)
+	}
+
+	err := decoder.Unmarshal([]byte("START=yesterday"), &config)
+	assert.ErrorContains(t, err, `cannot parse "yesterday" as time.Time: expecting an RFC 3339 time`)
+	err = decoder.Unmarshal([]byte("EVENTS_0=2023-01-01"), &config)
+	assert.ErrorContains(t, err, `cannot parse "2023-01-01" as time.Time`)
+}
+
+func TestTimeFormat(t *testing.T) {
+	type holidays struct {
+		First  time.Time            `timeformat:"DateOnly"`
+		Days   []time.Time          `timeformat:"DateOnly"`
+		Dates  []time.Time          `timeformat:"2006-01-02"`
+		Opens  map[string]time.Time `timeformat:"15:04"`
+		Cutoff *time.Time           `timeformat:"02.01.2006 15:04"`
+	}
+
+	var config holidays
+	data := []byte(`FIRST=2023-01-01
+DAYS_0=2023-12-25
+DAYS_1=2023-12-26
+DATES=2023-05-01,2023-05-08
+OPENS_mon=09:00
+CUTOFF=24.12.2023 18:00`)
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	assert.Equal(t, date(2023, 1, 1), config.First)
+	assert.Equal(t, []time.Time{date(2023, 12, 25), date(2023, 12, 26)}, config.Days)
+	assert.Equal(t, []time.Time{date(2023, 5, 1), date(2023, 5, 8)}, config.Dates)
+	assert.Equal(t, map[string]time.Time{"mon": time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC)}, config.Opens)
+	if assert.NotNil(t, config.Cutoff) {
+		assert.Equal(t, time.Date(2023, 12, 24, 18, 0, 0, 0, time.UTC), *config.Cutoff)
+	}
+
+	// Marshal writes the layout back
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, `FIRST=2023-01-01
+DAYS=2023-12-25,2023-12-26
+DATES=2023-05-01,2023-05-08
+OPENS_mon=09:00
+CUTOFF="24.12.2023 18:00"
+`, string(out))
+	var decoded holidays
+	assert.NoError(t, decoder.Unmarshal(out, &decoded))
+	assert.Equal(t, config, decoded)
+
+	err = decoder.Unmarshal([]byte("DAYS_0=2023-12-25T00:00:00Z"), &config)
+	assert.ErrorContains(t, err, `cannot parse "2023-12-25T00:00:00Z" as time: expecting the layout "2006-01-02"`)
+
+	// the defaults of allocated structs take the layout as well
+	var withDefaults struct {
+		Maintenance *struct {
+			Day  time.Time   `default:"2023-07-01" timeformat:"DateOnly"`
+			Days []time.Time `default:"2023-07-02,2023-07-03" timeformat:"DateOnly"`
+			Note string
+		}
+	}
+	assert.NoError(t, decoder.Unmarshal([]byte("MAINTENANCE_NOTE=x"), &withDefaults))
+	if assert.NotNil(t, withDefaults.Maintenance) {
+		assert.Equal(t, date(2023, 7, 1), withDefaults.Maintenance.Day)
+		assert.Equal(t, []time.Time{date(2023, 7, 2), date(2023, 7, 3)}, withDefaults.Maintenance.Days)
+	}
+}
+
+func TestTimeFormatTagName(t *testing.T) {
+	var config struct {
+		Day time.Time `layout:"DateOnly"`
+	}
+
+	decoder := xconfigdotenv.New(xconfigdotenv.WithTagNames(xconfigdotenv.TagNames{TimeFormat: "layout"}))
+	assert.NoError(t, decoder.Unmarshal([]byte("DAY=2023-07-01"), &config))
+	assert.Equal(t, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), config.Day)
+
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "DAY=2023-07-01\n", string(out))
+}
@@ -0,0 +1,9 @@
+package xconfigdotenv
+
+import "reflect"
+
+// skipsField reports whether field is left alone because it is unexported
+// and WithAllowUnexported(false) was given.
+func (o *options) skipsField(field reflect.StructField) bool {
+	return o.denyUnexported && !field.IsExported()
+}
@@ -0,0 +1,65 @@
+package xconfigdotenv_test
+
+import (
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type unexportedInner struct {
+	Level int
+}
+
+type unexportedCache struct {
+	Size int
+	ttl  int `default:"60"`
+}
+
+type unexportedConfig struct {
+	Name   string
+	secret string
+	tags   []string
+	Cache  *unexportedCache
+	unexportedInner
+}
+
+func TestAllowUnexported(t *testing.T) {
+	data := []byte("NAME=app\nSECRET=s3cr3t\nTAGS=a,b\nCACHE_SIZE=10\nUNEXPORTED_INNER_LEVEL=2")
+
+	// unexported fields are decoded by default
+	var config unexportedConfig
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &config))
+	assert.Equal(t, "s3cr3t", config.secret)
+	assert.Equal(t, []string{"a", "b"}, config.tags)
+	assert.Equal(t, &unexportedCache{Size: 10, ttl: 60}, config.Cache)
+	assert.Equal(t, 2, config.Level)
+
+	var unknown []string
+	decoder := xconfigdotenv.New(
+		xconfigdotenv.WithAllowUnexported(false),
+		xconfigdotenv.WithUnknownKeyCallback(func(key, _ string) error {
+			unknown = append(unknown, key)
+			return nil
+		}),
+	)
+	config = unexportedConfig{}
+	assert.NoError(t, decoder.Unmarshal(data, &config))
+	assert.Equal(t, unexportedConfig{Name: "app", Cache: &unexportedCache{Size: 10}}, config)
+	assert.Equal(t, []string{"SECRET", "TAGS", "UNEXPORTED_INNER_LEVEL"}, unknown)
+
+	config.secret = "s3cr3t"
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "NAME=app\nCACHE_SIZE=10\n", string(out))
+
+	var indexed struct {
+		Name   string
+		secret string
+	}
+	decoder = xconfigdotenv.New(xconfigdotenv.WithAllowUnexported(false), xconfigdotenv.WithMatchMode(xconfigdotenv.MatchIndex))
+	assert.EqualError(t, decoder.Unmarshal([]byte("_1=x"), &indexed), `xconfigdotenv: Unmarshal: key "_1": field index 1: field "secret" is unexported`)
+
+	decoder = xconfigdotenv.New(xconfigdotenv.WithAllowUnexported(false), xconfigdotenv.WithKeyMap(map[string]string{"S": "secret"}))
+	assert.ErrorContains(t, decoder.Unmarshal([]byte("S=x"), &indexed), `key map: field "secret" of struct { Name string; secret string } is unexported`)
+}
@@ -0,0 +1,118 @@
+package xconfigdotenv
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Unmarshaler is implemented by structs which decode themselves from their
+// keys, in place of the field by field decoding. It is the struct level
+// counterpart of MapSetter.
+//
+// When v passed to Unmarshal implements it, UnmarshalEnv receives every key
+// of the input as is. A struct field implementing it (or a pointer to one,
+// which is allocated) receives the keys matching the field, with the prefix
+// matching the field removed: under a field DB, DB_HOST=db and
+// DB_MAX_CONNS=10 give {"HOST": "db", "MAX_CONNS": "10"}, and DB=x itself
+// is given under the empty key. The segments after the prefix are joined
+// with '_' whatever the separators of the original key. Values are passed
+// after secrets are resolved and the `pre` tag of the field is applied.
+//
+// UnmarshalEnv is called once per field, after every other key is decoded,
+// with the keys of a field collected from the whole input.
+type Unmarshaler interface {
+	UnmarshalEnv(keys map[string]string) error
+}
+
+var unmarshalerType = reflect.TypeFor[Unmarshaler]()
+
+// pendingEnv holds the keys collected for an Unmarshaler field, which is
+// given them once every key is decoded.
+type pendingEnv struct {
+	// path is the path of the field.
+	path string
+	// parts holds the segments of the first key matching the field, used
+	// to reach the field again.
+	parts []string
+	keys  map[string]string
+}
+
+// isUnmarshaler reports whether fields of type t are decoded by their
+// Unmarshaler.
+func isUnmarshaler(t reflect.Type) bool {
+	return derefType(t).Kind() == reflect.Struct &&
+		(t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType))
+}
+
+// unmarshaler returns v as an Unmarshaler, allocating a nil pointer, or
+// false when its type does not implement Unmarshaler.
+func unmarshaler(v reflect.Value) (Unmarshaler, bool, error) {
+	if v.Kind() == reflect.Ptr && v.Type().Implements(unmarshalerType) {
+		if v.IsNil() {
+			if err := setWithReflect(v, reflect.New(v.Type().Elem())); err != nil {
+				return nil, false, err
+			}
+		}
+		return v.Interface().(Unmarshaler), true, nil
+	}
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(unmarshalerType) {
+		return v.Addr().Interface().(Unmarshaler), true, nil
+	}
+	return nil, false, nil
+}
+
+// collectEnv records the key of the Unmarshaler field at fieldPath under the
+// segments leftover after the field name. While flushing, it instead gives
+// the collected keys to the field, which the decoding reached again.
+func (s *decodeState) collectEnv(fieldVal reflect.Value, leftover []string, rawVal, fieldPath string) error {
+	if s.flush != nil {
+		if s.flush.path != fieldPath {
+			return nil
+		}
+		u, _, err := unmarshaler(fieldVal)
+		if err != nil {
+			return err
+		}
+		return s.reportSet(u.UnmarshalEnv(s.flush.keys), fieldPath, fieldVal)
+	}
+
+	subKey := strings.Join(leftover, "_")
+	if !s.claim(fieldPath + "[" + subKey + "]") {
+		return nil
+	}
+	p, ok := s.pendingByPath[fieldPath]
+	if !ok {
+		p = &pendingEnv{path: fieldPath, parts: s.parts, keys: make(map[string]string)}
+		if s.pendingByPath == nil {
+			s.pendingByPath = make(map[string]*pendingEnv)
+		}
+		s.pendingByPath[fieldPath] = p
+		s.pending = append(s.pending, p)
+	}
+	p.keys[subKey] = rawVal
+	return nil
+}
+
+// flushEnv gives every Unmarshaler field of elem the keys collected for it,
+// in the order the fields were first matched. Errors are collected in
+// partial when the options allow it.
+func (s *decodeState) flushEnv(elem reflect.Value, partial *PartialError) error {
+	defer func() { s.flush = nil }()
+
+	for _, p := range s.pending {
+		// Reach the field again through the first key which matched it:
+		// values held in maps are stored back on the way up
+		s.flush = p
+		s.key, s.parts = "", p.parts
+		s.ranks = s.ranks[:0]
+		s.resolved = true
+		if _, err := s.assignValue(elem, p.parts, "", ""); err != nil {
+			err = &KeyError{Field: p.path, Err: err}
+			if !s.opts.allowPartial {
+				return err
+			}
+			partial.Errors = append(partial.Errors, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package xconfigdotenv_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+// envKeys is an Unmarshaler keeping the keys it is given.
+type envKeys struct {
+	Keys  map[string]string
+	Calls int
+}
+
+func (e *envKeys) UnmarshalEnv(keys map[string]string) error {
+	if keys["FAIL"] != "" {
+		return errors.New(keys["FAIL"])
+	}
+	e.Keys = keys
+	e.Calls++
+	return nil
+}
+
+func TestUnmarshaler(t *testing.T) {
+	var config struct {
+		Name  string
+		DB    envKeys
+		Cache *envKeys
+		Pools map[string]struct {
+			Conn envKeys
+		}
+	}
+
+	data := []byte("NAME=app\nDB=main\nDB_HOST=db\nDB_MAX_CONNS=10\nCACHE_SIZE=64\nPOOLS_A_CONN_HOST=a\nPOOLS_A_CONN_PORT=1")
+	assert.NoError(t, xconfigdotenv.New().Unmarshal(data, &config))
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, envKeys{Keys: map[string]string{"": "main", "HOST": "db", "MAX_CONNS": "10"}, Calls: 1}, config.DB)
+	if assert.NotNil(t, config.Cache) {
+		assert.Equal(t, map[string]string{"SIZE": "64"}, config.Cache.Keys)
+	}
+	assert.Equal(t, envKeys{Keys: map[string]string{"HOST": "a", "PORT": "1"}, Calls: 1}, config.Pools["A"].Conn)
+
+	err := xconfigdotenv.New().Unmarshal([]byte("DB_FAIL=boom"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: field "DB": boom`)
+}
+
+func TestUnmarshalerTopLevel(t *testing.T) {
+	var config envKeys
+	assert.NoError(t, xconfigdotenv.New().Unmarshal([]byte("A=1\nB_C=2"), &config))
+	assert.Equal(t, map[string]string{"A": "1", "B_C": "2"}, config.Keys)
+}
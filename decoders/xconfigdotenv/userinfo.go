@@ -0,0 +1,43 @@
+package xconfigdotenv
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+var userinfoType = reflect.TypeFor[url.Userinfo]()
+
+// parseUserinfo parses a url.Userinfo from a user name, alone or followed by
+// ':' and a password, as in the user information of a URL: proxy:s3cr3t.
+// Both may be percent-encoded, as String writes them, e.g. for a ':' in
+// the user name. The errors never quote the value.
+func parseUserinfo(rawVal string) (url.Userinfo, error) {
+	user, password, hasPassword := strings.Cut(rawVal, ":")
+	user, err := url.PathUnescape(user)
+	if err != nil {
+		return url.Userinfo{}, errors.New("invalid escape in the user name")
+	}
+	if user == "" {
+		return url.Userinfo{}, errors.New("expecting user or user:password, the user name is empty")
+	}
+	if !hasPassword {
+		return *url.User(user), nil
+	}
+	password, err = url.PathUnescape(password)
+	if err != nil {
+		return url.Userinfo{}, errors.New("invalid escape in the password")
+	}
+	return *url.UserPassword(user, password), nil
+}
+
+// isCredentialType reports whether t holds credentials, such as
+// url.Userinfo, through pointers, slices and maps, so the fields of type t
+// are redacted by default.
+func isCredentialType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	return t == userinfoType
+}
@@ -0,0 +1,56 @@
+package xconfigdotenv_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserinfo(t *testing.T) {
+	var config struct {
+		ProxyAuth    *url.Userinfo
+		UpstreamAuth url.Userinfo
+		Mirrors      []*url.Userinfo
+	}
+
+	data := []byte(`PROXY_AUTH=proxy:s3cr3t
+UPSTREAM_AUTH=svc%3Aa:p%40ss:word
+MIRRORS=anonymous,ci:token`)
+	decoder := xconfigdotenv.New()
+	meta, err := decoder.UnmarshalWithMetadata(data, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, url.UserPassword("proxy", "s3cr3t"), config.ProxyAuth)
+	assert.Equal(t, *url.UserPassword("svc:a", "p@ss:word"), config.UpstreamAuth)
+	assert.Equal(t, []*url.Userinfo{url.User("anonymous"), url.UserPassword("ci", "token")}, config.Mirrors)
+	assert.Equal(t, "***", meta.Flat["PROXY_AUTH"])
+
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, `PROXY_AUTH=proxy:s3cr3t
+UPSTREAM_AUTH="svc%3Aa:p%40ss%3Aword"
+MIRRORS_0=anonymous
+MIRRORS_1=ci:token
+`, string(out))
+	var decoded struct {
+		ProxyAuth    *url.Userinfo
+		UpstreamAuth url.Userinfo
+		Mirrors      []*url.Userinfo
+	}
+	assert.NoError(t, decoder.Unmarshal(out, &decoded))
+	assert.Equal(t, config, decoded)
+
+	// the value never appears in errors
+	err = decoder.Unmarshal([]byte("PROXY_AUTH=:s3cr3t"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "PROXY_AUTH": cannot parse "***" as url.Userinfo: expecting user or user:password, the user name is empty`)
+	err = decoder.Unmarshal([]byte("PROXY_AUTH=proxy:s3%zzcr3t"), &config)
+	assert.EqualError(t, err, `xconfigdotenv: Unmarshal: key "PROXY_AUTH": cannot parse "***" as url.Userinfo: invalid escape in the password`)
+
+	// unless the field opts out of the redaction
+	var plain struct {
+		Auth url.Userinfo `redact:"false"`
+	}
+	err = decoder.Unmarshal([]byte("AUTH=:s3cr3t"), &plain)
+	assert.ErrorContains(t, err, `cannot parse ":s3cr3t" as url.Userinfo`)
+}
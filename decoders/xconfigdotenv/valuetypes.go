@@ -0,0 +1,147 @@
+package xconfigdotenv
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interval is a time.Duration written as a duration text, such as 30s or
+// 1h30m, for the periods of tickers and retries. It implements
+// encoding.TextUnmarshaler, so it decodes from the same text in the other
+// formats, such as JSON, and its converter is registered, so it needs no
+// option. A negative interval is rejected.
+type Interval time.Duration
+
+// Duration returns the interval as a time.Duration, e.g. for time.NewTicker.
+func (i Interval) Duration() time.Duration {
+	return time.Duration(i)
+}
+
+// String returns the interval as time.Duration writes it.
+func (i Interval) String() string {
+	return time.Duration(i).String()
+}
+
+// MarshalText returns the interval as time.Duration writes it.
+func (i Interval) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// UnmarshalText parses the interval from a duration text.
+func (i *Interval) UnmarshalText(text []byte) error {
+	d, err := time.ParseDuration(strings.TrimSpace(string(text)))
+	if err != nil {
+		return errors.New("expecting a duration, such as 30s or 1h30m")
+	}
+	if d < 0 {
+		return errors.New("expecting a duration of at least 0")
+	}
+	*i = Interval(d)
+	return nil
+}
+
+// Bytes is a size in bytes written with an optional size suffix, binary
+// (Ki, MiB) or decimal (k, MB), as WithSizeSuffixes accepts them for
+// integers: 64Mi is 67108864, while fractions such as 1.5Gi are rejected,
+// and so are negative sizes. Like Interval, it implements
+// encoding.TextUnmarshaler and needs no option.
+type Bytes int64
+
+// Int returns the size in bytes.
+func (b Bytes) Int() int64 {
+	return int64(b)
+}
+
+// binarySuffixes are the suffixes Bytes are written with, the largest first.
+var binarySuffixes = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"Ei", 1 << 60}, {"Pi", 1 << 50}, {"Ti", 1 << 40}, {"Gi", 1 << 30}, {"Mi", 1 << 20}, {"Ki", 1 << 10},
+}
+
+// String returns the size with the largest binary suffix dividing it, such
+// as 64Mi, or as a number of bytes.
+func (b Bytes) String() string {
+	for _, size := range binarySuffixes {
+		if b != 0 && int64(b)%size.multiplier == 0 {
+			return strconv.FormatInt(int64(b)/size.multiplier, 10) + size.suffix
+		}
+	}
+	return strconv.FormatInt(int64(b), 10)
+}
+
+// MarshalText returns the size as String writes it.
+func (b Bytes) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText parses the size from a number of bytes with an optional
+// size suffix.
+func (b *Bytes) UnmarshalText(text []byte) error {
+	num, multiplier := splitSizeSuffix(strings.TrimSpace(string(text)))
+	n, err := strconv.ParseInt(strings.TrimSpace(num), 10, 64)
+	if err != nil {
+		return errors.New("expecting a size, such as 512, 64Mi or 10MB")
+	}
+	if n < 0 {
+		return errors.New("expecting a size of at least 0")
+	}
+	if n > math.MaxInt64/multiplier {
+		return errors.New("size out of range")
+	}
+	*b = Bytes(n * multiplier)
+	return nil
+}
+
+// Percent is a fraction written as a percentage, such as 75% for 0.75, or
+// as the fraction itself, such as 0.75: a number without % is never
+// multiplied, so 50 is 5000%. Like Interval, it implements
+// encoding.TextUnmarshaler and needs no option.
+type Percent float64
+
+// Fraction returns the fraction, 0.75 for 75%.
+func (p Percent) Fraction() float64 {
+	return float64(p)
+}
+
+// String returns the percentage, such as 75%.
+func (p Percent) String() string {
+	// 15 digits keep 7% from printing as 7.000000000000001%
+	return strconv.FormatFloat(float64(p)*100, 'g', 15, 64) + "%"
+}
+
+// MarshalText returns the percentage as String writes it.
+func (p Percent) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText parses the fraction from a percentage or a fraction.
+func (p *Percent) UnmarshalText(text []byte) error {
+	num, percent := strings.CutSuffix(strings.TrimSpace(string(text)), "%")
+	f, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return errors.New("expecting a percentage or a fraction, such as 75% or 0.75")
+	}
+	if percent {
+		f /= 100
+	}
+	*p = Percent(f)
+	return nil
+}
+
+// parseText returns a converter parsing values of type T with the
+// UnmarshalText method of *T, for the helper types of this file.
+func parseText[T any, PT interface {
+	*T
+	UnmarshalText([]byte) error
+}]() func(rawVal string) (T, error) {
+	return func(rawVal string) (T, error) {
+		var v T
+		err := PT(&v).UnmarshalText([]byte(rawVal))
+		return v, err
+	}
+}
@@ -0,0 +1,95 @@
+package xconfigdotenv_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dv-net/xconfig/decoders/xconfigdotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterval(t *testing.T) {
+	var config struct {
+		Poll    xconfigdotenv.Interval
+		Retries []xconfigdotenv.Interval
+		Backoff *xconfigdotenv.Interval
+	}
+
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal([]byte("POLL=1h30m\nRETRIES=1s, 5s\nBACKOFF=250ms"), &config))
+	assert.Equal(t, 90*time.Minute, config.Poll.Duration())
+	assert.Equal(t, []xconfigdotenv.Interval{xconfigdotenv.Interval(time.Second), xconfigdotenv.Interval(5 * time.Second)}, config.Retries)
+	if assert.NotNil(t, config.Backoff) {
+		assert.Equal(t, 250*time.Millisecond, config.Backoff.Duration())
+	}
+
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "POLL=1h30m0s\nRETRIES=1s,5s\nBACKOFF=250ms\n", string(out))
+
+	err = decoder.Unmarshal([]byte("POLL=90"), &config)
+	assert.ErrorContains(t, err, `cannot parse "90" as xconfigdotenv.Interval: expecting a duration, such as 30s or 1h30m`)
+	err = decoder.Unmarshal([]byte("POLL=-1s"), &config)
+	assert.ErrorContains(t, err, "expecting a duration of at least 0")
+
+	// the other formats decode the same text
+	var fromJSON struct{ Poll xconfigdotenv.Interval }
+	assert.NoError(t, json.Unmarshal([]byte(`{"Poll":"2m"}`), &fromJSON))
+	assert.Equal(t, 2*time.Minute, fromJSON.Poll.Duration())
+}
+
+func TestBytes(t *testing.T) {
+	var config struct {
+		Cache   xconfigdotenv.Bytes
+		Upload  xconfigdotenv.Bytes
+		Buffer  xconfigdotenv.Bytes
+		Request xconfigdotenv.Bytes
+	}
+
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal([]byte("CACHE=64Mi\nUPLOAD=10MB\nBUFFER=4 KiB\nREQUEST=1000"), &config))
+	assert.Equal(t, int64(64<<20), config.Cache.Int())
+	assert.Equal(t, int64(10e6), config.Upload.Int())
+	assert.Equal(t, int64(4096), config.Buffer.Int())
+	assert.Equal(t, int64(1000), config.Request.Int())
+
+	// sizes are written with the largest binary suffix dividing them
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "CACHE=64Mi\nUPLOAD=10000000\nBUFFER=4Ki\nREQUEST=1000\n", string(out))
+
+	for value, want := range map[string]string{
+		"1.5Gi": "expecting a size, such as 512, 64Mi or 10MB",
+		"-1":    "expecting a size of at least 0",
+		"16Ei":  "size out of range",
+	} {
+		err := decoder.Unmarshal([]byte("CACHE="+value), &config)
+		assert.ErrorContains(t, err, want, value)
+	}
+}
+
+func TestPercent(t *testing.T) {
+	var config struct {
+		Sample    xconfigdotenv.Percent
+		Threshold xconfigdotenv.Percent
+		Rollout   map[string]xconfigdotenv.Percent
+	}
+
+	decoder := xconfigdotenv.New()
+	assert.NoError(t, decoder.Unmarshal([]byte("SAMPLE=7%\nTHRESHOLD=0.9\nROLLOUT_eu=12.5 %"), &config))
+	assert.Equal(t, 0.07, config.Sample.Fraction())
+	assert.Equal(t, 0.9, config.Threshold.Fraction())
+	assert.Equal(t, map[string]xconfigdotenv.Percent{"eu": 0.125}, config.Rollout)
+
+	out, err := decoder.Marshal(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, "SAMPLE=\"7%\"\nTHRESHOLD=\"90%\"\nROLLOUT_eu=\"12.5%\"\n", string(out))
+	decoded := config
+	decoded.Rollout = nil
+	assert.NoError(t, decoder.Unmarshal(out, &decoded))
+	assert.Equal(t, config, decoded)
+
+	err = decoder.Unmarshal([]byte("SAMPLE=most"), &config)
+	assert.ErrorContains(t, err, `cannot parse "most" as xconfigdotenv.Percent: expecting a percentage or a fraction, such as 75% or 0.75`)
+}
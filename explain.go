@@ -0,0 +1,122 @@
+package xconfig
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"sync"
+
+	"github.com/dv-net/xconfig/flat"
+	"github.com/dv-net/xconfig/plugins"
+)
+
+// explained holds the report of the last Parse of each config loaded with
+// WithExplain, keyed by the pointer given to Load or Custom.
+var explained sync.Map
+
+// Explain returns the field path → source map recorded by the last Parse
+// of v, the pointer given to Load or Custom. It is nil unless v was loaded
+// with WithExplain.
+//
+// A field is attributed to the last plugin whose Parse changed its value,
+// so a value from the "default" tag is reported as "defaults" and values
+// from SetDefaults as "customdefaults" until a later source (a file path,
+// "reader", "env", "flag", "secret") overrides them. A source that sets a
+// field to the value it already holds does not take it over, and fields
+// no plugin changed are left out. Plugins that do not implement
+// plugins.Namer are reported by their Go type.
+func Explain(v any) map[string]string {
+	sources, ok := explained.Load(v)
+	if !ok {
+		return nil
+	}
+
+	return maps.Clone(sources.(map[string]string))
+}
+
+// storeExplain makes the report of the last Parse available to Explain.
+func (c *config) storeExplain() {
+	if c.sources == nil {
+		explained.Delete(c.conf)
+		return
+	}
+
+	explained.Store(c.conf, c.sources)
+}
+
+func (c *config) record(p plugins.Plugin, before []reflect.Value) {
+	name := sourceName(p)
+	for i, f := range c.fields {
+		if !reflect.DeepEqual(before[i].Interface(), f.FieldValue().Interface()) {
+			c.sources[f.Name()] = name
+		}
+	}
+}
+
+func sourceName(p plugins.Plugin) string {
+	if n, ok := p.(plugins.Namer); ok {
+		return n.Name()
+	}
+
+	return fmt.Sprintf("%T", p)
+}
+
+// snapshot copies the current value of every field. The copy is deep, so
+// that a plugin writing into a map, slice or pointer in place is still seen
+// as changing the field.
+func snapshot(fields flat.Fields) []reflect.Value {
+	values := make([]reflect.Value, len(fields))
+	for i, f := range fields {
+		values[i] = deepCopy(f.FieldValue())
+	}
+
+	return values
+}
+
+// deepCopy returns a copy of v that shares no map, slice or pointer with it.
+// Unexported struct fields are copied as they are.
+func deepCopy(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			p := reflect.New(v.Type().Elem())
+			p.Elem().Set(deepCopy(v.Elem()))
+			c.Set(p)
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			c.Set(deepCopy(v.Elem()))
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			c.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+			iter := v.MapRange()
+			for iter.Next() {
+				c.SetMapIndex(deepCopy(iter.Key()), deepCopy(iter.Value()))
+			}
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			c.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+			for i := range v.Len() {
+				c.Index(i).Set(deepCopy(v.Index(i)))
+			}
+		}
+	case reflect.Array:
+		for i := range v.Len() {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+	case reflect.Struct:
+		c.Set(v)
+		for i := range v.NumField() {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+	default:
+		c.Set(v)
+	}
+
+	return c
+}
@@ -35,7 +35,11 @@ func Load(conf any, opts ...Option) (Config, error) {
 	}
 
 	if !o.skipEnv {
-		ps = append(ps, env.New(o.envPrefix))
+		var envOpts []env.Option
+		if o.envPrefixFold {
+			envOpts = append(envOpts, env.WithPrefixCaseInsensitive())
+		}
+		ps = append(ps, env.New(o.envPrefix, envOpts...))
 	}
 
 	if !o.skipFlags {
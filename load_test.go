@@ -8,9 +8,11 @@ import (
 	"testing"
 
 	"github.com/dv-net/xconfig"
+	"github.com/dv-net/xconfig/flat"
 	"github.com/dv-net/xconfig/internal/f"
 	"github.com/dv-net/xconfig/plugins/loader"
 	"github.com/dv-net/xconfig/plugins/secret"
+	"github.com/dv-net/xconfig/plugins/validate"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -159,3 +161,253 @@ func TestClassicBadPlugin(t *testing.T) {
 		t.Errorf("Expected unsupported plugin error, got: %v", err)
 	}
 }
+
+func TestExplain(t *testing.T) {
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{
+		".json": json.Unmarshal,
+	})
+	if err != nil {
+		t.Fatalf("failed to create loader: %v", err)
+	}
+
+	l.AddFile("testdata/classic.json", true)
+
+	value := f.Config{}
+
+	os.Setenv("REDIS_HOST", "from-envs")
+	os.Setenv("REDIS_PORT", "6379")
+	defer os.Unsetenv("REDIS_HOST")
+	defer os.Unsetenv("REDIS_PORT")
+
+	os.Args = append(os.Args[:1], "-version=from-flags")
+	defer func() { os.Args = os.Args[:1] }()
+
+	_, err = xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithExplain())
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	// Redis.Port is set by env to the value the file already set,
+	// and Rethink.Password is never set.
+	expect := map[string]string{
+		"Version":              "flag",
+		"GoHard":               "testdata/classic.json",
+		"Redis.Host":           "env",
+		"Redis.Port":           "testdata/classic.json",
+		"Rethink.Host.Address": "testdata/classic.json",
+		"Rethink.Host.Port":    "testdata/classic.json",
+		"Rethink.Db":           "testdata/classic.json",
+	}
+
+	if diff := cmp.Diff(expect, xconfig.Explain(&value)); diff != "" {
+		t.Error(diff)
+	}
+
+	// without a file the tag default is kept.
+	value = f.Config{}
+	_, err = xconfig.Load(&value, xconfig.WithExplain())
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if got := xconfig.Explain(&value)["Rethink.Db"]; got != "defaults" {
+		t.Errorf("expected Rethink.Db from defaults, got: %q", got)
+	}
+
+	_, err = xconfig.Load(&value)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if got := xconfig.Explain(&value); len(got) != 0 {
+		t.Errorf("expected no report without WithExplain, got: %v", got)
+	}
+}
+
+func TestExplainMapInPlace(t *testing.T) {
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{
+		".json": json.Unmarshal,
+	})
+	if err != nil {
+		t.Fatalf("failed to create loader: %v", err)
+	}
+
+	l.AddFiles([]string{"testdata/labels_a.json", "testdata/labels_b.json"}, false)
+
+	// the second file adds its key to the map the first one made.
+	var value struct {
+		Labels map[string]int
+	}
+	_, err = xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithSkipFlags(), xconfig.WithExplain())
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if got := xconfig.Explain(&value)["Labels"]; got != "testdata/labels_b.json" {
+		t.Errorf("expected Labels from the second file, got: %q", got)
+	}
+}
+
+func TestReservedKeys(t *testing.T) {
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{
+		".json": json.Unmarshal,
+	})
+	if err != nil {
+		t.Fatalf("failed to create loader: %v", err)
+	}
+
+	l.AddFile("testdata/classic.json", true)
+
+	os.Setenv("REDIS_HOST", "from-envs")
+	os.Setenv("RETHINK_DB", "from-envs")
+	defer os.Unsetenv("REDIS_HOST")
+	defer os.Unsetenv("RETHINK_DB")
+
+	os.Args = append(os.Args[:1], "-baseurl-api=from-flags", "-redis-port=1")
+	defer func() { os.Args = os.Args[:1] }()
+
+	value := f.Config{}
+	_, err = xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithExplain(),
+		xconfig.WithReservedKeys("REDIS_HOST", "Redis.Port", "BaseURL.API", "RETHINK_DB"))
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	// the file locks Redis, BaseURL.API is only set by the last source, and the
+	// tag default of Rethink.Db does not lock it.
+	if value.Redis.Host != "redis-host" || value.Redis.Port != 6379 {
+		t.Errorf("expected Redis from the file, got: %+v", value.Redis)
+	}
+
+	if value.BaseURL.API != "from-flags" {
+		t.Errorf("expected BaseURL.API from flags, got: %q", value.BaseURL.API)
+	}
+
+	if value.Rethink.Db != "base" {
+		t.Errorf("expected Rethink.Db from the file, got: %q", value.Rethink.Db)
+	}
+
+	if got := xconfig.Explain(&value)["Redis.Host"]; got != "testdata/classic.json" {
+		t.Errorf("expected Redis.Host from the file, got: %q", got)
+	}
+
+	value = f.Config{}
+	_, err = xconfig.Load(&value, xconfig.WithSkipFlags(), xconfig.WithReservedKeys("INSTANCE_ID"))
+	if err == nil || err.Error() != `reserved key "INSTANCE_ID" does not match any field` {
+		t.Errorf("expected unknown reserved key error, got: %v", err)
+	}
+}
+
+func TestReservedKeysMapInPlace(t *testing.T) {
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{
+		".json": json.Unmarshal,
+	})
+	if err != nil {
+		t.Fatalf("failed to create loader: %v", err)
+	}
+
+	l.AddFiles([]string{"testdata/labels_a.json", "testdata/labels_b.json"}, false)
+
+	// the second file writes into the map the first one locked.
+	var value struct {
+		Labels map[string]int
+	}
+	_, err = xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithSkipFlags(), xconfig.WithReservedKeys("Labels"))
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if diff := cmp.Diff(map[string]int{"a": 1}, value.Labels); diff != "" {
+		t.Error(diff)
+	}
+}
+
+// setPlugin sets the string field named field to value.
+type setPlugin struct {
+	name, field, value string
+	fields             flat.Fields
+}
+
+func (p *setPlugin) Name() string { return p.name }
+
+func (p *setPlugin) Visit(fields flat.Fields) error {
+	p.fields = fields
+	return nil
+}
+
+func (p *setPlugin) Parse() error {
+	for _, f := range p.fields {
+		if f.Name() == p.field {
+			f.FieldValue().SetString(p.value)
+		}
+	}
+	return nil
+}
+
+func TestReservedKeysNamedDefaults(t *testing.T) {
+	// a plugin named like the defaults one still locks the key.
+	value := f.Config{}
+	_, err := xconfig.Load(&value, xconfig.WithSkipFlags(), xconfig.WithReservedKeys("Version"),
+		xconfig.WithPlugins(
+			&setPlugin{name: "defaults", field: "Version", value: "first"},
+			&setPlugin{name: "second", field: "Version", value: "second"},
+		))
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if value.Version != "first" {
+		t.Errorf("expected Version from the first plugin, got: %q", value.Version)
+	}
+}
+
+func TestAfterDecode(t *testing.T) {
+	var calls []string
+	check := func(any) error {
+		calls = append(calls, "validate")
+		return nil
+	}
+	hook := func(conf any) error {
+		calls = append(calls, "hook")
+		conf.(*f.Config).Version = "derived"
+		return nil
+	}
+
+	value := f.Config{}
+	_, err := xconfig.Load(&value, xconfig.WithSkipFlags(), xconfig.WithAfterDecode(hook),
+		xconfig.WithPlugins(validate.New(check)))
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"validate", "hook"}, calls); diff != "" {
+		t.Error(diff)
+	}
+
+	if value.Version != "derived" {
+		t.Errorf("expected Version from the hook, got: %q", value.Version)
+	}
+
+	_, err = xconfig.Load(&value, xconfig.WithSkipFlags(), xconfig.WithAfterDecode(func(any) error {
+		return fmt.Errorf("not ready")
+	}))
+	if err == nil || err.Error() != "not ready" {
+		t.Errorf("expected the hook error, got: %v", err)
+	}
+}
+
+func TestEnvPrefixCaseInsensitive(t *testing.T) {
+	t.Setenv("app_REDIS_HOST", "from-lower")
+	t.Setenv("APP_REDIS_PORT", "6380")
+
+	value := f.Config{}
+	_, err := xconfig.Load(&value, xconfig.WithSkipFiles(), xconfig.WithSkipFlags(),
+		xconfig.WithEnvPrefix("app"), xconfig.WithPrefixCaseInsensitive())
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if value.Redis.Host != "from-lower" || value.Redis.Port != 6380 {
+		t.Errorf("expected Redis from the prefixed envs, got: %+v", value.Redis)
+	}
+}
@@ -21,6 +21,18 @@ type options struct {
 
 	// EnvPrefix is the prefix for environment variables.
 	envPrefix string
+	// EnvPrefixFold set to true matches the prefix in any case.
+	envPrefixFold bool
+
+	// Explain set to true records which plugin set each field.
+	explain bool
+
+	// ReservedKeys are the keys which keep the value of the first source
+	// that sets them.
+	reservedKeys []string
+
+	// AfterDecode is called with the config once every plugin has parsed.
+	afterDecode func(conf any) error
 
 	loader  *loader.Loader
 	plugins []plugins.Plugin
@@ -62,6 +74,50 @@ func WithEnvPrefix(prefix string) Option {
 	}
 }
 
+// WithPrefixCaseInsensitive matches the prefix of WithEnvPrefix in any case,
+// so with the prefix "app" both APP_REDIS_HOST and app_REDIS_HOST set
+// Redis.Host, the rest of the name still matching exactly. An upper-case
+// prefix wins over the other cases.
+func WithPrefixCaseInsensitive() Option {
+	return func(o *options) {
+		o.envPrefixFold = true
+	}
+}
+
+// WithExplain records which plugin set the final value of each field,
+// so that Explain can report it.
+func WithExplain() Option {
+	return func(o *options) {
+		o.explain = true
+	}
+}
+
+// WithReservedKeys locks each of the keys once a source sets it: the
+// sources after it in the load order, which would otherwise override it,
+// leave it unchanged. A key is a dotted field path, e.g. "Instance.ID", or
+// the environment variable name of the field without the prefix, e.g.
+// "INSTANCE_ID".
+//
+// A reserved key still takes the value of any source that sets it first,
+// even the one loaded last, such as a flag. The "default" tag and
+// SetDefaults do not lock it. Parse fails if a key matches no field.
+func WithReservedKeys(keys ...string) Option {
+	return func(o *options) {
+		o.reservedKeys = append(o.reservedKeys, keys...)
+	}
+}
+
+// WithAfterDecode calls hook with the config once every plugin has parsed
+// it without error, for the finalization concerning the whole config, such
+// as computing derived fields. Plugins include the validate plugin, so the
+// hook runs after the Validate methods and can rely on a validated config.
+// An error from hook fails Parse.
+func WithAfterDecode(hook func(conf any) error) Option {
+	return func(o *options) {
+		o.afterDecode = hook
+	}
+}
+
 func WithLoader(loader *loader.Loader) Option {
 	return func(o *options) {
 		o.loader = loader
@@ -18,6 +18,14 @@ type visitor struct {
 	config any
 }
 
+func (v *visitor) Name() string {
+	return "customdefaults"
+}
+
+func (v *visitor) IsDefault() bool {
+	return true
+}
+
 func (v *visitor) Parse() error {
 	if v.config == nil {
 		return nil
@@ -21,6 +21,14 @@ type visitor struct {
 	fields flat.Fields
 }
 
+func (v *visitor) Name() string {
+	return "defaults"
+}
+
+func (v *visitor) IsDefault() bool {
+	return true
+}
+
 func (v *visitor) Visit(f flat.Fields) error {
 	v.fields = f
 
@@ -3,6 +3,7 @@ package env
 
 import (
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/dv-net/xconfig/flat"
@@ -15,16 +16,44 @@ func init() {
 	plugins.RegisterTag(tag)
 }
 
+// Option configures the env plugin.
+type Option func(*visitor)
+
+// WithPrefixCaseInsensitive matches the prefix of the variables in any
+// case, so with the prefix "app" both APP_REDIS_HOST and app_REDIS_HOST set
+// Redis.Host. The rest of the name is still matched exactly, and so are the
+// names given by an env tag, which take no prefix. When several variables
+// differ only in the case of their prefix, the upper-case one wins, then
+// the first one in sorted order.
+func WithPrefixCaseInsensitive() Option {
+	return func(v *visitor) {
+		v.prefixFold = true
+	}
+}
+
 // New returns an EnvSet.
-func New(prefix string) plugins.Plugin {
-	return &visitor{
+func New(prefix string, opts ...Option) plugins.Plugin {
+	v := &visitor{
 		prefix: prefix,
 	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
 }
 
 type visitor struct {
 	fields flat.Fields
 	prefix string
+
+	// prefixFold matches the prefix in any case.
+	prefixFold bool
+	// prefixed holds the names given the prefix.
+	prefixed map[string]bool
+}
+
+func (v *visitor) Name() string {
+	return "env"
 }
 
 func makeEnvName(prefix, name string) string {
@@ -37,11 +66,13 @@ func makeEnvName(prefix, name string) string {
 
 func (v *visitor) Visit(f flat.Fields) error {
 	v.fields = f
+	v.prefixed = make(map[string]bool)
 
 	for _, f := range v.fields {
 		name, ok := f.Tag(tag)
 		if !ok || name == "" {
 			name = makeEnvName(v.prefix, f.EnvName())
+			v.prefixed[name] = true
 		}
 
 		f.Meta()[tag] = name
@@ -51,6 +82,8 @@ func (v *visitor) Visit(f flat.Fields) error {
 }
 
 func (v *visitor) Parse() error {
+	folded := v.foldedEnv()
+
 	for _, f := range v.fields {
 		name, ok := f.Meta()[tag]
 		if !ok || name == "-" {
@@ -58,6 +91,9 @@ func (v *visitor) Parse() error {
 		}
 
 		value, ok := os.LookupEnv(name)
+		if !ok && v.prefixed[name] {
+			value, ok = folded[name]
+		}
 
 		if !ok {
 			continue
@@ -71,3 +107,30 @@ func (v *visitor) Parse() error {
 
 	return nil
 }
+
+// foldedEnv returns the variables whose prefix matches the prefix in
+// another case, by their name with the upper-case prefix. It is nil unless
+// the prefix is matched case-insensitively.
+func (v *visitor) foldedEnv() map[string]string {
+	if !v.prefixFold || v.prefix == "" {
+		return nil
+	}
+
+	prefix := strings.ToUpper(v.prefix) + "_"
+	env := os.Environ()
+	sort.Strings(env)
+
+	folded := make(map[string]string)
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		if len(key) <= len(prefix) || !strings.EqualFold(key[:len(prefix)], prefix) {
+			continue
+		}
+		name := prefix + key[len(prefix):]
+		if _, ok := folded[name]; !ok {
+			folded[name] = value
+		}
+	}
+
+	return folded
+}
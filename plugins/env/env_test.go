@@ -106,3 +106,52 @@ func TestEnvTag(t *testing.T) {
 		t.Error(diff)
 	}
 }
+
+type fPrefixed struct {
+	Host  string
+	Port  int
+	Debug bool
+	Name  string `env:"app_NAME"`
+}
+
+func TestEnvPrefixCaseInsensitive(t *testing.T) {
+	envs := map[string]string{
+		"app_HOST":  "lower",
+		"App_PORT":  "8080",
+		"APP_DEBUG": "true",
+		"app_DEBUG": "false",
+		"app_host":  "rest-not-folded",
+		"app_NAME":  "tagged",
+	}
+
+	for key, value := range envs {
+		t.Setenv(key, value)
+	}
+
+	// without the option, only the upper-case prefix matches
+	value := fPrefixed{}
+	conf, err := xconfig.Custom(&value, env.New("app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(fPrefixed{Debug: true, Name: "tagged"}, value); diff != "" {
+		t.Error(diff)
+	}
+
+	// with it, app_ and App_ match too, the upper-case prefix still winning
+	value = fPrefixed{}
+	conf, err = xconfig.Custom(&value, env.New("app", env.WithPrefixCaseInsensitive()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	expect := fPrefixed{Host: "lower", Port: 8080, Debug: true, Name: "tagged"}
+	if diff := cmp.Diff(expect, value); diff != "" {
+		t.Error(diff)
+	}
+}
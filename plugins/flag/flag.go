@@ -51,6 +51,10 @@ type visitor struct {
 	args []string
 }
 
+func (v *visitor) Name() string {
+	return "flag"
+}
+
 func (v *visitor) Parse() error {
 	err := v.fs.Parse(v.args)
 
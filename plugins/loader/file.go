@@ -160,6 +160,15 @@ type walker struct {
 	err error
 }
 
+// Name returns the file path, or "reader" for a plugin made by NewReader.
+func (v *walker) Name() string {
+	if v.filepath == "" {
+		return "reader"
+	}
+
+	return v.filepath
+}
+
 func (v *walker) Walk(conf any) error {
 	if v.err != nil {
 		return v.err
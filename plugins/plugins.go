@@ -31,6 +31,20 @@ type Visitor interface {
 	Visit(fields flat.Fields) error
 }
 
+// Namer is implemented by providers that can name the source they
+// load from. The name is what xconfig.Explain reports for the fields
+// the provider sets.
+type Namer interface {
+	Name() string
+}
+
+// Defaulter is implemented by providers that only set default values,
+// like the "default" tag. Reserved keys they set stay unlocked, so that
+// any other source can still set them once.
+type Defaulter interface {
+	IsDefault() bool
+}
+
 var tags = map[string]string{}
 
 // ErrUsage is returned when user has request usage message
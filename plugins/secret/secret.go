@@ -27,6 +27,10 @@ type secret struct {
 	source Sourcer
 }
 
+func (v *secret) Name() string {
+	return "secret"
+}
+
 func makeSecretName(name string) string {
 	name = strings.ReplaceAll(name, ".", "_")
 	name = strings.ToUpper(name)
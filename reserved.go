@@ -0,0 +1,56 @@
+package xconfig
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/dv-net/xconfig/plugins"
+)
+
+// reservedFields marks the fields named by the reserved keys. A key names a
+// field by its dotted path, as reported by Explain, or by its environment
+// variable name without the prefix, e.g. "Instance.ID" or "INSTANCE_ID".
+// It returns nil if there are no reserved keys.
+func (c *config) reservedFields() ([]bool, error) {
+	if c.options == nil || len(c.options.reservedKeys) == 0 {
+		return nil, nil
+	}
+
+	reserved := make([]bool, len(c.fields))
+	for _, key := range c.options.reservedKeys {
+		found := false
+		for i, f := range c.fields {
+			if f.Name() == key || f.EnvName() == key {
+				reserved[i] = true
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("reserved key %q does not match any field", key)
+		}
+	}
+
+	return reserved, nil
+}
+
+// lock undoes the changes p made to reserved fields already set by an
+// earlier plugin, and locks the reserved fields p set first. Plugins that
+// implement plugins.Defaulter do not lock the fields they set, so that any
+// other source can still set them once.
+func (c *config) lock(p plugins.Plugin, before []reflect.Value, reserved, locked []bool) {
+	d, ok := p.(plugins.Defaulter)
+	defaults := ok && d.IsDefault()
+	for i, f := range c.fields {
+		if !reserved[i] || reflect.DeepEqual(before[i].Interface(), f.FieldValue().Interface()) {
+			continue
+		}
+
+		switch {
+		case locked[i]:
+			f.FieldValue().Set(before[i])
+		case !defaults:
+			locked[i] = true
+		}
+	}
+}
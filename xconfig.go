@@ -3,6 +3,7 @@ package xconfig
 
 import (
 	"errors"
+	"reflect"
 
 	"github.com/dv-net/xconfig/flat"
 	"github.com/dv-net/xconfig/plugins"
@@ -58,6 +59,7 @@ type config struct {
 	conf    any
 	fields  flat.Fields
 	options *options
+	sources map[string]string
 }
 
 // Options returns the options for the config.
@@ -103,11 +105,41 @@ func (c *config) addPlugin(plug plugins.Plugin) error { //nolint:funcorder
 }
 
 func (c *config) Parse() error {
+	explain := c.options != nil && c.options.explain
+	c.sources = nil
+	if explain {
+		c.sources = make(map[string]string)
+	}
+	defer c.storeExplain()
+
+	reserved, err := c.reservedFields()
+	if err != nil {
+		return err
+	}
+	locked := make([]bool, len(c.fields))
+
 	for _, p := range c.plugins {
-		err := p.Parse()
+		var before []reflect.Value
+		if explain || reserved != nil {
+			before = snapshot(c.fields)
+		}
+
+		err = p.Parse()
 		if err != nil {
 			return err
 		}
+
+		if reserved != nil {
+			c.lock(p, before, reserved, locked)
+		}
+
+		if explain {
+			c.record(p, before)
+		}
+	}
+
+	if c.options != nil && c.options.afterDecode != nil {
+		return c.options.afterDecode(c.conf)
 	}
 
 	return nil